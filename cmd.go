@@ -1,19 +1,241 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"iter"
 	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"text/tabwriter"
 	"time"
 
+	"github.com/foursixnine/imdblookup/internal/cache"
 	"github.com/foursixnine/imdblookup/internal/client"
 	ce "github.com/foursixnine/imdblookup/internal/errors"
+	"github.com/foursixnine/imdblookup/internal/graph"
+	"github.com/foursixnine/imdblookup/internal/heatmap"
+	"github.com/foursixnine/imdblookup/internal/ids"
+	"github.com/foursixnine/imdblookup/internal/library"
+	"github.com/foursixnine/imdblookup/internal/nfo"
+	"github.com/foursixnine/imdblookup/internal/output"
+	"github.com/foursixnine/imdblookup/internal/rename"
+	"github.com/foursixnine/imdblookup/internal/resolve"
+	"github.com/foursixnine/imdblookup/internal/store"
+	"github.com/foursixnine/imdblookup/internal/watchlist"
+	"github.com/foursixnine/imdblookup/models"
+	"github.com/go-openapi/strfmt"
 )
 
-func getTitles(imdbClient *client.ImdbClient, query string, wg *sync.WaitGroup, done chan struct{}, result *ce.IMDBClientApplicationError) {
+type command struct {
+	name     string
+	synopsis string
+	summary  string
+	run      func(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{name: "search", synopsis: "[--limit n] [--query] <text>", summary: "Search titles by name", run: runSearch},
+		{name: "resolve", synopsis: "[--year y] [--country c] [--language l] [--limit n] <title>", summary: "Find the title meant by a name in any language, through AKAs", run: runResolve},
+		{name: "title", synopsis: "<title id>", summary: "Show a title", run: runTitle},
+		{name: "name", synopsis: "<name id>", summary: "Show a person", run: runName},
+		{name: "episodes", synopsis: "[--season s] <series id>", summary: "Show the episode guide of a series", run: runEpisodes},
+		{name: "heatmap", synopsis: "[--color auto|always|never] <series id>", summary: "Draw the episode ratings of a series as a season heatmap", run: runHeatmap},
+		{name: "path", synopsis: "[--depth n] [--category cast|crew|c,...] [--budget n] <name id> <name id>", summary: "Find the shortest chain of shared titles between two people", run: runPath},
+		{name: "credits", synopsis: "[--category c] [--limit n] <title id>", summary: "List cast and crew of a title", run: runCredits},
+		{name: "awards", synopsis: "[--limit n] <title id>", summary: "List award nominations of a title", run: runAwards},
+		{name: "boxoffice", synopsis: "<title id>", summary: "Show box office figures of a title", run: runBoxOffice},
+		{name: "starmeter", synopsis: "[--limit n]", summary: "Show the STARmeter chart", run: runStarMeter},
+		{name: "interests", synopsis: "[interest id]", summary: "List interest categories, or show one interest", run: runInterests},
+		{name: "sync", synopsis: "[--max-age d] [--dry-run]", summary: "Fetch again the records of the local store older than max-age", run: runSync},
+		{name: "scan", synopsis: "[--min-confidence f] [--candidates n] <directory>", summary: "Match the video files of a directory to titles from their names", run: runScan},
+		{name: "nfo", synopsis: "[--dry-run] [--diff] [--country c] [--min-confidence f] <directory>", summary: "Write Kodi and Jellyfin NFO files for the matched files of a directory", run: runNfo},
+		{name: "rename", synopsis: "plan [--plan file] [--to dir] [--collision skip|suffix|fail] <directory> | apply [--journal file] <plan file> | undo <journal file>", summary: "Move library files to paths built from their titles, in reviewable steps", run: runRename},
+		{name: "watchlist", synopsis: "[--file f] add [--tag t,...] <title id>... | remove <title id>... | list [--sort s] [--reverse] [--status pending|done|all] [--tag t] [--genre g] [--type t,...] | tag [--remove] <title id> <tag>... | done [--undo] <title id>...", summary: "Keep a local list of titles to review", run: runWatchlist},
+		{name: "help", synopsis: "[command]", summary: "Show help for a command", run: runHelp},
+	}
+
+	// output knows the columns of the api models, these are the ones of
+	// what commands build on top of them.
+	output.RegisterColumns(library.Match{}, "status", "confidence", "release.path", "title.id", "title.primaryTitle", "title.startYear")
+	output.RegisterColumns(resolve.Candidate{}, "confidence", "title.id", "title.primaryTitle", "title.startYear", "name")
+	output.RegisterColumns(watchlist.Entry{}, "id", "title.primaryTitle", "title.startYear", "title.rating.aggregateRating", "tags", "done")
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func printCommands(w io.Writer) {
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+func runCommand(ctx context.Context, imdbClient *client.ImdbClient, name string, args []string) error {
+	cmd := findCommand(name)
+	if cmd == nil {
+		printCommands(os.Stderr)
+		return usageError("unknown command %q", name)
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.String("output", "text", "Output format, one of text, "+strings.Join(output.Formats(), ", "))
+	fs.String("columns", "", "Comma separated columns for table and csv output, such as id,rating.aggregateRating")
+	fs.String("format", "", "Go template applied to every result, such as '{{.ID}}\\t{{.PrimaryTitle}} ({{.StartYear}})'")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: imdblookup %s %s\n\n%s\n", cmd.name, cmd.synopsis, cmd.summary)
+		fs.PrintDefaults()
+	}
+
+	err := cmd.run(ctx, imdbClient, fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+func usageError(format string, a ...any) *ce.IMDBClientApplicationError {
+	err := ce.NewIMDBClientApplicationError(fmt.Sprintf(format, a...), nil)
+	err.Code = ce.USAGEERROR
+	return err
+}
+
+// parseArgs parses the command flags and checks the number of positional
+// arguments, maxArgs below zero meaning no upper bound.
+func parseArgs(fs *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError("%s: %v", fs.Name(), err)
+	}
+	if fs.NArg() < minArgs || (maxArgs >= 0 && fs.NArg() > maxArgs) {
+		fs.Usage()
+		return usageError("%s: unexpected number of arguments", fs.Name())
+	}
+	if format := outputFormat(fs); format != "text" && format != "template" && !slices.Contains(output.Formats(), format) {
+		return usageError("%s: unknown output format %q", fs.Name(), format)
+	}
+	if _, err := output.NewTemplate(fs.Lookup("format").Value.String()); err != nil {
+		return usageError("%s: invalid --format template: %v", fs.Name(), err)
+	}
+	return nil
+}
+
+// splitList splits a comma separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// outputFormat is the --output format, or "template" when --format is set.
+func outputFormat(fs *flag.FlagSet) string {
+	if fs.Lookup("format").Value.String() != "" {
+		return "template"
+	}
+	return fs.Lookup("output").Value.String()
+}
+
+// emit renders value in the requested output format, falling back to the
+// command's own text layout.
+func emit(fs *flag.FlagSet, value any, text func()) error {
+	format := outputFormat(fs)
+	switch format {
+	case "text":
+		text()
+		return nil
+	case "template":
+		tmpl, err := output.NewTemplate(fs.Lookup("format").Value.String())
+		if err != nil {
+			return err
+		}
+		return output.RenderTemplate(os.Stdout, tmpl, value)
+	}
+
+	var columns []string
+	for column := range strings.SplitSeq(fs.Lookup("columns").Value.String(), ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return output.Render(os.Stdout, format, value, output.Options{Columns: columns})
+}
+
+func runHelp(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 0, 1); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		flag.Usage()
+		return nil
+	}
+	return runCommand(ctx, imdbClient, fs.Arg(0), []string{"-h"})
+}
+
+func runSearch(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	query := fs.String("query", "", "Search query for IMDB titles")
+	limit := fs.Int("limit", 5, "Maximum number of results")
+	if err := parseArgs(fs, args, 0, -1); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		*query = strings.Join(fs.Args(), " ")
+	}
+
+	if outputFormat(fs) != "text" {
+		titles, err := imdbClient.SearchTitles(ctx, *query, *limit)
+		if err != nil {
+			if err.AppMessage == "Search title cannot be empty" {
+				err.Code = ce.EMPTYQUERYERROR
+			}
+			return err
+		}
+		return emit(fs, titles, nil)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	done := make(chan struct{})
+	result := &ce.IMDBClientApplicationError{}
+
+	go getTitles(ctx, imdbClient, *query, *limit, &wg, done, result)
+	go progressMarker(done)
+
+	wg.Wait()
+
+	if result.Code != ce.SUCCESS {
+		return result
+	}
+	return nil
+}
+
+func getTitles(ctx context.Context, imdbClient *client.ImdbClient, query string, limit int, wg *sync.WaitGroup, done chan struct{}, result *ce.IMDBClientApplicationError) {
 	defer wg.Done()
 	fmt.Println("Finding results:")
-	titles, err := imdbClient.FindShowsByTitle(&query)
+	titles, err := imdbClient.SearchTitles(ctx, query, limit)
 
 	if err != nil {
 		if err.AppMessage == "Search title cannot be empty" {
@@ -22,6 +244,11 @@ func getTitles(imdbClient *client.ImdbClient, query string, wg *sync.WaitGroup,
 			result.Code = ce.EMPTYQUERYERROR
 			return
 		}
+		if errors.Is(err, context.Canceled) {
+			*result = *err
+			result.Code = ce.CANCELLEDERROR
+			return
+		}
 		log.Printf("An unexpected error has occurred: (%v)\n", err)
 		*result = *err
 		result.Code = ce.GENERICERROR
@@ -58,3 +285,1119 @@ func progressMarker(done chan struct{}) {
 		}
 	}
 }
+
+func runResolve(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	year := fs.Int("year", 0, "Year the title came out, if known")
+	country := fs.String("country", "", "Country the title goes by that name in, such as DE")
+	language := fs.String("language", "", "Language of the title as given, such as deu")
+	limit := fs.Int("limit", 5, "Maximum number of candidates, 0 for all")
+	if err := parseArgs(fs, args, 1, -1); err != nil {
+		return err
+	}
+
+	resolver := resolve.NewResolver(resolve.NewClientSource(imdbClient), resolve.Options{Limit: *limit})
+	candidates, err := resolver.Resolve(ctx, resolve.Query{Title: strings.Join(fs.Args(), " "), Year: *year, Country: *country, Language: *language})
+	if err != nil {
+		return err
+	}
+
+	return emit(fs, candidates, func() {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, candidate := range candidates {
+			title := candidate.Title
+			label := cmp.Or(title.PrimaryTitle, title.OriginalTitle)
+			if title.StartYear != 0 {
+				label += fmt.Sprintf(" (%d)", title.StartYear)
+			}
+			via := ""
+			if aka := candidate.AKA; aka != nil {
+				via = "as " + aka.Text
+				if aka.Country != nil {
+					via += fmt.Sprintf(" (%s)", aka.Country.Code)
+				}
+			}
+			fmt.Fprintf(tw, "%.2f\t%s\t%s\t%s\n", candidate.Confidence, title.ID, label, via)
+		}
+		tw.Flush()
+	})
+}
+
+func runTitle(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	titleID, err := ids.ParseTitleID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	title, appErr := imdbClient.GetTitle(ctx, titleID)
+	if appErr != nil {
+		return appErr
+	}
+
+	return emit(fs, title, func() {
+		fmt.Printf("(%s)\t-> \"%s\" (%d)\n", title.ID, title.PrimaryTitle, title.StartYear)
+		fmt.Printf("Type:\t%s\n", title.Type)
+		fmt.Printf("Genres:\t%s\n", strings.Join(title.Genres, ", "))
+		if title.Rating != nil {
+			fmt.Printf("Rating:\t%.1f (%d votes)\n", title.Rating.AggregateRating, title.Rating.VoteCount)
+		}
+		if title.Plot != "" {
+			fmt.Printf("Plot:\t%s\n", title.Plot)
+		}
+	})
+}
+
+func runName(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	nameID, err := ids.ParseNameID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	name, appErr := imdbClient.GetName(ctx, nameID)
+	if appErr != nil {
+		return appErr
+	}
+
+	return emit(fs, name, func() {
+		fmt.Printf("(%s)\t-> \"%s\"\n", name.ID, name.DisplayName)
+		fmt.Printf("Professions:\t%s\n", strings.Join(name.PrimaryProfessions, ", "))
+		if name.BirthDate != nil {
+			fmt.Printf("Born:\t%04d-%02d-%02d %s\n", name.BirthDate.Year, name.BirthDate.Month, name.BirthDate.Day, name.BirthLocation)
+		}
+	})
+}
+
+func runEpisodes(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	season := fs.String("season", "", "Only list episodes of this season")
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	seriesID, err := ids.ParseTitleID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	guide, appErr := imdbClient.EpisodeGuide(ctx, seriesID)
+	if appErr != nil {
+		return appErr
+	}
+	if *season != "" {
+		guide.Seasons = slices.DeleteFunc(guide.Seasons, func(s *client.SeasonGuide) bool { return s.Season != *season })
+	}
+
+	return emit(fs, guide.Episodes(), func() { printEpisodeGuide(os.Stdout, guide) })
+}
+
+func printEpisodeGuide(w io.Writer, guide *client.EpisodeGuide) {
+	for i, season := range guide.Seasons {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		switch season.Kind {
+		case client.SeasonRegular:
+			fmt.Fprintf(w, "Season %d", season.Number)
+		case client.SeasonSpecials:
+			fmt.Fprint(w, "Specials")
+		default:
+			fmt.Fprintf(w, "Unknown season %q", season.Season)
+		}
+		fmt.Fprintf(w, " (%d episodes)\n", len(season.Episodes))
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, episode := range season.Episodes {
+			number := "-"
+			if episode.EpisodeNumber > 0 {
+				number = fmt.Sprintf("E%02d", episode.EpisodeNumber)
+			}
+			airDate := cmp.Or(output.Date(episode.ReleaseDate), "unaired")
+			rating := "-"
+			if episode.Rating != nil && episode.Rating.AggregateRating > 0 {
+				rating = fmt.Sprintf("%.1f", episode.Rating.AggregateRating)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t(%s)\n", number, airDate, rating, episode.Title, episode.ID)
+		}
+		tw.Flush()
+	}
+}
+
+func runHeatmap(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	color := fs.String("color", "auto", "Colour the cells: auto, always or never")
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	opts := heatmap.Options{}
+	switch *color {
+	case "auto":
+		opts.Color = isTerminal(os.Stdout)
+	case "always":
+		opts.Color = true
+	case "never":
+	default:
+		return usageError("%s: unknown --color %q", fs.Name(), *color)
+	}
+
+	seriesID, err := ids.ParseTitleID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	guide, appErr := imdbClient.EpisodeGuide(ctx, seriesID)
+	if appErr != nil {
+		return appErr
+	}
+
+	if outputFormat(fs) == "text" {
+		return heatmap.Render(os.Stdout, guide, opts)
+	}
+	return emit(fs, heatmap.Summarize(guide), nil)
+}
+
+// isTerminal reports whether f is a character device, which is as close to
+// a TTY check as the standard library gets.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func runPath(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	depth := fs.Int("depth", graph.DefaultMaxDepth, "Maximum number of titles between the two people")
+	category := fs.String("category", "", "Only follow these credit categories, cast, crew or a comma separated list such as actor,director")
+	budget := fs.Int("budget", 500, "Maximum number of filmography and credits lookups, 0 for no limit")
+	if err := parseArgs(fs, args, 2, 2); err != nil {
+		return err
+	}
+
+	var endpoints [2]string
+	for i := range endpoints {
+		id, err := ids.ParseNameID(fs.Arg(i))
+		if err != nil {
+			return err
+		}
+		endpoints[i] = id.String()
+	}
+
+	opts := graph.Options{MaxDepth: *depth, Budget: *budget}
+	for c := range strings.SplitSeq(*category, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			opts.Categories = append(opts.Categories, c)
+		}
+	}
+	var ttl cache.TTLPolicy
+	opts.Cache, ttl = imdbClient.Cache()
+	opts.CacheTTL = ttl.For("graph:")
+
+	path, err := graph.Find(ctx, graph.NewClientSource(imdbClient), endpoints[0], endpoints[1], opts)
+	if errors.Is(err, graph.ErrNoPath) {
+		appErr := ce.NewIMDBClientApplicationError(err.Error(), err)
+		appErr.Code = ce.NOTFOUNDERROR
+		return appErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return emit(fs, path, func() {
+		fmt.Printf("%d degrees, %d lookups\n", path.Degrees(), path.Lookups)
+		for i, node := range path.Nodes {
+			label := cmp.Or(node.Label, node.ID)
+			if i%2 == 1 {
+				fmt.Printf("  in \"%s\" (%s)\n", label, node.ID)
+				continue
+			}
+			fmt.Printf("(%s)\t-> \"%s\"\n", node.ID, label)
+		}
+	})
+}
+
+func runCredits(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	category := fs.String("category", "", "Comma separated credit categories, such as actor,director")
+	limit := fs.Int("limit", 0, "Maximum number of credits, 0 for all")
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	var params []client.QueryParameters
+	for c := range strings.SplitSeq(*category, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			params = append(params, client.QueryParameters{Key: "categories", Value: c})
+		}
+	}
+
+	titleID, err := ids.ParseTitleID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return printAll(fs, imdbClient.TitleCredits(ctx, titleID, params...), *limit, func(credit *models.ImdbapiCredit) {
+		if credit.Name == nil {
+			return
+		}
+		fmt.Printf("(%s)\t-> \"%s\"\t%s\t%s\n", credit.Name.ID, credit.Name.DisplayName, credit.Category, strings.Join(credit.Characters, ", "))
+	})
+}
+
+func runAwards(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	limit := fs.Int("limit", 0, "Maximum number of nominations, 0 for all")
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	titleID, err := ids.ParseTitleID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return printAll(fs, imdbClient.TitleAwardNominations(ctx, titleID), *limit, func(nomination *models.ImdbapiAwardNomination) {
+		result := "nominee"
+		if nomination.IsWinner {
+			result = "winner"
+		}
+		event := ""
+		if nomination.Event != nil {
+			event = nomination.Event.Name
+		}
+		fmt.Printf("%d\t%s\t%s\t%s\n", nomination.Year, event, nomination.Category, result)
+	})
+}
+
+func runBoxOffice(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	titleID, err := ids.ParseTitleID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	boxOffice, appErr := imdbClient.TitleBoxOffice(ctx, titleID)
+	if appErr != nil {
+		return appErr
+	}
+
+	money := func(m *models.ImdbapiMoney) string {
+		if m == nil {
+			return "-"
+		}
+		return m.Amount + " " + m.Currency
+	}
+	return emit(fs, boxOffice, func() {
+		fmt.Printf("Budget:\t%s\n", money(boxOffice.ProductionBudget))
+		fmt.Printf("Domestic:\t%s\n", money(boxOffice.DomesticGross))
+		fmt.Printf("Worldwide:\t%s\n", money(boxOffice.WorldwideGross))
+		if boxOffice.OpeningWeekendGross != nil {
+			fmt.Printf("Opening weekend:\t%s\n", money(boxOffice.OpeningWeekendGross.Gross))
+		}
+	})
+}
+
+func runStarMeter(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	limit := fs.Int("limit", 20, "Maximum number of names, 0 for all")
+	if err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	return printAll(fs, imdbClient.StarMeter(ctx), *limit, func(name *models.ImdbapiName) {
+		rank := int32(0)
+		if name.MeterRanking != nil {
+			rank = name.MeterRanking.CurrentRank
+		}
+		fmt.Printf("%d\t(%s)\t-> \"%s\"\n", rank, name.ID, name.DisplayName)
+	})
+}
+
+func runInterests(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 0, 1); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 1 {
+		interestID, err := ids.ParseInterestID(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		interest, appErr := imdbClient.GetInterest(ctx, interestID)
+		if appErr != nil {
+			return appErr
+		}
+		return emit(fs, interest, func() {
+			fmt.Printf("(%s)\t-> \"%s\"\n", interest.ID, interest.Name)
+			if interest.Description != "" {
+				fmt.Println(interest.Description)
+			}
+		})
+	}
+
+	categories, err := imdbClient.InterestCategories(ctx)
+	if err != nil {
+		return err
+	}
+	return emit(fs, categories, func() {
+		for _, category := range categories {
+			fmt.Printf("%s:\n", category.Category)
+			for _, interest := range category.Interests {
+				fmt.Printf("  (%s)\t-> \"%s\"\n", interest.ID, interest.Name)
+			}
+		}
+	})
+}
+
+func runSync(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	maxAge := fs.Duration("max-age", 24*time.Hour, "Refresh records fetched longer ago than this")
+	dryRun := fs.Bool("dry-run", false, "Only list the records that would be refreshed")
+	if err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	db, ok := imdbClient.Store().(*store.Store)
+	if !ok {
+		return usageError("%s: the local store is disabled", fs.Name())
+	}
+	stale, err := db.Stale(ctx, *maxAge)
+	if err != nil {
+		return ce.NewIMDBClientApplicationError("Listing stale records failed", err)
+	}
+	if *dryRun {
+		return emit(fs, stale, func() {
+			for _, record := range stale {
+				fmt.Printf("%s\t%s\t%s\n", record.Kind, record.ID, record.FetchedAt.Format(time.RFC3339))
+			}
+		})
+	}
+
+	started := time.Now()
+	refreshCtx := client.WithRefresh(ctx)
+	failed := 0
+	for _, record := range stale {
+		if err := syncRecord(refreshCtx, imdbClient, db, record, started); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			log.Printf("Refreshing %s %s failed: %v\n", record.Kind, record.ID, err)
+			failed++
+			continue
+		}
+		if outputFormat(fs) == "text" {
+			fmt.Printf("%s\t%s\trefreshed\n", record.Kind, record.ID)
+		}
+	}
+	if outputFormat(fs) != "text" {
+		if err := emit(fs, stale, nil); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return ce.NewIMDBClientApplicationError(fmt.Sprintf("%d of %d records could not be refreshed", failed, len(stale)), nil)
+	}
+	return nil
+}
+
+func syncRecord(ctx context.Context, imdbClient *client.ImdbClient, db *store.Store, record store.Record, started time.Time) error {
+	switch record.Kind {
+	case store.KindTitle:
+		if _, err := imdbClient.GetTitle(ctx, ids.TitleID(record.ID)); err != nil {
+			return err
+		}
+	case store.KindName:
+		if _, err := imdbClient.GetName(ctx, ids.NameID(record.ID)); err != nil {
+			return err
+		}
+	case store.KindCredits:
+		if err := drain(imdbClient.TitleCredits(ctx, ids.TitleID(record.ID))); err != nil {
+			return err
+		}
+		return db.Prune(ctx, record, started)
+	case store.KindEpisodes:
+		// Through the guide so the seasons are saved again too.
+		if _, appErr := imdbClient.EpisodeGuide(ctx, ids.TitleID(record.ID)); appErr != nil {
+			return appErr
+		}
+		return db.Prune(ctx, record, started)
+	}
+	return nil
+}
+
+func runScan(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	minConfidence := fs.Float64("min-confidence", library.DefaultMinConfidence, "Lowest confidence, between 0 and 1, a match is accepted at")
+	candidates := fs.Int("candidates", 3, "Number of scored candidates kept for each file, 0 for all")
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	matches, err := matchLibrary(ctx, imdbClient, fs.Arg(0), library.Options{MinConfidence: *minConfidence, Candidates: *candidates})
+	if err != nil {
+		return err
+	}
+	counts := map[string]int{}
+	for _, match := range matches {
+		counts[match.Status]++
+	}
+
+	return emit(fs, matches, func() {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, match := range matches {
+			found := cmp.Or(match.Error, "-")
+			if title := match.Title; title != nil {
+				found = cmp.Or(title.PrimaryTitle, title.OriginalTitle)
+				if title.StartYear != 0 {
+					found += fmt.Sprintf(" (%d)", title.StartYear)
+				}
+				found += fmt.Sprintf(" (%s)", title.ID)
+			}
+			fmt.Fprintf(tw, "%s\t%.2f\t%s\t%s\n", match.Status, match.Confidence, match.Release.Path, found)
+		}
+		tw.Flush()
+		fmt.Printf("%d files: %d matched, %d ambiguous, %d unmatched\n", len(matches), counts[library.Matched], counts[library.Ambiguous], counts[library.Unmatched])
+	})
+}
+
+// matchLibrary scans dir and matches every video file found to a title.
+func matchLibrary(ctx context.Context, imdbClient *client.ImdbClient, dir string, opts library.Options) ([]library.Match, error) {
+	releases, err := library.Scan(dir)
+	if err != nil {
+		return nil, ce.NewIMDBClientApplicationError("Scanning the library failed", err)
+	}
+
+	matcher := library.NewMatcher(library.NewClientSource(imdbClient), opts)
+	matches := make([]library.Match, 0, len(releases))
+	for _, release := range releases {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		matches = append(matches, matcher.Match(ctx, release))
+	}
+	return matches, nil
+}
+
+func runNfo(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	minConfidence := fs.Float64("min-confidence", library.DefaultMinConfidence, "Lowest confidence, between 0 and 1, a match is accepted at")
+	dryRun := fs.Bool("dry-run", false, "Only report what would be written")
+	diff := fs.Bool("diff", false, "Show how existing NFO files would change")
+	country := fs.String("country", "US", "Country whose certificate is written as the mpaa rating")
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	matches, err := matchLibrary(ctx, imdbClient, fs.Arg(0), library.Options{MinConfidence: *minConfidence})
+	if err != nil {
+		return err
+	}
+
+	writer := &nfoWriter{client: imdbClient, opts: nfo.Options{Country: *country}, dryRun: *dryRun, shows: showDirs(fs.Arg(0), matches), details: map[ids.TitleID]nfo.Details{}, guides: map[string]*client.EpisodeGuide{}, written: map[string]bool{}}
+	videosIn := map[string]int{}
+	for _, match := range matches {
+		videosIn[filepath.Dir(match.Release.Path)]++
+	}
+
+	var changes []nfo.Change
+	failed := 0
+	for _, match := range matches {
+		if match.Status != library.Matched {
+			log.Printf("Skipping %s, it is %s\n", match.Release.Path, match.Status)
+			continue
+		}
+		written, err := writer.write(ctx, match, videosIn[filepath.Dir(match.Release.Path)] > 1)
+		changes = append(changes, written...)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			log.Printf("Writing the NFO of %s failed: %v\n", match.Release.Path, err)
+			failed++
+		}
+	}
+
+	if err := emit(fs, changes, func() {
+		for _, change := range changes {
+			fmt.Printf("%s\t%s\n", change.Action, change.Path)
+			if *diff && change.Diff != "" {
+				fmt.Print(change.Diff)
+			}
+		}
+	}); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return ce.NewIMDBClientApplicationError(fmt.Sprintf("%d NFO files could not be written", failed), nil)
+	}
+	return nil
+}
+
+// nfoWriter fetches what the NFOs of a library need, once per title.
+type nfoWriter struct {
+	client  *client.ImdbClient
+	opts    nfo.Options
+	dryRun  bool
+	shows   map[string]bool
+	details map[ids.TitleID]nfo.Details
+	guides  map[string]*client.EpisodeGuide
+	// written holds the tvshow.nfo paths already handled.
+	written map[string]bool
+}
+
+// write produces the NFO of a matched file, and for an episode in a show
+// directory the tvshow.nfo of its series the first time the series comes
+// up.
+func (w *nfoWriter) write(ctx context.Context, match library.Match, sharesDirectory bool) ([]nfo.Change, error) {
+	details, err := w.lookup(ctx, ids.TitleID(match.Title.ID))
+	if err != nil {
+		return nil, err
+	}
+	if !match.Release.IsEpisode() {
+		change, err := w.save(nfo.MoviePath(match.Release.Path, sharesDirectory), nfo.NewMovie(details, w.opts))
+		return []nfo.Change{change}, err
+	}
+
+	var changes []nfo.Change
+	showDir := library.ShowDir(match.Release.Path)
+	if showPath := nfo.TVShowPath(showDir); !w.written[showPath] {
+		w.written[showPath] = true
+		if !w.shows[showDir] {
+			log.Printf("Not writing a tvshow.nfo in %s, it is not the directory of one series\n", showDir)
+		} else {
+			change, err := w.save(showPath, nfo.NewTVShow(details, w.opts))
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	guide, ok := w.guides[match.Title.ID]
+	if !ok {
+		var appErr *ce.IMDBClientApplicationError
+		if guide, appErr = w.client.EpisodeGuide(ctx, ids.TitleID(match.Title.ID)); appErr != nil {
+			return changes, appErr
+		}
+		w.guides[match.Title.ID] = guide
+	}
+	episode := findEpisode(guide, match.Release)
+	if episode == nil {
+		return changes, fmt.Errorf("%s has no episode S%02dE%02d", match.Title.ID, match.Release.Season, match.Release.Episode)
+	}
+	change, err := w.save(nfo.EpisodePath(match.Release.Path), nfo.NewEpisode(details.Title, episode))
+	return append(changes, change), err
+}
+
+// showDirs tells the directories that hold a single series, the only ones
+// a tvshow.nfo goes in. The library root, or a directory shared with
+// movies or other series, is not one even when episodes sit in it.
+func showDirs(root string, matches []library.Match) map[string]bool {
+	titles := map[string]map[string]bool{}
+	for _, match := range matches {
+		if match.Status != library.Matched {
+			continue
+		}
+		dir := filepath.Dir(match.Release.Path)
+		if match.Release.IsEpisode() {
+			dir = library.ShowDir(match.Release.Path)
+		}
+		if titles[dir] == nil {
+			titles[dir] = map[string]bool{}
+		}
+		titles[dir][match.Title.ID] = true
+	}
+	shows := map[string]bool{}
+	for dir, titleIDs := range titles {
+		shows[dir] = len(titleIDs) == 1 && filepath.Clean(dir) != filepath.Clean(root)
+	}
+	return shows
+}
+
+// findEpisode is the episode of guide a release names, nil when the guide
+// does not have it.
+func findEpisode(guide *client.EpisodeGuide, release library.Release) *models.ImdbapiEpisode {
+	season := strconv.Itoa(release.Season)
+	episodes := guide.Episodes()
+	i := slices.IndexFunc(episodes, func(episode *models.ImdbapiEpisode) bool {
+		return episode.Season == season && int(episode.EpisodeNumber) == release.Episode
+	})
+	if i < 0 {
+		return nil
+	}
+	return episodes[i]
+}
+
+func (w *nfoWriter) lookup(ctx context.Context, titleID ids.TitleID) (nfo.Details, error) {
+	if details, ok := w.details[titleID]; ok {
+		return details, nil
+	}
+
+	title, appErr := w.client.GetTitle(ctx, titleID)
+	if appErr != nil {
+		return nfo.Details{}, appErr
+	}
+	details := nfo.Details{Title: title}
+	for credit, err := range w.client.TitleCredits(ctx, titleID) {
+		if err != nil {
+			return nfo.Details{}, err
+		}
+		details.Credits = append(details.Credits, credit)
+	}
+	// Not every title has certificates, the NFO just goes without.
+	details.Certificates, _ = w.client.TitleCertificates(ctx, titleID)
+
+	w.details[titleID] = details
+	return details, nil
+}
+
+func (w *nfoWriter) save(path string, document any) (nfo.Change, error) {
+	data, err := nfo.Marshal(document)
+	if err != nil {
+		return nfo.Change{Path: path}, err
+	}
+	return nfo.Write(path, data, w.dryRun)
+}
+
+func runRename(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	// Output flags may come before the action.
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if err := parseArgs(fs, args, 1, -1); err != nil {
+			return err
+		}
+		args = fs.Args()
+	}
+	switch args[0] {
+	case "plan":
+		return runRenamePlan(ctx, imdbClient, fs, args[1:])
+	case "apply":
+		return runRenameApply(fs, args[1:])
+	case "undo":
+		return runRenameUndo(fs, args[1:])
+	}
+	fs.Usage()
+	return usageError("%s: unknown action %q, want plan, apply or undo", fs.Name(), args[0])
+}
+
+func runRenamePlan(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	to := fs.String("to", "", "Directory the renamed files go under, defaults to the scanned one")
+	planPath := fs.String("plan", "", "Save the plan to this file, for rename apply")
+	movieTemplate := fs.String("movie-template", rename.DefaultMovieTemplate, "Template of the new path of movies, / separating directories")
+	episodeTemplate := fs.String("episode-template", rename.DefaultEpisodeTemplate, "Template of the new path of episodes, / separating directories")
+	collision := fs.String("collision", rename.Skip, "What to do when a target is taken: skip, suffix or fail")
+	minConfidence := fs.Float64("min-confidence", library.DefaultMinConfidence, "Lowest confidence, between 0 and 1, a match is accepted at")
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	opts := rename.Options{MovieTemplate: *movieTemplate, EpisodeTemplate: *episodeTemplate, Collision: *collision}
+	if _, _, err := rename.Templates(opts); err != nil {
+		return usageError("%s: %v", fs.Name(), err)
+	}
+	matches, err := matchLibrary(ctx, imdbClient, fs.Arg(0), library.Options{MinConfidence: *minConfidence})
+	if err != nil {
+		return err
+	}
+
+	titles := map[string]*models.ImdbapiTitle{}
+	guides := map[string]*client.EpisodeGuide{}
+	var items []rename.Item
+	var unmatched []rename.Skipped
+	for _, match := range matches {
+		path, err := filepath.Abs(match.Release.Path)
+		if err != nil {
+			return err
+		}
+		if match.Status != library.Matched {
+			unmatched = append(unmatched, rename.Skipped{Path: path, Reason: match.Status})
+			continue
+		}
+		title, ok := titles[match.Title.ID]
+		if !ok {
+			var appErr *ce.IMDBClientApplicationError
+			if title, appErr = imdbClient.GetTitle(ctx, ids.TitleID(match.Title.ID)); appErr != nil {
+				log.Printf("Looking up %s failed: %v\n", match.Title.ID, appErr)
+			}
+			titles[match.Title.ID] = title
+		}
+		if title == nil {
+			unmatched = append(unmatched, rename.Skipped{Path: path, Reason: "title lookup failed"})
+			continue
+		}
+
+		name := filepath.Base(match.Release.Path)
+		fields := rename.Fields{ImdbapiTitle: title, Season: match.Release.Season, Episode: match.Release.Episode, Ext: filepath.Ext(name), Name: strings.TrimSuffix(name, filepath.Ext(name))}
+		if match.Release.IsEpisode() {
+			guide, ok := guides[title.ID]
+			if !ok {
+				// Without a guide the episodes are named without their titles.
+				guide, _ = imdbClient.EpisodeGuide(ctx, ids.TitleID(title.ID))
+				guides[title.ID] = guide
+			}
+			if guide != nil {
+				if episode := findEpisode(guide, match.Release); episode != nil {
+					fields.EpisodeTitle = episode.Title
+				}
+			}
+		}
+		items = append(items, rename.Item{Path: match.Release.Path, Fields: fields})
+	}
+
+	plan, err := rename.NewPlan(cmp.Or(*to, fs.Arg(0)), items, opts)
+	if err != nil {
+		return ce.NewIMDBClientApplicationError(err.Error(), err)
+	}
+	plan.Skipped = append(unmatched, plan.Skipped...)
+	if *planPath != "" {
+		if err := plan.Save(*planPath); err != nil {
+			return ce.NewIMDBClientApplicationError("Saving the plan failed", err)
+		}
+	}
+
+	return emit(fs, plan, func() {
+		for _, move := range plan.Moves {
+			fmt.Printf("move\t%s\n\t-> %s\n", move.From, move.To)
+		}
+		for _, skipped := range plan.Skipped {
+			fmt.Printf("skip\t%s\t(%s)\n", skipped.Path, skipped.Reason)
+		}
+		fmt.Printf("%d moves, %d skipped\n", len(plan.Moves), len(plan.Skipped))
+		if *planPath != "" {
+			fmt.Printf("Review %s, then run: imdblookup rename apply %s\n", *planPath, *planPath)
+		}
+	})
+}
+
+func runRenameApply(fs *flag.FlagSet, args []string) error {
+	journal := fs.String("journal", "", "File recording the moves for rename undo, defaults to the plan file with .journal added")
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+	journalPath := cmp.Or(*journal, fs.Arg(0)+".journal")
+
+	plan, err := rename.LoadPlan(fs.Arg(0))
+	if err != nil {
+		return ce.NewIMDBClientApplicationError("Loading the plan failed", err)
+	}
+	moved, err := rename.Apply(plan, journalPath)
+	if emitErr := emit(fs, moved, func() {
+		for _, move := range moved {
+			fmt.Printf("moved\t%s\n\t-> %s\n", move.From, move.To)
+		}
+		if len(moved) > 0 {
+			fmt.Printf("Undo with: imdblookup rename undo %s\n", journalPath)
+		}
+	}); emitErr != nil {
+		return emitErr
+	}
+	if err != nil {
+		return ce.NewIMDBClientApplicationError(fmt.Sprintf("Applying the plan stopped after %d of %d moves: %v", len(moved), len(plan.Moves), err), err)
+	}
+	return nil
+}
+
+func runRenameUndo(fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	undone, err := rename.Undo(fs.Arg(0))
+	if emitErr := emit(fs, undone, func() {
+		for _, move := range undone {
+			fmt.Printf("restored\t%s\n\t-> %s\n", move.From, move.To)
+		}
+	}); emitErr != nil {
+		return emitErr
+	}
+	if err != nil {
+		return ce.NewIMDBClientApplicationError("Undoing the renames was incomplete: "+err.Error(), err)
+	}
+	return nil
+}
+
+func runWatchlist(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string) error {
+	file := fs.String("file", "", "Watchlist file, defaults to watchlist.json in the configuration directory")
+	// Output flags and --file may come before the action.
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if err := parseArgs(fs, args, 1, -1); err != nil {
+			return err
+		}
+		args = fs.Args()
+	}
+	var run func(*flag.FlagSet, []string, *string) (*watchlist.Watchlist, error)
+	switch args[0] {
+	case "add":
+		run = runWatchlistAdd
+	case "remove":
+		run = runWatchlistRemove
+	case "tag":
+		run = runWatchlistTag
+	case "done":
+		run = runWatchlistDone
+	case "list":
+		run = func(fs *flag.FlagSet, args []string, file *string) (*watchlist.Watchlist, error) {
+			return runWatchlistList(ctx, imdbClient, fs, args, file)
+		}
+	default:
+		fs.Usage()
+		return usageError("%s: unknown action %q, want add, remove, list, tag or done", fs.Name(), args[0])
+	}
+
+	list, err := run(fs, args[1:], file)
+	if list == nil {
+		return err
+	}
+	if saveErr := list.Save(); saveErr != nil {
+		return ce.NewIMDBClientApplicationError("Saving the watchlist failed", saveErr)
+	}
+	return err
+}
+
+// loadWatchlist parses args and loads the watchlist at file, or at the
+// default path when it is empty.
+func loadWatchlist(fs *flag.FlagSet, args []string, minArgs, maxArgs int, file *string) (*watchlist.Watchlist, error) {
+	if err := parseArgs(fs, args, minArgs, maxArgs); err != nil {
+		return nil, err
+	}
+	path := *file
+	if path == "" {
+		var err error
+		if path, err = watchlist.DefaultPath(); err != nil {
+			return nil, ce.NewIMDBClientApplicationError("Finding the watchlist failed", err)
+		}
+	}
+	list, err := watchlist.Load(path)
+	if err != nil {
+		return nil, ce.NewIMDBClientApplicationError("Loading the watchlist failed", err)
+	}
+	return list, nil
+}
+
+// parseTitleIDs canonicalises ids, which may be imdb.com urls.
+func parseTitleIDs(fs *flag.FlagSet, args []string) ([]ids.TitleID, error) {
+	var titleIDs []ids.TitleID
+	for _, arg := range args {
+		id, err := ids.ParseTitleID(arg)
+		if err != nil {
+			return nil, usageError("%s: %v", fs.Name(), err)
+		}
+		titleIDs = append(titleIDs, id)
+	}
+	return titleIDs, nil
+}
+
+// watchlistChanges reports the entries an action could not change, all of
+// the others having been.
+func watchlistChanges(failed []error) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	err := errors.Join(failed...)
+	return ce.NewIMDBClientApplicationError(fmt.Sprintf("%d titles could not be changed: %v", len(failed), err), err)
+}
+
+func runWatchlistAdd(fs *flag.FlagSet, args []string, file *string) (*watchlist.Watchlist, error) {
+	tags := fs.String("tag", "", "Comma separated tags given to the titles")
+	list, err := loadWatchlist(fs, args, 1, -1, file)
+	if err != nil {
+		return nil, err
+	}
+	titleIDs, err := parseTitleIDs(fs, fs.Args())
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*watchlist.Entry
+	var added []bool
+	for _, id := range titleIDs {
+		entry, isNew := list.Add(id, splitList(*tags)...)
+		entries, added = append(entries, entry), append(added, isNew)
+	}
+	return list, emit(fs, entries, func() {
+		for i, entry := range entries {
+			action := "listed"
+			if added[i] {
+				action = "added"
+			}
+			fmt.Printf("%s\t%s\n", action, entry.ID)
+		}
+	})
+}
+
+func runWatchlistRemove(fs *flag.FlagSet, args []string, file *string) (*watchlist.Watchlist, error) {
+	list, err := loadWatchlist(fs, args, 1, -1, file)
+	if err != nil {
+		return nil, err
+	}
+	titleIDs, err := parseTitleIDs(fs, fs.Args())
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []*watchlist.Entry
+	var failed []error
+	for _, id := range titleIDs {
+		entry, err := list.Entry(id)
+		if err == nil {
+			err = list.Remove(id)
+		}
+		if err != nil {
+			failed = append(failed, err)
+			continue
+		}
+		removed = append(removed, entry)
+	}
+	if err := emit(fs, removed, func() {
+		for _, entry := range removed {
+			fmt.Printf("removed\t%s\n", entry.ID)
+		}
+	}); err != nil {
+		return list, err
+	}
+	return list, watchlistChanges(failed)
+}
+
+func runWatchlistTag(fs *flag.FlagSet, args []string, file *string) (*watchlist.Watchlist, error) {
+	remove := fs.Bool("remove", false, "Take the tags off instead")
+	list, err := loadWatchlist(fs, args, 2, -1, file)
+	if err != nil {
+		return nil, err
+	}
+	titleIDs, err := parseTitleIDs(fs, fs.Args()[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := list.Entry(titleIDs[0])
+	if err != nil {
+		return nil, watchlistChanges([]error{err})
+	}
+	if *remove {
+		entry.Untag(fs.Args()[1:]...)
+	} else {
+		entry.Tag(fs.Args()[1:]...)
+	}
+	return list, emit(fs, entry, func() {
+		fmt.Printf("%s\t%s\n", entry.ID, strings.Join(entry.Tags, ","))
+	})
+}
+
+func runWatchlistDone(fs *flag.FlagSet, args []string, file *string) (*watchlist.Watchlist, error) {
+	undo := fs.Bool("undo", false, "Mark the titles as pending again")
+	list, err := loadWatchlist(fs, args, 1, -1, file)
+	if err != nil {
+		return nil, err
+	}
+	titleIDs, err := parseTitleIDs(fs, fs.Args())
+	if err != nil {
+		return nil, err
+	}
+
+	action := "done"
+	if *undo {
+		action = "pending"
+	}
+	var marked []*watchlist.Entry
+	var failed []error
+	for _, id := range titleIDs {
+		if err := list.MarkDone(id, !*undo); err != nil {
+			failed = append(failed, err)
+			continue
+		}
+		entry, _ := list.Entry(id)
+		marked = append(marked, entry)
+	}
+	if err := emit(fs, marked, func() {
+		for _, entry := range marked {
+			fmt.Printf("%s\t%s\n", action, entry.ID)
+		}
+	}); err != nil {
+		return list, err
+	}
+	return list, watchlistChanges(failed)
+}
+
+func runWatchlistList(ctx context.Context, imdbClient *client.ImdbClient, fs *flag.FlagSet, args []string, file *string) (*watchlist.Watchlist, error) {
+	sortBy := fs.String("sort", "added", "Order of the titles, one of "+strings.Join(watchlist.Sorts(), ", "))
+	reverse := fs.Bool("reverse", false, "Reverse the order, titles missing what is sorted on staying last")
+	status := fs.String("status", watchlist.Pending, "Titles listed: pending, done or all")
+	tag := fs.String("tag", "", "Only list titles with this tag")
+	genre := fs.String("genre", "", "Only list titles of this genre")
+	types := fs.String("type", "", "Comma separated title types listed, such as MOVIE,TV_SERIES")
+	refresh := fs.Duration("refresh", 0, "Look titles up again when fetched longer ago than this, 0 for never")
+	list, err := loadWatchlist(fs, args, 0, 0, file)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := watchlist.Filter{Status: *status, Tag: *tag, Genre: *genre}
+	for _, t := range splitList(*types) {
+		titleType := models.ImdbapiTitleType(strings.ToUpper(t))
+		if err := titleType.Validate(strfmt.Default); err != nil {
+			return nil, usageError("%s: unknown title type %q", fs.Name(), t)
+		}
+		filter.Types = append(filter.Types, titleType)
+	}
+
+	// Only the titles that may be listed are looked up, genre and type
+	// being known once they are.
+	listed, err := list.Select(watchlist.Filter{Status: filter.Status, Tag: filter.Tag}, *sortBy, *reverse)
+	if err != nil {
+		return nil, usageError("%s: %v", fs.Name(), err)
+	}
+	if _, err := list.Enrich(ctx, watchlist.NewClientSource(imdbClient), listed, *refresh); err != nil {
+		log.Printf("Some titles are listed without their details: %v\n", err)
+	}
+	if listed, err = list.Select(filter, *sortBy, *reverse); err != nil {
+		return nil, usageError("%s: %v", fs.Name(), err)
+	}
+
+	return list, emit(fs, listed, func() {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, entry := range listed {
+			done := "[ ]"
+			if entry.IsDone() {
+				done = "[x]"
+			}
+			label, rating, runtime := "-", "-", "-"
+			if title := entry.Title; title != nil {
+				label = cmp.Or(title.PrimaryTitle, title.OriginalTitle)
+				if title.StartYear != 0 {
+					label += fmt.Sprintf(" (%d)", title.StartYear)
+				}
+				if title.Rating != nil && title.Rating.AggregateRating > 0 {
+					rating = fmt.Sprintf("%.1f", title.Rating.AggregateRating)
+				}
+				runtime = cmp.Or(output.Runtime(title.RuntimeSeconds), runtime)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", done, entry.ID, label, rating, runtime, strings.Join(entry.Tags, ","))
+		}
+		tw.Flush()
+		fmt.Printf("%d titles\n", len(listed))
+	})
+}
+
+// drain walks seq to the end for the side effect of storing every item.
+func drain[T any](seq iter.Seq2[*T, error]) error {
+	for _, err := range seq {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printAll collects items from seq until it ends or limit items have been
+// read, limit zero meaning no limit, and emits them with print as the text
+// layout of a single item.
+func printAll[T any](fs *flag.FlagSet, seq iter.Seq2[*T, error], limit int, print func(*T)) error {
+	var items []*T
+	for item, err := range seq {
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+		if limit > 0 && len(items) >= limit {
+			break
+		}
+	}
+
+	return emit(fs, items, func() {
+		for _, item := range items {
+			print(item)
+		}
+	})
+}
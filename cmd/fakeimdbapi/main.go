@@ -0,0 +1,47 @@
+// Command fakeimdbapi serves a seeded fakeapi dataset, for pointing
+// imdblookup --api at something local while developing.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/foursixnine/imdblookup/tests/fakeapi"
+)
+
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile | log.Lmsgprefix)
+
+	addr := flag.String("addr", "127.0.0.1:8089", "Address to listen on")
+	seed := flag.Uint64("seed", 1, "Seed for the generated titles and names, the same seed always serves the same data")
+	fault := flag.String("fault", "", "Fault scenario applied to every request, as in the "+fakeapi.FaultHeader+" header, e.g. status=503,rate=0.2")
+	flag.Parse()
+
+	scenario, err := fakeapi.ParseScenario(*fault)
+	if err != nil {
+		log.Fatalf("Error parsing -fault: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	data := fakeapi.Seed(*seed)
+	fake := fakeapi.New(data)
+	fake.SetScenario(scenario)
+	server := &http.Server{Addr: *addr, Handler: fake}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	log.Printf("Serving %d titles and %d names on http://%s\n", len(data.Titles), len(data.Names), *addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Error serving: %v", err)
+	}
+}
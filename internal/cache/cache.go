@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"strings"
+	"time"
+)
+
+// Cache stores raw response bodies keyed by request URL.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// TTLPolicy picks how long a response stays fresh based on the endpoint path.
+// The longest matching prefix in Endpoints wins, Default applies otherwise.
+type TTLPolicy struct {
+	Default   time.Duration
+	Endpoints map[string]time.Duration
+}
+
+func DefaultTTLPolicy() TTLPolicy {
+	return TTLPolicy{
+		Default: time.Hour,
+		Endpoints: map[string]time.Duration{
+			"names/":          7 * 24 * time.Hour,
+			"titles/":         24 * time.Hour,
+			"interests":       7 * 24 * time.Hour,
+			"search/titles":   time.Hour,
+			"chart/starmeter": 10 * time.Minute,
+			"graph:":          7 * 24 * time.Hour,
+		},
+	}
+}
+
+func (p TTLPolicy) For(path string) time.Duration {
+	path = strings.TrimPrefix(path, "/")
+	ttl, matched := p.Default, -1
+	for prefix, endpointTTL := range p.Endpoints {
+		if strings.HasPrefix(path, prefix) && len(prefix) > matched {
+			ttl, matched = endpointTTL, len(prefix)
+		}
+	}
+	return ttl
+}
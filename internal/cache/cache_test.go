@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLRU(10)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", []byte("aaaa"), time.Minute)
+	c.Set("b", []byte("bbbb"), time.Minute)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("TestLRU = expected a to be cached")
+	}
+
+	// a was used last, so adding c evicts b.
+	c.Set("c", []byte("cccc"), time.Minute)
+	if _, ok := c.Get("b"); ok {
+		t.Error("TestLRU = expected b to be evicted")
+	}
+	if value, ok := c.Get("a"); !ok || string(value) != "aaaa" {
+		t.Errorf("TestLRU = got (%s, %v), want (aaaa, true)", value, ok)
+	}
+
+	c.Set("big", []byte("more than ten bytes"), time.Minute)
+	if _, ok := c.Get("big"); ok {
+		t.Error("TestLRU = expected value larger than the cache to be skipped")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Error("TestLRU = expected a to have expired")
+	}
+	if c.Len() != 1 {
+		t.Errorf("TestLRU = got %d entries, want 1", c.Len())
+	}
+}
+
+func TestDisk(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	c, err := NewDisk(dir)
+	if err != nil {
+		t.Fatalf("TestDisk = unexpected error (%v)", err)
+	}
+	c.now = func() time.Time { return now }
+
+	c.Set("https://api.imdbapi.dev/titles/tt0111161", []byte(`{"id":"tt0111161"}`), time.Hour)
+
+	reopened, _ := NewDisk(dir)
+	reopened.now = c.now
+	if value, ok := reopened.Get("https://api.imdbapi.dev/titles/tt0111161"); !ok || string(value) != `{"id":"tt0111161"}` {
+		t.Errorf("TestDisk = got (%s, %v), want cached title", value, ok)
+	}
+	if _, ok := reopened.Get("https://api.imdbapi.dev/titles/tt0000000"); ok {
+		t.Error("TestDisk = expected miss for unknown key")
+	}
+
+	now = now.Add(2 * time.Hour)
+	c.ServeExpired = true
+	if value, ok := c.Get("https://api.imdbapi.dev/titles/tt0111161"); !ok || string(value) != `{"id":"tt0111161"}` {
+		t.Errorf("TestDisk = got (%s, %v) serving expired entries, want the expired title", value, ok)
+	}
+	if _, ok := reopened.Get("https://api.imdbapi.dev/titles/tt0111161"); ok {
+		t.Error("TestDisk = expected entry to have expired")
+	}
+}
+
+func TestTTLPolicy(t *testing.T) {
+	policy := DefaultTTLPolicy()
+	testCases := map[string]time.Duration{
+		"names/nm0000102":         7 * 24 * time.Hour,
+		"/titles/tt0111161":       24 * time.Hour,
+		"chart/starmeter":         10 * time.Minute,
+		"search/titles":           time.Hour,
+		"titles":                  time.Hour,
+		"titles:batchGet":         time.Hour,
+		"names/nm0000102/trivia":  7 * 24 * time.Hour,
+		"titles/tt0111161/akas":   24 * time.Hour,
+		"something/else/entirely": time.Hour,
+	}
+
+	for path, expected := range testCases {
+		if ttl := policy.For(path); ttl != expected {
+			t.Errorf("TestTTLPolicy(%s) = got (%v), want (%v).", path, ttl, expected)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Disk keeps one JSON file per entry, named after the hash of its key.
+type Disk struct {
+	// ServeExpired answers with entries past their expiry instead of
+	// removing them, for offline use where an old answer beats none.
+	ServeExpired bool
+
+	dir string
+	now func() time.Time
+}
+
+type diskEntry struct {
+	Key     string    `json:"key"`
+	Expires time.Time `json:"expires"`
+	Value   []byte    `json:"value"`
+}
+
+// DefaultDir is $XDG_CACHE_HOME/imdblookup, falling back to the platform's
+// user cache directory.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "imdblookup"), nil
+}
+
+func NewDisk(dir string) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Disk{dir: dir, now: time.Now}, nil
+}
+
+func (c *Disk) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry diskEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return nil, false
+	}
+	if !c.ServeExpired && c.now().After(entry.Expires) {
+		os.Remove(c.path(key))
+		return nil, false
+	}
+	return entry.Value, true
+}
+
+func (c *Disk) Set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(diskEntry{Key: key, Expires: c.now().Add(ttl), Value: value})
+	if err != nil {
+		return
+	}
+
+	// Write to a temporary file first so readers never see half an entry.
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	os.Rename(tmp.Name(), c.path(key))
+}
+
+func (c *Disk) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
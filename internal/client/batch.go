@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	ce "github.com/foursixnine/imdblookup/internal/errors"
+	"github.com/foursixnine/imdblookup/internal/ids"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// BatchGetMaxIDs is the most IDs the api accepts in one batchGet request.
+const BatchGetMaxIDs = 5
+
+// batchGetWorkers bounds how many chunks are requested at once, the
+// transport's rate limiter still applies on top.
+const batchGetWorkers = 4
+
+// BatchResult is the outcome for one requested ID. Err is set, and Value
+// nil, when the ID was malformed, missing from the response or its chunk
+// failed.
+type BatchResult[T any] struct {
+	ID    string
+	Value *T
+	Err   error
+}
+
+func (imdbClient *ImdbClient) BatchGetTitles(ctx context.Context, titleIDs []ids.TitleID) ([]BatchResult[models.ImdbapiTitle], *ce.IMDBClientApplicationError) {
+	parse := func(id ids.TitleID) (string, error) {
+		titleID, err := ids.ParseTitleID(string(id))
+		return titleID.String(), err
+	}
+	return batchGet(ctx, imdbClient, "titles:batchGet", "titleIds", titleIDs, parse, func(r *models.ImdbapiBatchGetTitlesResponse) map[string]*models.ImdbapiTitle {
+		found := make(map[string]*models.ImdbapiTitle, len(r.Titles))
+		for _, title := range r.Titles {
+			if title != nil {
+				found[title.ID] = title
+			}
+		}
+		return found
+	})
+}
+
+func (imdbClient *ImdbClient) BatchGetNames(ctx context.Context, nameIDs []ids.NameID) ([]BatchResult[models.ImdbapiName], *ce.IMDBClientApplicationError) {
+	parse := func(id ids.NameID) (string, error) {
+		nameID, err := ids.ParseNameID(string(id))
+		return nameID.String(), err
+	}
+	return batchGet(ctx, imdbClient, "names:batchGet", "nameIds", nameIDs, parse, func(r *models.ImdbapiBatchGetNamesResponse) map[string]*models.ImdbapiName {
+		found := make(map[string]*models.ImdbapiName, len(r.Names))
+		for _, name := range r.Names {
+			if name != nil {
+				found[name.ID] = name
+			}
+		}
+		return found
+	})
+}
+
+// batchRequest is one entry of a chunk: the canonical ID sent to the api and
+// the result it fills in.
+type batchRequest[T any] struct {
+	id     string
+	result *BatchResult[T]
+}
+
+// batchGet splits the well formed ids into chunks of BatchGetMaxIDs, fetches
+// them concurrently and lays the results out in the order of ids.
+func batchGet[R any, T any, ID ~string](ctx context.Context, imdbClient *ImdbClient, path, key string, idList []ID, parse func(ID) (string, error), index func(*R) map[string]*T) ([]BatchResult[T], *ce.IMDBClientApplicationError) {
+	results := make([]BatchResult[T], len(idList))
+	var requests []batchRequest[T]
+	for i, id := range idList {
+		results[i].ID = string(id)
+		canonical, err := parse(id)
+		if err != nil {
+			results[i].Err = ce.NewIMDBClientApplicationError("Invalid id", err)
+			continue
+		}
+		requests = append(requests, batchRequest[T]{id: canonical, result: &results[i]})
+	}
+
+	chunks := make(chan []batchRequest[T])
+	var wg sync.WaitGroup
+	for range min(batchGetWorkers, (len(requests)+BatchGetMaxIDs-1)/BatchGetMaxIDs) {
+		wg.Go(func() {
+			for chunk := range chunks {
+				fetchChunk(ctx, imdbClient, path, key, chunk, index)
+			}
+		})
+	}
+
+	// Each chunk points at distinct results, so workers fill them in
+	// without locking.
+	for start := 0; start < len(requests); start += BatchGetMaxIDs {
+		chunks <- requests[start:min(start+BatchGetMaxIDs, len(requests))]
+	}
+	close(chunks)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return results, ce.NewIMDBClientApplicationError("Batch lookup interrupted", err)
+	}
+	return results, nil
+}
+
+func fetchChunk[R any, T any](ctx context.Context, imdbClient *ImdbClient, path, key string, chunk []batchRequest[T], index func(*R) map[string]*T) {
+	parameters := make([]QueryParameters, 0, len(chunk))
+	for _, request := range chunk {
+		parameters = append(parameters, QueryParameters{Key: key, Value: request.id})
+	}
+
+	fail := func(err error) {
+		for _, request := range chunk {
+			request.result.Err = err
+		}
+	}
+
+	resp, err := imdbClient.GetContext(ctx, path, &parameters)
+	if err != nil {
+		fail(ce.NewIMDBClientApplicationError("An error occurred querying "+path, err))
+		return
+	}
+
+	var response R
+	if err := json.Unmarshal(resp, &response); err != nil {
+		fail(ce.NewIMDBClientApplicationError("error: JSON answer cannot be read", err))
+		return
+	}
+
+	found := index(&response)
+	for _, request := range chunk {
+		if value, ok := found[request.id]; ok {
+			request.result.Value = value
+		} else {
+			request.result.Err = ce.NotFound(request.id)
+		}
+	}
+}
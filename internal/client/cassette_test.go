@@ -0,0 +1,87 @@
+package client
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+
+	"github.com/foursixnine/imdblookup/models"
+	"github.com/foursixnine/imdblookup/tests"
+)
+
+// TestIMDBClientEndpointsCassette decodes a recorded answer of every
+// endpoint in the swagger spec. The ids are those of the fake api seeded
+// with 1, which the cassette was recorded from with
+//
+//	go run ./cmd/fakeimdbapi &
+//	IMDBLOOKUP_RECORD=1 IMDBLOOKUP_RECORD_API=http://127.0.0.1:8089 go test -run TestIMDBClientEndpointsCassette ./internal/client/
+//
+// As the fake answers from the same models the client decodes into, this
+// only shows the client reads every endpoint back, not that it keeps up
+// with the real api. Checking that takes recording from
+// https://api.imdbapi.dev, with ids of real titles in place of the fake
+// ones, which the scrubber keeps credentials out of.
+func TestIMDBClientEndpointsCassette(t *testing.T) {
+	transport := tests.UseCassette(t, "endpoints", tests.MatchStrict)
+	apiURL, err := url.Parse(cmp.Or(os.Getenv(tests.RecordAPIEnv), "https://api.imdbapi.dev"))
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+	imdbClient := NewWithOptions(&ImdbClientOptions{ApiURL: apiURL, UserAgent: "imdblookup/0.1", Transport: transport})
+
+	testCases := map[string]struct {
+		path     string
+		params   []QueryParameters
+		response interface{ Validate(strfmt.Registry) error }
+	}{
+		"list titles":             {path: "titles", params: []QueryParameters{{Key: "types", Value: "MOVIE"}}, response: &models.ImdbapiListTitlesResponse{}},
+		"title":                   {path: "titles/tt2000003", response: &models.ImdbapiTitle{}},
+		"batch get titles":        {path: "titles:batchGet", params: []QueryParameters{{Key: "titleIds", Value: "tt2000003"}, {Key: "titleIds", Value: "tt2000007"}}, response: &models.ImdbapiBatchGetTitlesResponse{}},
+		"title credits":           {path: "titles/tt2000003/credits", response: &models.ImdbapiListTitleCreditsResponse{}},
+		"title release dates":     {path: "titles/tt2000003/releaseDates", response: &models.ImdbapiListTitleReleaseDatesResponse{}},
+		"title akas":              {path: "titles/tt2000003/akas", response: &models.ImdbapiListTitleAKAsResponse{}},
+		"title seasons":           {path: "titles/tt2000001/seasons", response: &models.ImdbapiListTitleSeasonsResponse{}},
+		"title episodes":          {path: "titles/tt2000001/episodes", params: []QueryParameters{{Key: "season", Value: "1"}}, response: &models.ImdbapiListTitleEpisodesResponse{}},
+		"title images":            {path: "titles/tt2000003/images", response: &models.ImdbapiListTitleImagesResponse{}},
+		"title videos":            {path: "titles/tt2000003/videos", response: &models.ImdbapiListTitleVideosResponse{}},
+		"title award nominations": {path: "titles/tt2000003/awardNominations", response: &models.ImdbapiListTitleAwardNominationsResponse{}},
+		"title parents guide":     {path: "titles/tt2000003/parentsGuide", response: &models.ImdbapiListTitleParentsGuideResponse{}},
+		"title certificates":      {path: "titles/tt2000003/certificates", response: &models.ImdbapiListTitleCertificatesResponse{}},
+		"title company credits":   {path: "titles/tt2000003/companyCredits", response: &models.ImdbapiListTitleCompanyCreditsResponse{}},
+		"title box office":        {path: "titles/tt2000003/boxOffice", response: &models.ImdbapiBoxOffice{}},
+		"search titles":           {path: "search/titles", params: []QueryParameters{{Key: "query", Value: "The Lost Orchard"}}, response: &models.ImdbapiSearchTitlesResponse{}},
+		"name":                    {path: "names/nm2000001", response: &models.ImdbapiName{}},
+		"name images":             {path: "names/nm2000001/images", response: &models.ImdbapiListNameImagesResponse{}},
+		"name filmography":        {path: "names/nm2000001/filmography", response: &models.ImdbapiListNameFilmographyResponse{}},
+		"name relationships":      {path: "names/nm2000001/relationships", response: &models.ImdbapiListNameRelationshipsResponse{}},
+		"name trivia":             {path: "names/nm2000001/trivia", response: &models.ImdbapiListNameTriviaResponse{}},
+		"batch get names":         {path: "names:batchGet", params: []QueryParameters{{Key: "nameIds", Value: "nm2000001"}, {Key: "nameIds", Value: "nm2000002"}}, response: &models.ImdbapiBatchGetNamesResponse{}},
+		"starmeter":               {path: "chart/starmeter", response: &models.ImdbapiListStarMetersResponse{}},
+		"interests":               {path: "interests", response: &models.ImdbapiListListInterestCategoriesResponse{}},
+		"interest":                {path: "interests/in0000001", response: &models.ImdbapiInterest{}},
+	}
+
+	// In a set order, so the cassette only changes where the answers did.
+	for _, testName := range slices.Sorted(maps.Keys(testCases)) {
+		testCase := testCases[testName]
+		resp, err := imdbClient.GetContext(context.Background(), testCase.path, &testCase.params)
+		if err != nil {
+			t.Errorf("TestIMDBClientEndpointsCassette(%s) = unexpected error (%v)", testName, err)
+			continue
+		}
+		if err := json.Unmarshal(resp, testCase.response); err != nil {
+			t.Errorf("TestIMDBClientEndpointsCassette(%s) = cannot decode (%v)", testName, err)
+			continue
+		}
+		if err := testCase.response.Validate(strfmt.Default); err != nil {
+			t.Errorf("TestIMDBClientEndpointsCassette(%s) = invalid response (%v)", testName, err)
+		}
+	}
+}
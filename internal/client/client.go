@@ -1,11 +1,13 @@
 package client
 
 import (
+	"context"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
 
+	"github.com/foursixnine/imdblookup/internal/cache"
 	"github.com/foursixnine/imdblookup/internal/errors"
 )
 
@@ -18,25 +20,59 @@ type ImdbClientOptions struct {
 	ApiURL    *url.URL
 	Verbose   bool
 	UserAgent string
+	Retry     *RetryPolicy
+	// RateLimit is the sustained number of requests per second, with up to
+	// RateBurst sent back to back. Zero disables rate limiting.
+	RateLimit float64
+	RateBurst int
+	// MaxInFlight caps concurrent requests, zero means no cap.
+	MaxInFlight int
+	// Cache, when set, answers repeated GETs without touching the network.
+	Cache    cache.Cache
+	CacheTTL cache.TTLPolicy
+	// Store, when set, answers title and name lookups and unfiltered credits
+	// and episode listings before the cache, and keeps a copy of every title,
+	// name, credit and complete episode listing fetched.
+	Store Store
+	// Offline answers from Cache and Store only, a miss failing with
+	// errors.ErrOffline. Both are best set up to answer with what they hold
+	// however old it is, as with cache.Disk.ServeExpired and a Store MaxAge
+	// of zero.
+	Offline bool
+	// Transport sends the requests, http.DefaultTransport when nil. Headers,
+	// retries and rate limiting are layered on top of it.
+	Transport http.RoundTripper
 }
 
 type ImdbClient struct {
 	HttpClient *http.Client
 	options    *ImdbClientOptions
+	limiter    *limiter
 }
 
 type imdbClientTransport struct {
 	UserAgent string
+	Retry     *RetryPolicy
+	Base      http.RoundTripper
+	limiter   *limiter
 }
 
 func New(url *url.URL) *ImdbClient {
-	options := &ImdbClientOptions{
+	return NewWithOptions(&ImdbClientOptions{
 		ApiURL:    url,
 		Verbose:   true,
 		UserAgent: "imdblookup/0.1",
-	}
+		Retry:     DefaultRetryPolicy(),
+	})
+}
+
+func NewWithOptions(options *ImdbClientOptions) *ImdbClient {
+	limiter := newLimiter(options.RateLimit, options.RateBurst, options.MaxInFlight)
 	transport := &imdbClientTransport{
 		UserAgent: options.UserAgent,
+		Retry:     options.Retry,
+		Base:      options.Transport,
+		limiter:   limiter,
 	}
 
 	httpClient := &http.Client{
@@ -46,9 +82,25 @@ func New(url *url.URL) *ImdbClient {
 	return &ImdbClient{
 		HttpClient: httpClient,
 		options:    options,
+		limiter:    limiter,
 	}
 }
 
+// Cache is the response cache of the client, nil when caching is off, for
+// callers that keep their own derived results next to the responses.
+func (client *ImdbClient) Cache() (cache.Cache, cache.TTLPolicy) {
+	return client.options.Cache, client.options.CacheTTL
+}
+
+// Store is the local mirror of the client, nil when there is none.
+func (client *ImdbClient) Store() Store {
+	return client.options.Store
+}
+
+func (client *ImdbClient) LimiterStats() LimiterStats {
+	return client.limiter.snapshot()
+}
+
 func (t *imdbClientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	r := req.Clone(req.Context())
 	r.Header.Add("Content-Type", "application/json")
@@ -57,15 +109,61 @@ func (t *imdbClientTransport) RoundTrip(req *http.Request) (*http.Response, erro
 	r.Header.Add("User-Agent", t.UserAgent)
 	// r.Header.Add("X-AUTH-API-KEY", t.apiKey)
 
-	return http.DefaultTransport.RoundTrip(r)
+	if t.Retry.enabled() {
+		return t.roundTripWithRetry(r)
+	}
+	return t.send(r)
+}
+
+// send makes a single attempt, waiting for the limiter first.
+func (t *imdbClientTransport) send(req *http.Request) (*http.Response, error) {
+	if t.limiter == nil {
+		return t.base().RoundTrip(req)
+	}
+
+	release, err := t.limiter.wait(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+func (t *imdbClientTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
 }
 
 func (client *ImdbClient) Get(path string, params *[]QueryParameters) ([]byte, error) {
+	return client.GetContext(context.Background(), path, params)
+}
+
+func (client *ImdbClient) GetContext(ctx context.Context, path string, params *[]QueryParameters) ([]byte, error) {
 
 	url := client.makeUrl(path, *params)
+
+	if client.options.Cache != nil && !refreshing(ctx) {
+		if response, ok := client.options.Cache.Get(url); ok {
+			log.Println("ImdbClient cache hit: " + url)
+			return response, nil
+		}
+	}
+
+	if client.options.Offline {
+		return nil, errors.NewIMDBClientGenericError("error: offline, no cached answer for "+url, errors.ErrOffline)
+	}
+
 	log.Println("ImdbClient querying: " + url)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, errors.NewIMDBClientGenericError("error: creating http request", err)
 	}
@@ -82,11 +180,20 @@ func (client *ImdbClient) Get(path string, params *[]QueryParameters) ([]byte, e
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		var httpErr *errors.HTTPError
 		if resp.StatusCode == http.StatusNotFound {
-			return response, errors.NotFound(url)
+			httpErr = errors.NotFound(url)
 		} else {
-			return response, errors.UnexpectedError(resp.StatusCode, string(response))
+			httpErr = errors.UnexpectedError(resp.StatusCode, string(response))
+		}
+		if apiErr, ok := errors.DecodeAPIError(httpErr, response); ok {
+			return response, apiErr
 		}
+		return response, httpErr
+	}
+
+	if client.options.Cache != nil {
+		client.options.Cache.Set(url, response, client.options.CacheTTL.For(path))
 	}
 
 	return response, nil
@@ -97,7 +204,7 @@ func (client *ImdbClient) makeUrl(path string, params []QueryParameters) string
 	q := url.Query()
 	for _, query := range params {
 		if query.Key != "" {
-			q.Set(query.Key, query.Value) // Set the key-value in the URL's query parameters
+			q.Add(query.Key, query.Value) // Add, so repeated keys become multi-valued parameters
 		}
 	}
 	url.RawQuery = q.Encode()
@@ -1,15 +1,22 @@
 package client
 
 import (
+	"context"
 	e "errors"
+	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"slices"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/foursixnine/imdblookup/internal/cache"
 	"github.com/foursixnine/imdblookup/internal/errors"
+	"github.com/foursixnine/imdblookup/internal/ids"
 	"github.com/foursixnine/imdblookup/models"
 	"github.com/foursixnine/imdblookup/tests"
 )
@@ -111,6 +118,59 @@ func TestIMDBClientGet(t *testing.T) {
 
 }
 
+func TestIMDBClientGetAPIError(t *testing.T) {
+	testCases := map[string]struct {
+		path      string
+		code      errors.Code
+		httpError *errors.HTTPError
+		detail    any
+	}{
+		"with rpc not found": {
+			path:      "/titles/tt9999999",
+			code:      errors.CodeNotFound,
+			httpError: errors.NotFound(server.URL + "/titles/tt9999999"),
+			detail:    &errors.ErrorInfo{},
+		},
+		"with rpc invalid argument": {
+			path:      "/titles/invalid",
+			code:      errors.CodeInvalidArgument,
+			httpError: errors.UnexpectedError(http.StatusBadRequest, `{"code":3,"message":"invalid title id","details":[{"@type":"type.googleapis.com/google.rpc.BadRequest","fieldViolations":[{"field":"titleId","description":"must start with tt"}]},{"@type":"type.googleapis.com/example.Unknown","foo":"bar"}]}`),
+			detail:    &errors.BadRequest{},
+		},
+	}
+
+	url, err := url.Parse(server.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+
+	imdbClient := New(url)
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			_, err := imdbClient.Get(testCase.path, &[]QueryParameters{})
+
+			if !e.Is(err, testCase.code) {
+				t.Errorf("TestIMDBClientGetAPIError(%s) = got (%v), want code (%v).", testName, err, testCase.code)
+			}
+			if e.Is(err, errors.CodeResourceExhausted) {
+				t.Errorf("TestIMDBClientGetAPIError(%s) = (%v) should not match %v.", testName, err, errors.CodeResourceExhausted)
+			}
+			if !e.Is(err, testCase.httpError) {
+				t.Errorf("TestIMDBClientGetAPIError(%s) = got (%v), want http error (%v).", testName, err, testCase.httpError)
+			}
+
+			var apiErr *errors.APIError
+			if !e.As(err, &apiErr) || len(apiErr.Details) == 0 {
+				t.Fatalf("TestIMDBClientGetAPIError(%s) = got (%v), want an APIError with details", testName, err)
+			}
+			if fmt.Sprintf("%T", apiErr.Details[0]) != fmt.Sprintf("%T", testCase.detail) {
+				t.Errorf("TestIMDBClientGetAPIError(%s) = got detail %T, want %T.", testName, apiErr.Details[0], testCase.detail)
+			}
+		})
+	}
+}
+
 func TestIMDBClientMakeURL(t *testing.T) {
 	testCases := map[string]struct {
 		params   []QueryParameters
@@ -244,6 +304,591 @@ func TestIMDBClientFindShowsByTitle(t *testing.T) {
 
 }
 
+func TestIMDBClientListTitles(t *testing.T) {
+	testCases := map[string]struct {
+		filter   *ListTitlesFilter
+		expected []string
+		error    bool
+	}{
+		"with nil filter, all pages": {
+			filter:   nil,
+			expected: []string{"tt0000001", "tt0000002", "tt0000003", "tt0000004", "tt0000005"},
+		},
+		"with cap inside second page": {
+			filter:   &ListTitlesFilter{MaxResults: 3},
+			expected: []string{"tt0000001", "tt0000002", "tt0000003"},
+		},
+		"with type filter": {
+			filter:   &ListTitlesFilter{Types: []models.ImdbapiTitleType{models.ImdbapiTitleTypeTVSERIES}},
+			expected: []string{"tt0000002", "tt0000004"},
+		},
+		"with invalid sort order": {
+			filter: &ListTitlesFilter{SortOrder: "SIDEWAYS"},
+			error:  true,
+		},
+	}
+
+	url, err := url.Parse(server.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+
+	imdbClient := New(url)
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			titles, err := imdbClient.ListTitles(testCase.filter)
+			if testCase.error {
+				if err == nil {
+					t.Fatalf("TestIMDBClientListTitles(%s) = expected an error, got (%v)", testName, titles)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TestIMDBClientListTitles(%s) = unexpected error (%v)", testName, err)
+			}
+
+			var ids []string
+			for _, title := range titles {
+				ids = append(ids, title.ID)
+			}
+			if !slices.Equal(ids, testCase.expected) {
+				t.Errorf("TestIMDBClientListTitles(%s) = got (%v), want (%v).", testName, ids, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestIMDBClientListTitlesQueryParameters(t *testing.T) {
+	filter := &ListTitlesFilter{
+		Types:              []models.ImdbapiTitleType{models.ImdbapiTitleTypeMOVIE, models.ImdbapiTitleTypeSHORT},
+		Genres:             []string{"Drama"},
+		StartYear:          1990,
+		MinAggregateRating: 7.5,
+		SortBy:             models.ImdbapiTitleSortBySORTBYYEAR,
+		SortOrder:          models.ImdbapiSortOrderDESC,
+	}
+
+	url, err := url.Parse(server.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+
+	parameters, err := filter.queryParameters()
+	if err != nil {
+		t.Fatalf("TestIMDBClientListTitlesQueryParameters = unexpected error (%v)", err)
+	}
+
+	expected := server.URL + "/titles?genres=Drama&minAggregateRating=7.5&sortBy=SORT_BY_YEAR&sortOrder=DESC&startYear=1990&types=MOVIE&types=SHORT"
+	if result := New(url).makeUrl("titles", parameters); result != expected {
+		t.Errorf("TestIMDBClientListTitlesQueryParameters = got (%v), want (%v).", result, expected)
+	}
+}
+
+func TestIMDBClientTitleCredits(t *testing.T) {
+	testCases := map[string]struct {
+		titleID  ids.TitleID
+		stopAt   int
+		expected []string
+		error    bool
+	}{
+		"with all pages": {
+			titleID:  "tt0000001",
+			expected: []string{"nm0000001", "nm0000002", "nm0000003"},
+		},
+		"with early break": {
+			titleID:  "tt0000001",
+			stopAt:   1,
+			expected: []string{"nm0000001"},
+		},
+		"with unknown title": {
+			titleID: "tt0000404",
+			error:   true,
+		},
+	}
+
+	url, err := url.Parse(server.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+
+	imdbClient := New(url)
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			var ids []string
+			for credit, err := range imdbClient.TitleCredits(context.Background(), testCase.titleID) {
+				if err != nil {
+					if !testCase.error {
+						t.Fatalf("TestIMDBClientTitleCredits(%s) = unexpected error (%v)", testName, err)
+					}
+					return
+				}
+				ids = append(ids, credit.Name.ID)
+				if len(ids) == testCase.stopAt {
+					break
+				}
+			}
+
+			if testCase.error {
+				t.Fatalf("TestIMDBClientTitleCredits(%s) = expected an error, got (%v)", testName, ids)
+			}
+			if !slices.Equal(ids, testCase.expected) {
+				t.Errorf("TestIMDBClientTitleCredits(%s) = got (%v), want (%v).", testName, ids, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestIMDBClientContextCancelled(t *testing.T) {
+	url, err := url.Parse(server.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+
+	imdbClient := New(url)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := imdbClient.GetContext(ctx, "", &[]QueryParameters{}); !e.Is(err, context.Canceled) {
+		t.Errorf("TestIMDBClientContextCancelled(GetContext) = got (%v), want (%v).", err, context.Canceled)
+	}
+
+	query := "Stranger Things"
+	if _, err := imdbClient.FindShowsByTitleContext(ctx, &query); !e.Is(err, context.Canceled) {
+		t.Errorf("TestIMDBClientContextCancelled(FindShowsByTitleContext) = got (%v), want (%v).", err, context.Canceled)
+	}
+
+	if _, err := imdbClient.ListTitlesContext(ctx, nil); !e.Is(err, context.Canceled) {
+		t.Errorf("TestIMDBClientContextCancelled(ListTitlesContext) = got (%v), want (%v).", err, context.Canceled)
+	}
+}
+
+func TestIMDBClientCache(t *testing.T) {
+	var calls atomic.Int32
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/500" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer counting.Close()
+
+	url, err := url.Parse(counting.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+
+	imdbClient := NewWithOptions(&ImdbClientOptions{
+		ApiURL:   url,
+		Cache:    cache.NewLRU(1 << 20),
+		CacheTTL: cache.TTLPolicy{Default: time.Minute, Endpoints: map[string]time.Duration{"chart/starmeter": 0}},
+	})
+
+	testCases := []struct {
+		path  string
+		calls int32
+	}{
+		{path: "titles/tt0111161", calls: 1},
+		{path: "titles/tt0111161", calls: 1},
+		{path: "chart/starmeter", calls: 2},
+		{path: "chart/starmeter", calls: 3},
+		{path: "500", calls: 4},
+		{path: "500", calls: 5},
+	}
+
+	for _, testCase := range testCases {
+		resp, _ := imdbClient.Get(testCase.path, &[]QueryParameters{})
+		if string(resp) != "/"+testCase.path {
+			t.Errorf("TestIMDBClientCache(%s) = got (%s), want (/%s).", testCase.path, resp, testCase.path)
+		}
+		if calls.Load() != testCase.calls {
+			t.Errorf("TestIMDBClientCache(%s) = server saw %d calls, want %d.", testCase.path, calls.Load(), testCase.calls)
+		}
+	}
+}
+
+func TestIMDBClientBatchGetTitles(t *testing.T) {
+	url, err := url.Parse(server.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+	imdbClient := New(url)
+
+	titleIDs := []ids.TitleID{"tt0000005", "tt0000404", "tt0000001", "tt0000002", "tt0000003", "tt0000004", "tt0000001", "tt0000405"}
+	results, appErr := imdbClient.BatchGetTitles(context.Background(), titleIDs)
+	if appErr != nil {
+		t.Fatalf("TestIMDBClientBatchGetTitles = unexpected error (%v)", appErr)
+	}
+
+	if len(results) != len(titleIDs) {
+		t.Fatalf("TestIMDBClientBatchGetTitles = got %d results, want %d", len(results), len(titleIDs))
+	}
+	for i, result := range results {
+		missing := result.ID == "tt0000404" || result.ID == "tt0000405"
+		switch {
+		case result.ID != string(titleIDs[i]):
+			t.Errorf("TestIMDBClientBatchGetTitles(%d) = got id (%s), want (%s).", i, result.ID, titleIDs[i])
+		case missing && !e.Is(result.Err, errors.NotFound(result.ID)):
+			t.Errorf("TestIMDBClientBatchGetTitles(%s) = got error (%v), want not found.", result.ID, result.Err)
+		case !missing && (result.Err != nil || result.Value == nil || result.Value.ID != result.ID):
+			t.Errorf("TestIMDBClientBatchGetTitles(%s) = got (%v, %v), want the title.", result.ID, result.Value, result.Err)
+		}
+	}
+}
+
+func TestIMDBClientBatchGetNames(t *testing.T) {
+	url, err := url.Parse(server.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+	imdbClient := New(url)
+
+	results, appErr := imdbClient.BatchGetNames(context.Background(), []ids.NameID{"nm0000003", "nm0000001"})
+	if appErr != nil {
+		t.Fatalf("TestIMDBClientBatchGetNames = unexpected error (%v)", appErr)
+	}
+	if len(results) != 2 || results[0].Value == nil || results[0].Value.ID != "nm0000003" || results[1].Value == nil || results[1].Value.ID != "nm0000001" {
+		t.Errorf("TestIMDBClientBatchGetNames = got (%+v), want names in input order", results)
+	}
+
+	if results, _ := imdbClient.BatchGetNames(context.Background(), nil); len(results) != 0 {
+		t.Errorf("TestIMDBClientBatchGetNames = got (%+v) for no ids, want none", results)
+	}
+}
+
+func TestIMDBClientRejectsMalformedIDs(t *testing.T) {
+	var calls atomic.Int32
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"id":"tt0111161"}`))
+	}))
+	defer counting.Close()
+
+	url, err := url.Parse(counting.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+	imdbClient := New(url)
+	ctx := context.Background()
+
+	var invalid *ids.InvalidIDError
+	if _, err := imdbClient.GetTitle(ctx, "nm0000102"); !e.As(err, &invalid) {
+		t.Errorf("TestIMDBClientRejectsMalformedIDs(GetTitle) = got (%v), want an InvalidIDError", err)
+	}
+	if _, err := imdbClient.GetName(ctx, "nm12"); !e.As(err, &invalid) {
+		t.Errorf("TestIMDBClientRejectsMalformedIDs(GetName) = got (%v), want an InvalidIDError", err)
+	}
+	for _, err := range imdbClient.TitleCredits(ctx, "../names/nm0000102") {
+		if !e.As(err, &invalid) {
+			t.Errorf("TestIMDBClientRejectsMalformedIDs(TitleCredits) = got (%v), want an InvalidIDError", err)
+		}
+	}
+	results, _ := imdbClient.BatchGetTitles(ctx, []ids.TitleID{"bogus"})
+	if len(results) != 1 || !e.As(results[0].Err, &invalid) {
+		t.Errorf("TestIMDBClientRejectsMalformedIDs(BatchGetTitles) = got (%+v), want an InvalidIDError", results)
+	}
+
+	if calls.Load() != 0 {
+		t.Errorf("TestIMDBClientRejectsMalformedIDs = server saw %d calls, want none", calls.Load())
+	}
+
+	title, appErr := imdbClient.GetTitle(ctx, "https://www.imdb.com/title/tt0111161/?ref_=fn_al_tt_1")
+	if appErr != nil || title.ID != "tt0111161" {
+		t.Errorf("TestIMDBClientRejectsMalformedIDs(GetTitle url) = got (%v, %v), want tt0111161", title, appErr)
+	}
+}
+
+func TestIMDBClientEpisodeGuide(t *testing.T) {
+	url, err := url.Parse(server.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+	imdbClient := New(url)
+
+	guide, appErr := imdbClient.EpisodeGuide(context.Background(), "tt0000002")
+	if appErr != nil {
+		t.Fatalf("TestIMDBClientEpisodeGuide = unexpected error (%v)", appErr)
+	}
+
+	expected := []struct {
+		season   string
+		kind     SeasonKind
+		episodes []string
+	}{
+		{season: "1", kind: SeasonRegular, episodes: []string{"tt1000011", "tt1000012", "tt1000010"}},
+		{season: "2", kind: SeasonRegular, episodes: []string{"tt1000021"}},
+		// Listed without episodes.
+		{season: "3", kind: SeasonRegular},
+		// Not listed as a season.
+		{season: "Specials", kind: SeasonSpecials, episodes: []string{"tt1000001"}},
+	}
+	if len(guide.Seasons) != len(expected) {
+		t.Fatalf("TestIMDBClientEpisodeGuide = got %d seasons, want %d", len(guide.Seasons), len(expected))
+	}
+	for i, season := range guide.Seasons {
+		var episodes []string
+		for _, episode := range season.Episodes {
+			episodes = append(episodes, episode.ID)
+		}
+		if season.Season != expected[i].season || season.Kind != expected[i].kind || !slices.Equal(episodes, expected[i].episodes) {
+			t.Errorf("TestIMDBClientEpisodeGuide(%d) = got (%s, %v, %v), want (%s, %v, %v).", i, season.Season, season.Kind, episodes, expected[i].season, expected[i].kind, expected[i].episodes)
+		}
+	}
+
+	if count := len(guide.Episodes()); count != 5 {
+		t.Errorf("TestIMDBClientEpisodeGuide = got %d episodes, want 5", count)
+	}
+}
+
+// memoryStore is a Store keeping what the client saves in maps, credits
+// also being logged in the order they were saved.
+type memoryStore struct {
+	titles   map[string]*models.ImdbapiTitle
+	names    map[string]*models.ImdbapiName
+	credits  []string
+	listings map[string][]*models.ImdbapiCredit
+	episodes map[string][]*models.ImdbapiEpisode
+	seasons  map[string][]*models.ImdbapiSeason
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		titles:   map[string]*models.ImdbapiTitle{},
+		names:    map[string]*models.ImdbapiName{},
+		listings: map[string][]*models.ImdbapiCredit{},
+		episodes: map[string][]*models.ImdbapiEpisode{},
+		seasons:  map[string][]*models.ImdbapiSeason{},
+	}
+}
+
+func (s *memoryStore) Title(ctx context.Context, id string) (*models.ImdbapiTitle, error) {
+	return s.titles[id], nil
+}
+
+func (s *memoryStore) Name(ctx context.Context, id string) (*models.ImdbapiName, error) {
+	return s.names[id], nil
+}
+
+func (s *memoryStore) Credits(ctx context.Context, titleID string) ([]*models.ImdbapiCredit, error) {
+	return s.listings[titleID], nil
+}
+
+func (s *memoryStore) Episodes(ctx context.Context, seriesID string) ([]*models.ImdbapiEpisode, error) {
+	return s.episodes[seriesID], nil
+}
+
+func (s *memoryStore) Seasons(ctx context.Context, seriesID string) ([]*models.ImdbapiSeason, error) {
+	return s.seasons[seriesID], nil
+}
+
+func (s *memoryStore) SaveTitle(ctx context.Context, title *models.ImdbapiTitle) error {
+	s.titles[title.ID] = title
+	return nil
+}
+
+func (s *memoryStore) SaveName(ctx context.Context, name *models.ImdbapiName) error {
+	s.names[name.ID] = name
+	return nil
+}
+
+func (s *memoryStore) SaveCredit(ctx context.Context, titleID, nameID string, credit *models.ImdbapiCredit) error {
+	s.credits = append(s.credits, titleID+"/"+nameID+"/"+credit.Category)
+	return nil
+}
+
+func (s *memoryStore) SaveCredits(ctx context.Context, titleID string, credits []*models.ImdbapiCredit) error {
+	for _, credit := range credits {
+		s.SaveCredit(ctx, titleID, credit.Name.ID, credit)
+	}
+	s.listings[titleID] = append([]*models.ImdbapiCredit{}, credits...)
+	return nil
+}
+
+func (s *memoryStore) SaveEpisodes(ctx context.Context, seriesID string, episodes []*models.ImdbapiEpisode) error {
+	s.episodes[seriesID] = append([]*models.ImdbapiEpisode{}, episodes...)
+	return nil
+}
+
+func (s *memoryStore) SaveSeasons(ctx context.Context, seriesID string, seasons []*models.ImdbapiSeason) error {
+	s.seasons[seriesID] = append([]*models.ImdbapiSeason{}, seasons...)
+	return nil
+}
+
+func TestIMDBClientStore(t *testing.T) {
+	var calls atomic.Int32
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		fmt.Fprintf(w, `{"id":"%s","primaryTitle":"Stored"}`, r.URL.Path[len("/titles/"):])
+	}))
+	defer counting.Close()
+
+	url, err := url.Parse(counting.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+	store := newMemoryStore()
+	imdbClient := NewWithOptions(&ImdbClientOptions{ApiURL: url, Store: store})
+
+	testCases := []struct {
+		ctx   context.Context
+		id    ids.TitleID
+		calls int32
+	}{
+		{ctx: context.Background(), id: "tt0111161", calls: 1},
+		{ctx: context.Background(), id: "https://www.imdb.com/title/tt0111161/", calls: 1},
+		{ctx: WithRefresh(context.Background()), id: "tt0111161", calls: 2},
+		{ctx: context.Background(), id: "tt0468569", calls: 3},
+	}
+	for _, testCase := range testCases {
+		title, appErr := imdbClient.GetTitle(testCase.ctx, testCase.id)
+		if appErr != nil || title.PrimaryTitle != "Stored" {
+			t.Errorf("TestIMDBClientStore(%s) = got (%v, %v), want the stored title.", testCase.id, title, appErr)
+		}
+		if calls.Load() != testCase.calls {
+			t.Errorf("TestIMDBClientStore(%s) = server saw %d calls, want %d.", testCase.id, calls.Load(), testCase.calls)
+		}
+	}
+	if len(store.titles) != 2 {
+		t.Errorf("TestIMDBClientStore = got %d stored titles, want 2.", len(store.titles))
+	}
+}
+
+func TestIMDBClientStoreWriteThrough(t *testing.T) {
+	url, err := url.Parse(server.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+	store := newMemoryStore()
+	imdbClient := NewWithOptions(&ImdbClientOptions{ApiURL: url, Store: store})
+
+	for _, err := range imdbClient.TitleCredits(context.Background(), "tt0000001") {
+		if err != nil {
+			t.Fatalf("TestIMDBClientStoreWriteThrough(credits) = unexpected error (%v)", err)
+		}
+	}
+	for _, err := range imdbClient.NameFilmography(context.Background(), "nm0000001") {
+		if err != nil {
+			t.Fatalf("TestIMDBClientStoreWriteThrough(filmography) = unexpected error (%v)", err)
+		}
+	}
+	// A limited or filtered listing is not the whole of it.
+	for range imdbClient.TitleCredits(context.Background(), "tt0000003") {
+		break
+	}
+	for _, err := range imdbClient.TitleEpisodes(context.Background(), "tt0000002", QueryParameters{Key: "season", Value: "2"}) {
+		if err != nil {
+			t.Fatalf("TestIMDBClientStoreWriteThrough(season) = unexpected error (%v)", err)
+		}
+	}
+	if len(store.episodes) != 0 {
+		t.Errorf("TestIMDBClientStoreWriteThrough(season) = got (%v), want no listing stored.", store.episodes)
+	}
+	if _, appErr := imdbClient.EpisodeGuide(context.Background(), "tt0000002"); appErr != nil {
+		t.Fatalf("TestIMDBClientStoreWriteThrough(episodes) = unexpected error (%v)", appErr)
+	}
+
+	credits := []string{"tt0000001/nm0000001/actor", "tt0000001/nm0000002/actress", "tt0000001/nm0000003/director", "tt0000001/nm0000001/actor"}
+	if !slices.Equal(store.credits, credits) {
+		t.Errorf("TestIMDBClientStoreWriteThrough(credits) = got (%v), want (%v).", store.credits, credits)
+	}
+	if len(store.listings) != 1 || len(store.listings["tt0000001"]) != 3 {
+		t.Errorf("TestIMDBClientStoreWriteThrough(listings) = got (%v), want the three credits of tt0000001 only.", store.listings)
+	}
+	if len(store.episodes) != 1 || len(store.episodes["tt0000002"]) != 5 {
+		t.Errorf("TestIMDBClientStoreWriteThrough(episodes) = got (%v), want the five episodes of tt0000002 only.", store.episodes)
+	}
+	if len(store.seasons) != 1 || len(store.seasons["tt0000002"]) != 3 {
+		t.Errorf("TestIMDBClientStoreWriteThrough(seasons) = got (%v), want the three seasons of tt0000002 only.", store.seasons)
+	}
+}
+
+func TestIMDBClientStoreListings(t *testing.T) {
+	var calls atomic.Int32
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{}`))
+	}))
+	defer counting.Close()
+
+	url, err := url.Parse(counting.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+	store := newMemoryStore()
+	store.listings["tt0111161"] = []*models.ImdbapiCredit{{Category: "actor", Name: &models.ImdbapiName{ID: "nm0000209"}}}
+	store.episodes["tt0903747"] = []*models.ImdbapiEpisode{{ID: "tt0959621", Season: "1", EpisodeNumber: 1}, {ID: "tt1054724", Season: "1", EpisodeNumber: 2}}
+	store.seasons["tt0903747"] = []*models.ImdbapiSeason{{Season: "1", EpisodeCount: 2}, {Season: "2"}}
+	imdbClient := NewWithOptions(&ImdbClientOptions{ApiURL: url, Store: store})
+
+	var credits []string
+	for credit, err := range imdbClient.TitleCredits(context.Background(), "tt0111161") {
+		if err != nil {
+			t.Fatalf("TestIMDBClientStoreListings(credits) = unexpected error (%v)", err)
+		}
+		credits = append(credits, credit.Name.ID)
+	}
+	if !slices.Equal(credits, []string{"nm0000209"}) {
+		t.Errorf("TestIMDBClientStoreListings(credits) = got (%v), want the stored credit.", credits)
+	}
+	guide, appErr := imdbClient.EpisodeGuide(context.Background(), "tt0903747")
+	if appErr != nil || len(guide.Seasons) != 2 || len(guide.Seasons[0].Episodes) != 2 || len(guide.Seasons[1].Episodes) != 0 {
+		t.Errorf("TestIMDBClientStoreListings(episodes) = got (%v, %v), want a season of two episodes and an empty one.", guide, appErr)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("TestIMDBClientStoreListings = server saw %d calls, want none.", calls.Load())
+	}
+
+	for range imdbClient.TitleCredits(context.Background(), "tt0111161", QueryParameters{Key: "categories", Value: "actor"}) {
+	}
+	for range imdbClient.TitleCredits(WithRefresh(context.Background()), "tt0111161") {
+	}
+	if calls.Load() != 2 {
+		t.Errorf("TestIMDBClientStoreListings = server saw %d calls, want the filtered and refreshed listings asked of it.", calls.Load())
+	}
+}
+
+func TestIMDBClientOffline(t *testing.T) {
+	var calls atomic.Int32
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{}`))
+	}))
+	defer counting.Close()
+
+	url, err := url.Parse(counting.URL)
+	if err != nil {
+		t.Error("Failed to parse url")
+	}
+	lru := cache.NewLRU(1 << 20)
+	store := newMemoryStore()
+	store.titles["tt0111161"] = &models.ImdbapiTitle{ID: "tt0111161", PrimaryTitle: "Stored"}
+	imdbClient := NewWithOptions(&ImdbClientOptions{ApiURL: url, Cache: lru, CacheTTL: cache.TTLPolicy{Default: time.Minute}, Store: store, Offline: true})
+	lru.Set(imdbClient.makeUrl("names/nm0000151", nil), []byte(`{"id":"nm0000151","displayName":"Cached"}`), time.Minute)
+
+	if title, appErr := imdbClient.GetTitle(context.Background(), "tt0111161"); appErr != nil || title.PrimaryTitle != "Stored" {
+		t.Errorf("TestIMDBClientOffline(store) = got (%v, %v), want the stored title.", title, appErr)
+	}
+	if name, appErr := imdbClient.GetName(context.Background(), "nm0000151"); appErr != nil || name.DisplayName != "Cached" {
+		t.Errorf("TestIMDBClientOffline(cache) = got (%v, %v), want the cached name.", name, appErr)
+	}
+
+	_, appErr := imdbClient.GetTitle(context.Background(), "tt0468569")
+	if !e.Is(appErr, errors.ErrOffline) {
+		t.Errorf("TestIMDBClientOffline(miss) = got (%v), want (%v).", appErr, errors.ErrOffline)
+	}
+	for _, err := range imdbClient.TitleCredits(context.Background(), "tt0111161") {
+		if !e.Is(err, errors.ErrOffline) {
+			t.Errorf("TestIMDBClientOffline(credits) = got (%v), want (%v).", err, errors.ErrOffline)
+		}
+	}
+
+	if calls.Load() != 0 {
+		t.Errorf("TestIMDBClientOffline = server saw %d calls, want none.", calls.Load())
+	}
+}
+
 func TestMain(m *testing.M) {
 	t := &testing.T{}
 	server = tests.SetupServer(t)
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	ce "github.com/foursixnine/imdblookup/internal/errors"
+	"github.com/foursixnine/imdblookup/internal/ids"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+type SeasonKind int
+
+const (
+	SeasonRegular SeasonKind = iota
+	SeasonSpecials
+	SeasonUnknown
+)
+
+// EpisodeGuide is every episode of a series, regular seasons in ascending
+// order followed by specials and then episodes the api could not place.
+type EpisodeGuide struct {
+	SeriesID string         `json:"seriesId"`
+	Seasons  []*SeasonGuide `json:"seasons"`
+}
+
+type SeasonGuide struct {
+	// Season is the label the api uses, Number its numeric value for
+	// regular seasons and 0 otherwise.
+	Season   string                   `json:"season"`
+	Number   int                      `json:"number"`
+	Kind     SeasonKind               `json:"kind"`
+	Episodes []*models.ImdbapiEpisode `json:"episodes"`
+}
+
+func (guide *EpisodeGuide) Episodes() []*models.ImdbapiEpisode {
+	var episodes []*models.ImdbapiEpisode
+	for _, season := range guide.Seasons {
+		episodes = append(episodes, season.Episodes...)
+	}
+	return episodes
+}
+
+func (imdbClient *ImdbClient) TitleSeasons(ctx context.Context, titleID ids.TitleID) ([]*models.ImdbapiSeason, *ce.IMDBClientApplicationError) {
+	path, err := titlePath(titleID, "seasons")
+	if err != nil {
+		return nil, ce.NewIMDBClientApplicationError("Invalid title id", err)
+	}
+	seasons, appErr := getJSON[models.ImdbapiListTitleSeasonsResponse](ctx, imdbClient, path, nil, "seasons")
+	if appErr != nil {
+		return nil, appErr
+	}
+	return seasons.Seasons, nil
+}
+
+// EpisodeGuide lists the seasons of seriesID and pages through all of its
+// episodes, grouping them by the season reported on each. Episodes of a
+// season missing from the season list, or of none at all, are kept under
+// that season, which is SeasonUnknown when it is not a number. The episodes
+// and seasons are read from and saved to the store together.
+func (imdbClient *ImdbClient) EpisodeGuide(ctx context.Context, seriesID ids.TitleID) (*EpisodeGuide, *ce.IMDBClientApplicationError) {
+	id, err := ids.ParseTitleID(string(seriesID))
+	if err != nil {
+		return nil, ce.NewIMDBClientApplicationError("Invalid title id", err)
+	}
+	if episodes, ok := listFromStore(ctx, imdbClient, id.String(), Store.Episodes); ok {
+		seasons, _ := listFromStore(ctx, imdbClient, id.String(), Store.Seasons)
+		return newEpisodeGuide(id, seasons, episodes), nil
+	}
+
+	seasons, appErr := imdbClient.TitleSeasons(ctx, id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	// Listed in one go, TitleEpisodes stores them itself.
+	var episodes []*models.ImdbapiEpisode
+	for episode, err := range imdbClient.TitleEpisodes(ctx, id) {
+		if err != nil {
+			return nil, ce.NewIMDBClientApplicationError("An error occurred querying episodes", err)
+		}
+		episodes = append(episodes, episode)
+	}
+	imdbClient.saveToStore("seasons", id.String(), func(store Store) error { return store.SaveSeasons(ctx, id.String(), seasons) })
+	return newEpisodeGuide(id, seasons, episodes), nil
+}
+
+// newEpisodeGuide groups episodes by season, seasons listing those known to
+// exist even without episodes.
+func newEpisodeGuide(seriesID ids.TitleID, seasons []*models.ImdbapiSeason, episodes []*models.ImdbapiEpisode) *EpisodeGuide {
+	guide := &EpisodeGuide{SeriesID: seriesID.String()}
+	bySeason := map[string]*SeasonGuide{}
+	for _, season := range seasons {
+		if season != nil && season.Season != "" {
+			bySeason[season.Season] = newSeasonGuide(season.Season)
+		}
+	}
+	for _, episode := range episodes {
+		season, ok := bySeason[episode.Season]
+		if !ok {
+			season = newSeasonGuide(episode.Season)
+			bySeason[episode.Season] = season
+		}
+		season.Episodes = append(season.Episodes, episode)
+	}
+
+	for _, season := range bySeason {
+		slices.SortStableFunc(season.Episodes, compareEpisodes)
+		guide.Seasons = append(guide.Seasons, season)
+	}
+	slices.SortFunc(guide.Seasons, func(a, b *SeasonGuide) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Number, b.Number), strings.Compare(a.Season, b.Season))
+	})
+	return guide
+}
+
+func newSeasonGuide(label string) *SeasonGuide {
+	season := &SeasonGuide{Season: label, Kind: SeasonUnknown}
+	if number, err := strconv.Atoi(strings.TrimSpace(label)); err == nil {
+		if number > 0 {
+			season.Number, season.Kind = number, SeasonRegular
+		} else {
+			season.Kind = SeasonSpecials
+		}
+	} else if strings.HasPrefix(strings.ToLower(label), "special") {
+		season.Kind = SeasonSpecials
+	}
+	return season
+}
+
+// compareEpisodes orders by episode number, unnumbered episodes last and in
+// air date order.
+func compareEpisodes(a, b *models.ImdbapiEpisode) int {
+	if (a.EpisodeNumber == 0) != (b.EpisodeNumber == 0) {
+		if a.EpisodeNumber == 0 {
+			return 1
+		}
+		return -1
+	}
+	return cmp.Or(cmp.Compare(a.EpisodeNumber, b.EpisodeNumber), cmp.Compare(airDate(a), airDate(b)))
+}
+
+func airDate(episode *models.ImdbapiEpisode) int32 {
+	if episode.ReleaseDate == nil {
+		return 0
+	}
+	return episode.ReleaseDate.Year*10000 + episode.ReleaseDate.Month*100 + episode.ReleaseDate.Day
+}
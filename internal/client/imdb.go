@@ -1,40 +1,292 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"path"
+	"strconv"
 
 	ce "github.com/foursixnine/imdblookup/internal/errors"
+	"github.com/foursixnine/imdblookup/internal/ids"
 	"github.com/foursixnine/imdblookup/models"
+	"github.com/go-openapi/strfmt"
 )
 
+// ListTitlesFilter holds the optional filters accepted by /titles.
+// Zero values are left out of the request.
+type ListTitlesFilter struct {
+	Types              []models.ImdbapiTitleType
+	Genres             []string
+	CountryCodes       []string
+	LanguageCodes      []string
+	NameIDs            []string
+	InterestIDs        []string
+	StartYear          int32
+	EndYear            int32
+	MinVoteCount       int32
+	MaxVoteCount       int32
+	MinAggregateRating float32
+	MaxAggregateRating float32
+	SortBy             models.ImdbapiTitleSortBy
+	SortOrder          models.ImdbapiSortOrder
+
+	// MaxResults caps the number of titles collected across pages, 0 means
+	// follow nextPageToken until the server runs out of results.
+	MaxResults int
+}
+
 func (imdbClient *ImdbClient) FindShowsByTitle(searchTitle *string) ([]*models.ImdbapiTitle, *ce.IMDBClientApplicationError) {
-	if *searchTitle == "" {
+	return imdbClient.FindShowsByTitleContext(context.Background(), searchTitle)
+}
+
+func (imdbClient *ImdbClient) FindShowsByTitleContext(ctx context.Context, searchTitle *string) ([]*models.ImdbapiTitle, *ce.IMDBClientApplicationError) {
+	return imdbClient.SearchTitles(ctx, *searchTitle, 5)
+}
+
+func (imdbClient *ImdbClient) SearchTitles(ctx context.Context, searchTitle string, limit int) ([]*models.ImdbapiTitle, *ce.IMDBClientApplicationError) {
+	if searchTitle == "" {
 		err := ce.NewIMDBClientApplicationError("Search title cannot be empty", nil)
 		return nil, err
 	}
 
-	var titlesResults models.ImdbapiSearchTitlesResponse
-	var titles []*models.ImdbapiTitle
-
-	path := "search/titles"
 	parameters := []QueryParameters{
-		{Key: "query", Value: *searchTitle},
-		{Key: "limit", Value: "5"},
+		{Key: "query", Value: searchTitle},
+	}
+	if limit > 0 {
+		parameters = append(parameters, QueryParameters{Key: "limit", Value: strconv.Itoa(limit)})
 	}
 
-	resp, err := imdbClient.Get(path, &parameters)
+	titlesResults, err := getJSON[models.ImdbapiSearchTitlesResponse](ctx, imdbClient, "search/titles", parameters, "search results")
 	if err != nil {
-		clientErr, ok := err.(*ce.IMDBClientError)
-		if !ok {
-			return nil, ce.NewIMDBClientApplicationError("unexpected error type: %w", err)
-		}
-		return nil, ce.NewIMDBClientApplicationError("An error occurred querying search results", clientErr)
+		return nil, err
+	}
+	return titlesResults.Titles, nil
+}
+
+func (imdbClient *ImdbClient) GetTitle(ctx context.Context, titleID ids.TitleID) (*models.ImdbapiTitle, *ce.IMDBClientApplicationError) {
+	id, err := ids.ParseTitleID(string(titleID))
+	if err != nil {
+		return nil, ce.NewIMDBClientApplicationError("Invalid title id", err)
+	}
+	if title := fromStore(ctx, imdbClient, id.String(), Store.Title); title != nil {
+		return title, nil
+	}
+
+	path, _ := titlePath(id)
+	title, appErr := getJSON[models.ImdbapiTitle](ctx, imdbClient, path, nil, "title")
+	if appErr != nil {
+		return nil, appErr
+	}
+	imdbClient.saveToStore("title", id.String(), func(store Store) error { return store.SaveTitle(ctx, title) })
+	return title, nil
+}
+
+func (imdbClient *ImdbClient) TitleBoxOffice(ctx context.Context, titleID ids.TitleID) (*models.ImdbapiBoxOffice, *ce.IMDBClientApplicationError) {
+	path, err := titlePath(titleID, "boxOffice")
+	if err != nil {
+		return nil, ce.NewIMDBClientApplicationError("Invalid title id", err)
+	}
+	return getJSON[models.ImdbapiBoxOffice](ctx, imdbClient, path, nil, "box office")
+}
+
+func (imdbClient *ImdbClient) TitleCertificates(ctx context.Context, titleID ids.TitleID) ([]*models.ImdbapiCertificate, *ce.IMDBClientApplicationError) {
+	path, err := titlePath(titleID, "certificates")
+	if err != nil {
+		return nil, ce.NewIMDBClientApplicationError("Invalid title id", err)
+	}
+	certificates, appErr := getJSON[models.ImdbapiListTitleCertificatesResponse](ctx, imdbClient, path, nil, "certificates")
+	if appErr != nil {
+		return nil, appErr
+	}
+	return certificates.Certificates, nil
+}
+
+func (imdbClient *ImdbClient) TitleAKAs(ctx context.Context, titleID ids.TitleID) ([]*models.ImdbapiAKA, *ce.IMDBClientApplicationError) {
+	path, err := titlePath(titleID, "akas")
+	if err != nil {
+		return nil, ce.NewIMDBClientApplicationError("Invalid title id", err)
+	}
+	akas, appErr := getJSON[models.ImdbapiListTitleAKAsResponse](ctx, imdbClient, path, nil, "AKAs")
+	if appErr != nil {
+		return nil, appErr
+	}
+	return akas.Akas, nil
+}
+
+func (imdbClient *ImdbClient) GetName(ctx context.Context, nameID ids.NameID) (*models.ImdbapiName, *ce.IMDBClientApplicationError) {
+	id, err := ids.ParseNameID(string(nameID))
+	if err != nil {
+		return nil, ce.NewIMDBClientApplicationError("Invalid name id", err)
+	}
+	if name := fromStore(ctx, imdbClient, id.String(), Store.Name); name != nil {
+		return name, nil
+	}
+
+	path, _ := namePath(id)
+	name, appErr := getJSON[models.ImdbapiName](ctx, imdbClient, path, nil, "name")
+	if appErr != nil {
+		return nil, appErr
 	}
+	imdbClient.saveToStore("name", id.String(), func(store Store) error { return store.SaveName(ctx, name) })
+	return name, nil
+}
+
+func (imdbClient *ImdbClient) InterestCategories(ctx context.Context) ([]*models.ImdbapiInterestCategory, *ce.IMDBClientApplicationError) {
+	categories, err := getJSON[models.ImdbapiListListInterestCategoriesResponse](ctx, imdbClient, "interests", nil, "interests")
+	if err != nil {
+		return nil, err
+	}
+	return categories.Categories, nil
+}
 
-	if err := json.Unmarshal(resp, &titlesResults); err != nil {
+func (imdbClient *ImdbClient) GetInterest(ctx context.Context, interestID ids.InterestID) (*models.ImdbapiInterest, *ce.IMDBClientApplicationError) {
+	path, err := interestPath(interestID)
+	if err != nil {
+		return nil, ce.NewIMDBClientApplicationError("Invalid interest id", err)
+	}
+	return getJSON[models.ImdbapiInterest](ctx, imdbClient, path, nil, "interest")
+}
+
+// titlePath checks titleID, which may also be an imdb.com url, and builds
+// the path of the title or of one of its resources.
+func titlePath(titleID ids.TitleID, resource ...string) (string, error) {
+	id, err := ids.ParseTitleID(string(titleID))
+	if err != nil {
+		return "", err
+	}
+	return path.Join(append([]string{"titles", id.String()}, resource...)...), nil
+}
+
+func namePath(nameID ids.NameID, resource ...string) (string, error) {
+	id, err := ids.ParseNameID(string(nameID))
+	if err != nil {
+		return "", err
+	}
+	return path.Join(append([]string{"names", id.String()}, resource...)...), nil
+}
+
+func interestPath(interestID ids.InterestID) (string, error) {
+	id, err := ids.ParseInterestID(string(interestID))
+	if err != nil {
+		return "", err
+	}
+	return "interests/" + id.String(), nil
+}
+
+// getJSON fetches path and decodes the body into a T, what names the
+// resource in error messages.
+func getJSON[T any](ctx context.Context, imdbClient *ImdbClient, path string, parameters []QueryParameters, what string) (*T, *ce.IMDBClientApplicationError) {
+	resp, err := imdbClient.GetContext(ctx, path, &parameters)
+	if err != nil {
+		return nil, ce.NewIMDBClientApplicationError("An error occurred querying "+what, err)
+	}
+
+	var result T
+	if err := json.Unmarshal(resp, &result); err != nil {
 		return nil, ce.NewIMDBClientApplicationError("error: JSON answer cannot be read", err)
 	}
+	return &result, nil
+}
+
+func (imdbClient *ImdbClient) ListTitles(filter *ListTitlesFilter) ([]*models.ImdbapiTitle, *ce.IMDBClientApplicationError) {
+	return imdbClient.ListTitlesContext(context.Background(), filter)
+}
+
+func (imdbClient *ImdbClient) ListTitlesContext(ctx context.Context, filter *ListTitlesFilter) ([]*models.ImdbapiTitle, *ce.IMDBClientApplicationError) {
+	if filter == nil {
+		filter = &ListTitlesFilter{}
+	}
+
+	parameters, err := filter.queryParameters()
+	if err != nil {
+		return nil, ce.NewIMDBClientApplicationError("Invalid title filter", err)
+	}
+
+	var titles []*models.ImdbapiTitle
+	pages := paginate(ctx, imdbClient, "titles", parameters, func(r *models.ImdbapiListTitlesResponse) ([]*models.ImdbapiTitle, string) {
+		return r.Titles, r.NextPageToken
+	})
+
+	for title, err := range pages {
+		if err != nil {
+			return titles, ce.NewIMDBClientApplicationError("An error occurred listing titles", err)
+		}
+		titles = append(titles, title)
+		if filter.MaxResults > 0 && len(titles) >= filter.MaxResults {
+			break
+		}
+	}
 
-	titles = titlesResults.Titles
 	return titles, nil
 }
+
+func (filter *ListTitlesFilter) queryParameters() ([]QueryParameters, error) {
+	var parameters []QueryParameters
+
+	for _, titleType := range filter.Types {
+		if err := titleType.Validate(strfmt.Default); err != nil {
+			return nil, err
+		}
+		parameters = append(parameters, QueryParameters{Key: "types", Value: string(titleType)})
+	}
+
+	multi := []struct {
+		key    string
+		values []string
+	}{
+		{"genres", filter.Genres},
+		{"countryCodes", filter.CountryCodes},
+		{"languageCodes", filter.LanguageCodes},
+		{"nameIds", filter.NameIDs},
+		{"interestIds", filter.InterestIDs},
+	}
+	for _, m := range multi {
+		for _, value := range m.values {
+			parameters = append(parameters, QueryParameters{Key: m.key, Value: value})
+		}
+	}
+
+	integers := []struct {
+		key   string
+		value int32
+	}{
+		{"startYear", filter.StartYear},
+		{"endYear", filter.EndYear},
+		{"minVoteCount", filter.MinVoteCount},
+		{"maxVoteCount", filter.MaxVoteCount},
+	}
+	for _, i := range integers {
+		if i.value != 0 {
+			parameters = append(parameters, QueryParameters{Key: i.key, Value: strconv.FormatInt(int64(i.value), 10)})
+		}
+	}
+
+	floats := []struct {
+		key   string
+		value float32
+	}{
+		{"minAggregateRating", filter.MinAggregateRating},
+		{"maxAggregateRating", filter.MaxAggregateRating},
+	}
+	for _, f := range floats {
+		if f.value != 0 {
+			parameters = append(parameters, QueryParameters{Key: f.key, Value: strconv.FormatFloat(float64(f.value), 'f', -1, 32)})
+		}
+	}
+
+	if filter.SortBy != "" {
+		if err := filter.SortBy.Validate(strfmt.Default); err != nil {
+			return nil, err
+		}
+		parameters = append(parameters, QueryParameters{Key: "sortBy", Value: string(filter.SortBy)})
+	}
+
+	if filter.SortOrder != "" {
+		if err := filter.SortOrder.Validate(strfmt.Default); err != nil {
+			return nil, err
+		}
+		parameters = append(parameters, QueryParameters{Key: "sortOrder", Value: string(filter.SortOrder)})
+	}
+
+	return parameters, nil
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+
+	ce "github.com/foursixnine/imdblookup/internal/errors"
+	"github.com/foursixnine/imdblookup/internal/ids"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// paginate walks an endpoint that returns nextPageToken, fetching a page only
+// once the previous one has been consumed. A failed request or undecodable
+// page is yielded as the error and ends the sequence.
+func paginate[R any, T any](ctx context.Context, imdbClient *ImdbClient, path string, params []QueryParameters, page func(*R) ([]*T, string)) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		pageToken := ""
+		for {
+			pageParameters := params[:len(params):len(params)]
+			if pageToken != "" {
+				pageParameters = append(pageParameters, QueryParameters{Key: "pageToken", Value: pageToken})
+			}
+
+			resp, err := imdbClient.GetContext(ctx, path, &pageParameters)
+			if err != nil {
+				yield(nil, ce.NewIMDBClientApplicationError("An error occurred querying "+path, err))
+				return
+			}
+
+			var response R
+			if err := json.Unmarshal(resp, &response); err != nil {
+				yield(nil, ce.NewIMDBClientApplicationError("error: JSON answer cannot be read", err))
+				return
+			}
+
+			items, nextPageToken := page(&response)
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if nextPageToken == "" || nextPageToken == pageToken {
+				return
+			}
+			pageToken = nextPageToken
+		}
+	}
+}
+
+// failed is a sequence yielding only err, for arguments rejected before any
+// request is made.
+func failed[T any](err error) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		yield(nil, err)
+	}
+}
+
+// TitleCredits lists the credits of titleID. Without params the listing
+// is read from and saved whole to the store, filtered ones are only ever
+// asked of the api.
+func (imdbClient *ImdbClient) TitleCredits(ctx context.Context, titleID ids.TitleID, params ...QueryParameters) iter.Seq2[*models.ImdbapiCredit, error] {
+	path, err := titlePath(titleID, "credits")
+	if err != nil {
+		return failed[models.ImdbapiCredit](ce.NewIMDBClientApplicationError("Invalid title id", err))
+	}
+	id := pathID(path)
+	if len(params) == 0 {
+		if credits, ok := listFromStore(ctx, imdbClient, id, Store.Credits); ok {
+			return stored(credits)
+		}
+	}
+	credits := paginate(ctx, imdbClient, path, params, func(r *models.ImdbapiListTitleCreditsResponse) ([]*models.ImdbapiCredit, string) {
+		return r.Credits, r.NextPageToken
+	})
+	if len(params) > 0 {
+		return credits
+	}
+	return storingAll(imdbClient, credits, "credits", id, func(store Store, credits []*models.ImdbapiCredit) error {
+		return store.SaveCredits(ctx, id, credits)
+	})
+}
+
+func (imdbClient *ImdbClient) TitleReleaseDates(ctx context.Context, titleID ids.TitleID, params ...QueryParameters) iter.Seq2[*models.ImdbapiReleaseDate, error] {
+	path, err := titlePath(titleID, "releaseDates")
+	if err != nil {
+		return failed[models.ImdbapiReleaseDate](ce.NewIMDBClientApplicationError("Invalid title id", err))
+	}
+	return paginate(ctx, imdbClient, path, params, func(r *models.ImdbapiListTitleReleaseDatesResponse) ([]*models.ImdbapiReleaseDate, string) {
+		return r.ReleaseDates, r.NextPageToken
+	})
+}
+
+// TitleEpisodes lists the episodes of titleID, going through the store
+// like TitleCredits.
+func (imdbClient *ImdbClient) TitleEpisodes(ctx context.Context, titleID ids.TitleID, params ...QueryParameters) iter.Seq2[*models.ImdbapiEpisode, error] {
+	path, err := titlePath(titleID, "episodes")
+	if err != nil {
+		return failed[models.ImdbapiEpisode](ce.NewIMDBClientApplicationError("Invalid title id", err))
+	}
+	id := pathID(path)
+	if len(params) == 0 {
+		if episodes, ok := listFromStore(ctx, imdbClient, id, Store.Episodes); ok {
+			return stored(episodes)
+		}
+	}
+	episodes := paginate(ctx, imdbClient, path, params, func(r *models.ImdbapiListTitleEpisodesResponse) ([]*models.ImdbapiEpisode, string) {
+		return r.Episodes, r.NextPageToken
+	})
+	if len(params) > 0 {
+		return episodes
+	}
+	return storingAll(imdbClient, episodes, "episodes", id, func(store Store, episodes []*models.ImdbapiEpisode) error {
+		return store.SaveEpisodes(ctx, id, episodes)
+	})
+}
+
+func (imdbClient *ImdbClient) TitleImages(ctx context.Context, titleID ids.TitleID, params ...QueryParameters) iter.Seq2[*models.ImdbapiImage, error] {
+	path, err := titlePath(titleID, "images")
+	if err != nil {
+		return failed[models.ImdbapiImage](ce.NewIMDBClientApplicationError("Invalid title id", err))
+	}
+	return paginate(ctx, imdbClient, path, params, func(r *models.ImdbapiListTitleImagesResponse) ([]*models.ImdbapiImage, string) {
+		return r.Images, r.NextPageToken
+	})
+}
+
+func (imdbClient *ImdbClient) TitleVideos(ctx context.Context, titleID ids.TitleID, params ...QueryParameters) iter.Seq2[*models.ImdbapiVideo, error] {
+	path, err := titlePath(titleID, "videos")
+	if err != nil {
+		return failed[models.ImdbapiVideo](ce.NewIMDBClientApplicationError("Invalid title id", err))
+	}
+	return paginate(ctx, imdbClient, path, params, func(r *models.ImdbapiListTitleVideosResponse) ([]*models.ImdbapiVideo, string) {
+		return r.Videos, r.NextPageToken
+	})
+}
+
+func (imdbClient *ImdbClient) TitleAwardNominations(ctx context.Context, titleID ids.TitleID, params ...QueryParameters) iter.Seq2[*models.ImdbapiAwardNomination, error] {
+	path, err := titlePath(titleID, "awardNominations")
+	if err != nil {
+		return failed[models.ImdbapiAwardNomination](ce.NewIMDBClientApplicationError("Invalid title id", err))
+	}
+	return paginate(ctx, imdbClient, path, params, func(r *models.ImdbapiListTitleAwardNominationsResponse) ([]*models.ImdbapiAwardNomination, string) {
+		return r.AwardNominations, r.NextPageToken
+	})
+}
+
+func (imdbClient *ImdbClient) TitleCompanyCredits(ctx context.Context, titleID ids.TitleID, params ...QueryParameters) iter.Seq2[*models.ImdbapiCompanyCredit, error] {
+	path, err := titlePath(titleID, "companyCredits")
+	if err != nil {
+		return failed[models.ImdbapiCompanyCredit](ce.NewIMDBClientApplicationError("Invalid title id", err))
+	}
+	return paginate(ctx, imdbClient, path, params, func(r *models.ImdbapiListTitleCompanyCreditsResponse) ([]*models.ImdbapiCompanyCredit, string) {
+		return r.CompanyCredits, r.NextPageToken
+	})
+}
+
+func (imdbClient *ImdbClient) NameImages(ctx context.Context, nameID ids.NameID, params ...QueryParameters) iter.Seq2[*models.ImdbapiImage, error] {
+	path, err := namePath(nameID, "images")
+	if err != nil {
+		return failed[models.ImdbapiImage](ce.NewIMDBClientApplicationError("Invalid name id", err))
+	}
+	return paginate(ctx, imdbClient, path, params, func(r *models.ImdbapiListNameImagesResponse) ([]*models.ImdbapiImage, string) {
+		return r.Images, r.NextPageToken
+	})
+}
+
+func (imdbClient *ImdbClient) NameFilmography(ctx context.Context, nameID ids.NameID, params ...QueryParameters) iter.Seq2[*models.ImdbapiCredit, error] {
+	path, err := namePath(nameID, "filmography")
+	if err != nil {
+		return failed[models.ImdbapiCredit](ce.NewIMDBClientApplicationError("Invalid name id", err))
+	}
+	credits := paginate(ctx, imdbClient, path, params, func(r *models.ImdbapiListNameFilmographyResponse) ([]*models.ImdbapiCredit, string) {
+		return r.Credits, r.NextPageToken
+	})
+	id := pathID(path)
+	return storing(imdbClient, credits, "filmography", id, func(store Store, credit *models.ImdbapiCredit) error {
+		if credit.Title == nil {
+			return nil
+		}
+		return store.SaveCredit(ctx, credit.Title.ID, id, credit)
+	})
+}
+
+func (imdbClient *ImdbClient) NameTrivia(ctx context.Context, nameID ids.NameID, params ...QueryParameters) iter.Seq2[*models.ImdbapiNameTrivia, error] {
+	path, err := namePath(nameID, "trivia")
+	if err != nil {
+		return failed[models.ImdbapiNameTrivia](ce.NewIMDBClientApplicationError("Invalid name id", err))
+	}
+	return paginate(ctx, imdbClient, path, params, func(r *models.ImdbapiListNameTriviaResponse) ([]*models.ImdbapiNameTrivia, string) {
+		return r.TriviaEntries, r.NextPageToken
+	})
+}
+
+func (imdbClient *ImdbClient) StarMeter(ctx context.Context) iter.Seq2[*models.ImdbapiName, error] {
+	return paginate(ctx, imdbClient, "chart/starmeter", nil, func(r *models.ImdbapiListStarMetersResponse) ([]*models.ImdbapiName, string) {
+		return r.Names, r.NextPageToken
+	})
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// LimiterStats reports how long requests spent waiting for the rate limiter
+// and for a free in-flight slot before being sent.
+type LimiterStats struct {
+	Requests  int64
+	Delayed   int64
+	TotalWait time.Duration
+	MaxWait   time.Duration
+}
+
+func (s LimiterStats) AverageWait() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Requests)
+}
+
+// limiter is a token bucket refilled at rate tokens per second, holding at
+// most burst tokens, combined with a semaphore of maxInFlight slots. A zero
+// rate or maxInFlight disables that half.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	slots  chan struct{}
+	stats  LimiterStats
+}
+
+func newLimiter(rate float64, burst int, maxInFlight int) *limiter {
+	l := &limiter{rate: rate, burst: float64(max(burst, 1))}
+	l.tokens = l.burst
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+	return l
+}
+
+// reserve takes a token and returns how long the caller has to wait before
+// the token is actually available.
+func (l *limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+func (l *limiter) unreserve() {
+	l.mu.Lock()
+	l.tokens = min(l.burst, l.tokens+1)
+	l.mu.Unlock()
+}
+
+// wait blocks until the request may be sent, the returned release must be
+// called once the request is finished with its in-flight slot.
+func (l *limiter) wait(ctx context.Context) (release func(), err error) {
+	start := time.Now()
+
+	if l.rate > 0 {
+		if delay := l.reserve(start); delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				l.unreserve()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+
+	release = func() {}
+	if l.slots != nil {
+		select {
+		case <-ctx.Done():
+			// The request is not sent, so its token goes back as well.
+			if l.rate > 0 {
+				l.unreserve()
+			}
+			return nil, ctx.Err()
+		case l.slots <- struct{}{}:
+		}
+		var once sync.Once
+		release = func() { once.Do(func() { <-l.slots }) }
+	}
+
+	l.record(time.Since(start))
+	return release, nil
+}
+
+func (l *limiter) record(waited time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.Requests++
+	if waited > time.Millisecond {
+		l.stats.Delayed++
+	}
+	l.stats.TotalWait += waited
+	l.stats.MaxWait = max(l.stats.MaxWait, waited)
+}
+
+func (l *limiter) snapshot() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// releasingBody hands the in-flight slot back once the caller is done
+// reading the response.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
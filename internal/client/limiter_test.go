@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	e "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiterRate(t *testing.T) {
+	url, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal("Failed to parse url")
+	}
+
+	imdbClient := NewWithOptions(&ImdbClientOptions{ApiURL: url, RateLimit: 50, RateBurst: 2})
+
+	start := time.Now()
+	for range 5 {
+		if _, err := imdbClient.Get("", &[]QueryParameters{}); err != nil {
+			t.Fatalf("TestLimiterRate = unexpected error (%v)", err)
+		}
+	}
+
+	// Two requests ride the burst, the other three wait 20ms each.
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("TestLimiterRate = 5 requests took %v, want at least 60ms", elapsed)
+	}
+
+	stats := imdbClient.LimiterStats()
+	if stats.Requests != 5 || stats.Delayed < 3 || stats.MaxWait <= 0 || stats.AverageWait() <= 0 {
+		t.Errorf("TestLimiterRate = unexpected stats %+v", stats)
+	}
+}
+
+func TestLimiterMaxInFlight(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			observed := peak.Load()
+			if current <= observed || peak.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(w, "Hello, world")
+	}))
+	defer slow.Close()
+
+	url, err := url.Parse(slow.URL)
+	if err != nil {
+		t.Fatal("Failed to parse url")
+	}
+	imdbClient := NewWithOptions(&ImdbClientOptions{ApiURL: url, MaxInFlight: 2})
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			if _, err := imdbClient.Get("", &[]QueryParameters{}); err != nil {
+				t.Errorf("TestLimiterMaxInFlight = unexpected error (%v)", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if peak.Load() > 2 {
+		t.Errorf("TestLimiterMaxInFlight = saw %d concurrent requests, want at most 2", peak.Load())
+	}
+}
+
+func TestLimiterContextCancelled(t *testing.T) {
+	l := newLimiter(1, 1, 0)
+	if _, err := l.wait(context.Background()); err != nil {
+		t.Fatalf("TestLimiterContextCancelled = unexpected error (%v)", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.wait(ctx); !e.Is(err, context.DeadlineExceeded) {
+		t.Errorf("TestLimiterContextCancelled = got (%v), want (%v).", err, context.DeadlineExceeded)
+	}
+}
+
+func TestLimiterCancelledWhileInFlight(t *testing.T) {
+	// A slow refill, so a token handed back is not mistaken for a new one.
+	l := newLimiter(0.001, 2, 1)
+	release, err := l.wait(context.Background())
+	if err != nil {
+		t.Fatalf("TestLimiterCancelledWhileInFlight = unexpected error (%v)", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.wait(ctx); !e.Is(err, context.DeadlineExceeded) {
+		t.Errorf("TestLimiterCancelledWhileInFlight = got (%v), want (%v).", err, context.DeadlineExceeded)
+	}
+
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	if tokens < 1 {
+		t.Errorf("TestLimiterCancelledWhileInFlight = %.3f tokens left, want the cancelled request's token back.", tokens)
+	}
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	ce "github.com/foursixnine/imdblookup/internal/errors"
+)
+
+// RetryPolicy describes how the transport retries a failed request. A nil
+// policy, or one with MaxAttempts below 2, sends every request exactly once.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter is the fraction, between 0 and 1, of each backoff delay that is
+	// randomised.
+	Jitter               float64
+	RetryableStatusCodes []int
+	// RetryableError reports whether a transport error is worth another
+	// attempt, IsRetryableError is used when it is nil.
+	RetryableError func(error) bool
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// IsRetryableError treats connection resets, truncated responses and
+// timeouts as transient.
+func IsRetryableError(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (policy *RetryPolicy) enabled() bool {
+	return policy != nil && policy.MaxAttempts > 1
+}
+
+func (policy *RetryPolicy) retryableError(err error) bool {
+	if policy.RetryableError != nil {
+		return policy.RetryableError(err)
+	}
+	return IsRetryableError(err)
+}
+
+// backoff returns the delay before the given retry, attempt being the number
+// of attempts already made. The server's Retry-After wins over a shorter
+// backoff, roundTripWithRetry gives up rather than wait past MaxDelay.
+func (policy *RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	delay := policy.BaseDelay << (attempt - 1)
+	if delay <= 0 || (policy.MaxDelay > 0 && delay > policy.MaxDelay) {
+		delay = policy.MaxDelay
+	}
+	if policy.Jitter > 0 && delay > 0 {
+		spread := time.Duration(float64(delay) * min(policy.Jitter, 1))
+		delay = delay - spread + time.Duration(rand.Int64N(int64(2*spread)+1))
+	}
+	return max(delay, retryAfter)
+}
+
+// parseRetryAfter understands both forms of the header, delay-seconds and
+// HTTP-date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
+
+func (t *imdbClientTransport) roundTripWithRetry(req *http.Request) (*http.Response, error) {
+	policy := t.Retry
+	var attempts []error
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, &ce.RetryError{Attempts: attempts}
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, &ce.RetryError{Attempts: append(attempts, err)}
+			}
+			req.Body = body
+		}
+
+		resp, err := t.send(req)
+
+		var attemptErr error
+		retryAfter := time.Duration(0)
+		switch {
+		case err != nil:
+			if req.Context().Err() != nil || !policy.retryableError(err) {
+				if len(attempts) == 0 {
+					return nil, err
+				}
+				return nil, &ce.RetryError{Attempts: append(attempts, err)}
+			}
+			attemptErr = err
+		case slices.Contains(policy.RetryableStatusCodes, resp.StatusCode):
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			httpErr := ce.UnexpectedError(resp.StatusCode, string(body))
+			attemptErr = httpErr
+			if apiErr, ok := ce.DecodeAPIError(httpErr, body); ok {
+				attemptErr = apiErr
+			}
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		default:
+			return resp, nil
+		}
+
+		attempts = append(attempts, attemptErr)
+		// Waiting less than the server asked for would only be turned away
+		// again.
+		if attempt >= policy.MaxAttempts || (policy.MaxDelay > 0 && retryAfter > policy.MaxDelay) {
+			return nil, &ce.RetryError{Attempts: attempts}
+		}
+
+		timer := time.NewTimer(policy.backoff(attempt, retryAfter))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, &ce.RetryError{Attempts: append(attempts, req.Context().Err())}
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package client
+
+import (
+	"context"
+	e "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/foursixnine/imdblookup/internal/errors"
+	"github.com/foursixnine/imdblookup/tests/fakeapi"
+)
+
+func newFlakyServer(t *testing.T, failures int32, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			fmt.Fprint(w, "try again")
+			return
+		}
+		fmt.Fprint(w, "Hello, world")
+	}))
+	t.Cleanup(flaky.Close)
+	return flaky, &calls
+}
+
+func newRetryClient(t *testing.T, serverURL string, policy *RetryPolicy) *ImdbClient {
+	t.Helper()
+	url, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatal("Failed to parse url")
+	}
+	return NewWithOptions(&ImdbClientOptions{ApiURL: url, UserAgent: "imdblookup/test", Retry: policy})
+}
+
+func TestRetryTransport(t *testing.T) {
+	testCases := map[string]struct {
+		failures int32
+		status   int
+		calls    int32
+		error    *errors.HTTPError
+	}{
+		"with recovery on third attempt": {
+			failures: 2,
+			status:   http.StatusServiceUnavailable,
+			calls:    3,
+		},
+		"with attempts exhausted": {
+			failures: 5,
+			status:   http.StatusBadGateway,
+			calls:    3,
+			error:    errors.UnexpectedError(http.StatusBadGateway, "try again"),
+		},
+		"with non retryable status": {
+			failures: 5,
+			status:   http.StatusInternalServerError,
+			calls:    1,
+			error:    errors.UnexpectedError(http.StatusInternalServerError, "try again"),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			flaky, calls := newFlakyServer(t, testCase.failures, testCase.status, "")
+			policy := DefaultRetryPolicy()
+			policy.BaseDelay = time.Millisecond
+			imdbClient := newRetryClient(t, flaky.URL, policy)
+
+			resp, err := imdbClient.Get("", &[]QueryParameters{})
+			if testCase.error == nil && err != nil {
+				t.Fatalf("TestRetryTransport(%s) = unexpected error (%v)", testName, err)
+			}
+			if testCase.error != nil && !e.Is(err, testCase.error) {
+				t.Errorf("TestRetryTransport(%s) = got (%v), want (%v).", testName, err, testCase.error)
+			}
+			if testCase.error == nil && string(resp) != "Hello, world" {
+				t.Errorf("TestRetryTransport(%s) = got (%s), want (Hello, world).", testName, resp)
+			}
+			if calls.Load() != testCase.calls {
+				t.Errorf("TestRetryTransport(%s) = made %d calls, want %d.", testName, calls.Load(), testCase.calls)
+			}
+		})
+	}
+}
+
+func TestRetryTransportSurfacesAttempts(t *testing.T) {
+	flaky, _ := newFlakyServer(t, 5, http.StatusTooManyRequests, "0")
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	imdbClient := newRetryClient(t, flaky.URL, policy)
+
+	_, err := imdbClient.Get("", &[]QueryParameters{})
+
+	var clientErr *errors.IMDBClientError
+	if !e.As(err, &clientErr) {
+		t.Fatalf("TestRetryTransportSurfacesAttempts = got (%v), want an IMDBClientError", err)
+	}
+	var retryErr *errors.RetryError
+	if !e.As(err, &retryErr) {
+		t.Fatalf("TestRetryTransportSurfacesAttempts = got (%v), want a RetryError", err)
+	}
+	if len(retryErr.Attempts) != policy.MaxAttempts {
+		t.Errorf("TestRetryTransportSurfacesAttempts = got %d attempts, want %d.", len(retryErr.Attempts), policy.MaxAttempts)
+	}
+}
+
+func TestRetryTransportLongRetryAfter(t *testing.T) {
+	flaky, calls := newFlakyServer(t, 5, http.StatusTooManyRequests, "3600")
+	imdbClient := newRetryClient(t, flaky.URL, DefaultRetryPolicy())
+
+	started := time.Now()
+	_, err := imdbClient.Get("", &[]QueryParameters{})
+
+	var retryErr *errors.RetryError
+	if !e.As(err, &retryErr) || len(retryErr.Attempts) != 1 {
+		t.Errorf("TestRetryTransportLongRetryAfter = got (%v), want a RetryError of one attempt.", err)
+	}
+	if calls.Load() != 1 || time.Since(started) > time.Second {
+		t.Errorf("TestRetryTransportLongRetryAfter = made %d calls in %v, want one without waiting.", calls.Load(), time.Since(started))
+	}
+}
+
+func TestRetryTransportFaults(t *testing.T) {
+	testCases := map[string]struct {
+		scenario string
+		timeout  time.Duration
+		// atLeast is the shortest the request may take, to show Retry-After
+		// was honoured.
+		atLeast time.Duration
+		error   error
+	}{
+		"with unavailable twice":      {scenario: "status=503,retry-after=0s,times=2"},
+		"with rate limit honoured":    {scenario: "status=429,retry-after=1s,times=1", atLeast: time.Second},
+		"with unavailable throughout": {scenario: "status=503,retry-after=0s", error: errors.CodeUnavailable},
+		"with connection reset once":  {scenario: "reset,times=1"},
+		"with slow drip":              {scenario: "drip=1ms,drip-size=256"},
+		"with jittered latency":       {scenario: "latency=20ms,jitter=10ms"},
+		"with latency past deadline":  {scenario: "latency=2s", timeout: 50 * time.Millisecond, error: context.DeadlineExceeded},
+		"with truncated body":         {scenario: "truncate=10", error: io.ErrUnexpectedEOF},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			server := httptest.NewServer(fakeapi.New(fakeapi.Seed(1)))
+			t.Cleanup(server.Close)
+			url, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatal("Failed to parse url")
+			}
+			policy := DefaultRetryPolicy()
+			policy.BaseDelay = time.Millisecond
+			imdbClient := NewWithOptions(&ImdbClientOptions{ApiURL: url, Retry: policy, Transport: &fakeapi.FaultTransport{Scenario: testCase.scenario}})
+
+			ctx := t.Context()
+			if testCase.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, testCase.timeout)
+				defer cancel()
+			}
+			started := time.Now()
+			title, appErr := imdbClient.GetTitle(ctx, "tt2000001")
+			elapsed := time.Since(started)
+
+			if testCase.error == nil {
+				if appErr != nil || title == nil || title.ID != "tt2000001" {
+					t.Fatalf("TestRetryTransportFaults(%s) = got (%v, %v), want tt2000001.", testName, title, appErr)
+				}
+				if elapsed < testCase.atLeast {
+					t.Errorf("TestRetryTransportFaults(%s) = took %v, want at least %v.", testName, elapsed, testCase.atLeast)
+				}
+				return
+			}
+			if appErr == nil || !e.Is(appErr.ClientError, testCase.error) {
+				t.Errorf("TestRetryTransportFaults(%s) = got (%v), want (%v).", testName, appErr, testCase.error)
+			}
+		})
+	}
+}
+
+func TestMalformedJSONIsNotRetried(t *testing.T) {
+	fake := fakeapi.New(fakeapi.Seed(1))
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	url, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal("Failed to parse url")
+	}
+	fake.SetScenario(fakeapi.Scenario{Malformed: true, Times: 1})
+	imdbClient := NewWithOptions(&ImdbClientOptions{ApiURL: url, Retry: DefaultRetryPolicy()})
+
+	if _, appErr := imdbClient.GetTitle(t.Context(), "tt2000001"); appErr == nil || appErr.AppMessage != "error: JSON answer cannot be read" {
+		t.Errorf("TestMalformedJSONIsNotRetried = got (%v), want a JSON error.", appErr)
+	}
+	if title, appErr := imdbClient.GetTitle(t.Context(), "tt2000001"); appErr != nil || title.ID != "tt2000001" {
+		t.Errorf("TestMalformedJSONIsNotRetried = second call got (%v, %v), want tt2000001.", title, appErr)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	testCases := map[string]struct {
+		attempt    int
+		retryAfter time.Duration
+		expected   time.Duration
+	}{
+		"first retry":                 {attempt: 1, expected: 100 * time.Millisecond},
+		"second retry doubles":        {attempt: 2, expected: 200 * time.Millisecond},
+		"capped at max delay":         {attempt: 4, expected: 300 * time.Millisecond},
+		"retry after beats backoff":   {attempt: 1, retryAfter: 250 * time.Millisecond, expected: 250 * time.Millisecond},
+		"backoff beats short headers": {attempt: 2, retryAfter: time.Millisecond, expected: 200 * time.Millisecond},
+	}
+
+	for testName, testCase := range testCases {
+		if delay := policy.backoff(testCase.attempt, testCase.retryAfter); delay != testCase.expected {
+			t.Errorf("TestRetryPolicyBackoff(%s) = got (%v), want (%v).", testName, delay, testCase.expected)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		value    string
+		expected time.Duration
+	}{
+		"empty":       {value: "", expected: 0},
+		"seconds":     {value: "3", expected: 3 * time.Second},
+		"http date":   {value: "Wed, 01 Jan 2025 12:00:10 GMT", expected: 10 * time.Second},
+		"date passed": {value: "Wed, 01 Jan 2025 11:00:00 GMT", expected: 0},
+		"garbage":     {value: "soon", expected: 0},
+	}
+
+	for testName, testCase := range testCases {
+		if result := parseRetryAfter(testCase.value, now); result != testCase.expected {
+			t.Errorf("TestParseRetryAfter(%s) = got (%v), want (%v).", testName, result, testCase.expected)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"log"
+	"strings"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// Store is a local mirror the client reads titles, names, credits and
+// episodes from before asking the api, and saves what it fetches into.
+// Lookups return nil without an error for records the store does not have
+// or holds stale. Credits and Episodes only answer with listings saved
+// whole through SaveCredits and SaveEpisodes, an empty listing being a
+// non-nil empty slice, and Seasons with the seasons saved alongside.
+type Store interface {
+	Title(ctx context.Context, id string) (*models.ImdbapiTitle, error)
+	Name(ctx context.Context, id string) (*models.ImdbapiName, error)
+	Credits(ctx context.Context, titleID string) ([]*models.ImdbapiCredit, error)
+	Episodes(ctx context.Context, seriesID string) ([]*models.ImdbapiEpisode, error)
+	Seasons(ctx context.Context, seriesID string) ([]*models.ImdbapiSeason, error)
+	SaveTitle(ctx context.Context, title *models.ImdbapiTitle) error
+	SaveName(ctx context.Context, name *models.ImdbapiName) error
+	SaveCredit(ctx context.Context, titleID, nameID string, credit *models.ImdbapiCredit) error
+	SaveCredits(ctx context.Context, titleID string, credits []*models.ImdbapiCredit) error
+	SaveEpisodes(ctx context.Context, seriesID string, episodes []*models.ImdbapiEpisode) error
+	SaveSeasons(ctx context.Context, seriesID string, seasons []*models.ImdbapiSeason) error
+}
+
+type refreshKey struct{}
+
+// WithRefresh makes requests done with ctx skip the response cache and the
+// store, which are still updated with what comes back.
+func WithRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+func refreshing(ctx context.Context) bool {
+	refresh, _ := ctx.Value(refreshKey{}).(bool)
+	return refresh
+}
+
+// fromStore looks id up with lookup, a failing store being logged and
+// treated as a miss so the api still answers.
+func fromStore[T any](ctx context.Context, imdbClient *ImdbClient, id string, lookup func(Store, context.Context, string) (*T, error)) *T {
+	if imdbClient.options.Store == nil || refreshing(ctx) {
+		return nil
+	}
+	record, err := lookup(imdbClient.options.Store, ctx, id)
+	if err != nil {
+		log.Printf("ImdbClient store lookup of %s failed: %v\n", id, err)
+		return nil
+	}
+	if record != nil {
+		log.Println("ImdbClient store hit: " + id)
+	}
+	return record
+}
+
+// listFromStore is fromStore for listings, reporting whether the store had
+// one as an empty listing is an answer too.
+func listFromStore[T any](ctx context.Context, imdbClient *ImdbClient, id string, lookup func(Store, context.Context, string) ([]*T, error)) ([]*T, bool) {
+	if imdbClient.options.Store == nil || refreshing(ctx) {
+		return nil, false
+	}
+	records, err := lookup(imdbClient.options.Store, ctx, id)
+	if err != nil {
+		log.Printf("ImdbClient store lookup of %s failed: %v\n", id, err)
+		return nil, false
+	}
+	if records == nil {
+		return nil, false
+	}
+	log.Println("ImdbClient store hit: " + id)
+	return records, true
+}
+
+func (imdbClient *ImdbClient) saveToStore(what, id string, save func(Store) error) {
+	if imdbClient.options.Store == nil {
+		return
+	}
+	if err := save(imdbClient.options.Store); err != nil {
+		log.Printf("ImdbClient could not store %s %s: %v\n", what, id, err)
+	}
+}
+
+// pathID is the id in a titles/{id}/... or names/{id}/... path, already
+// checked by titlePath or namePath.
+func pathID(path string) string {
+	return strings.Split(path, "/")[1]
+}
+
+// stored yields a listing read from the store.
+func stored[T any](items []*T) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		for _, item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+// storingAll saves the items of seq together once all of them went
+// through, a listing stopped early or failing being left out of the store.
+func storingAll[T any](imdbClient *ImdbClient, seq iter.Seq2[*T, error], what, id string, save func(Store, []*T) error) iter.Seq2[*T, error] {
+	if imdbClient.options.Store == nil {
+		return seq
+	}
+	return func(yield func(*T, error) bool) {
+		var items []*T
+		for item, err := range seq {
+			if err != nil {
+				yield(item, err)
+				return
+			}
+			items = append(items, item)
+			if !yield(item, nil) {
+				return
+			}
+		}
+		imdbClient.saveToStore(what, id, func(store Store) error { return save(store, items) })
+	}
+}
+
+// storing saves every item of seq as it passes through.
+func storing[T any](imdbClient *ImdbClient, seq iter.Seq2[*T, error], what, id string, save func(Store, *T) error) iter.Seq2[*T, error] {
+	if imdbClient.options.Store == nil {
+		return seq
+	}
+	return func(yield func(*T, error) bool) {
+		for item, err := range seq {
+			if err == nil {
+				imdbClient.saveToStore(what, id, func(store Store) error { return save(store, item) })
+			}
+			if !yield(item, err) {
+				return
+			}
+		}
+	}
+}
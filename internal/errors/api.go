@@ -0,0 +1,141 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// Code is a google.rpc.Code as carried in rpcStatus bodies. It implements
+// error so it can be used directly as an errors.Is target.
+type Code int32
+
+const (
+	CodeOK Code = iota
+	CodeCanceled
+	CodeUnknown
+	CodeInvalidArgument
+	CodeDeadlineExceeded
+	CodeNotFound
+	CodeAlreadyExists
+	CodePermissionDenied
+	CodeResourceExhausted
+	CodeFailedPrecondition
+	CodeAborted
+	CodeOutOfRange
+	CodeUnimplemented
+	CodeInternal
+	CodeUnavailable
+	CodeDataLoss
+	CodeUnauthenticated
+)
+
+var codeNames = [...]string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED",
+	"NOT_FOUND", "ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION", "ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED",
+	"INTERNAL", "UNAVAILABLE", "DATA_LOSS", "UNAUTHENTICATED",
+}
+
+func (c Code) String() string {
+	if c >= 0 && int(c) < len(codeNames) {
+		return codeNames[c]
+	}
+	return fmt.Sprintf("CODE(%d)", int32(c))
+}
+
+func (c Code) Error() string {
+	return "rpc code " + c.String()
+}
+
+// Typed versions of the google.rpc error details the api is known to send.
+type ErrorInfo struct {
+	Reason   string            `json:"reason"`
+	Domain   string            `json:"domain"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+type RetryInfo struct {
+	RetryDelay string `json:"retryDelay"`
+}
+
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+type BadRequest struct {
+	FieldViolations []FieldViolation `json:"fieldViolations"`
+}
+
+// APIError is a non-2xx response whose body decoded as an rpcStatus. It wraps
+// the HTTPError for the raw status so existing checks keep matching.
+type APIError struct {
+	Code    Code
+	Message string
+	// Details holds *ErrorInfo, *RetryInfo or *BadRequest values, and the
+	// raw *models.ProtobufAny for any other detail type.
+	Details []any
+	Err     *HTTPError
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error: %s: %s (http status %d)", e.Code, e.Message, e.Err.Code)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+func (e *APIError) Is(target error) bool {
+	switch t := target.(type) {
+	case Code:
+		return e.Code == t
+	case *APIError:
+		return e.Code == t.Code && (t.Message == "" || e.Message == t.Message)
+	}
+	return false
+}
+
+// DecodeAPIError turns body into an APIError when it is an rpcStatus, ok is
+// false for anything else, such as plain text error pages.
+func DecodeAPIError(httpErr *HTTPError, body []byte) (apiErr *APIError, ok bool) {
+	var status models.RPCStatus
+	if err := json.Unmarshal(body, &status); err != nil || (status.Code == 0 && status.Message == "") {
+		return nil, false
+	}
+
+	apiErr = &APIError{
+		Code:    Code(status.Code),
+		Message: status.Message,
+		Err:     httpErr,
+	}
+	for _, detail := range status.Details {
+		if detail != nil {
+			apiErr.Details = append(apiErr.Details, decodeDetail(detail))
+		}
+	}
+	return apiErr, true
+}
+
+func decodeDetail(detail *models.ProtobufAny) any {
+	var typed any
+	switch detail.AtType[strings.LastIndex(detail.AtType, "/")+1:] {
+	case "google.rpc.ErrorInfo":
+		typed = &ErrorInfo{}
+	case "google.rpc.RetryInfo":
+		typed = &RetryInfo{}
+	case "google.rpc.BadRequest":
+		typed = &BadRequest{}
+	default:
+		return detail
+	}
+
+	data, err := json.Marshal(detail.ProtobufAny)
+	if err != nil || json.Unmarshal(data, typed) != nil {
+		return detail
+	}
+	return typed
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 )
 
 const (
@@ -13,8 +14,16 @@ const (
 	CONNECTIONREFUSEDERROR
 	HOSTNOTFOUNDERROR
 	EMPTYQUERYERROR
+	CANCELLEDERROR
+	USAGEERROR
+	NOTFOUNDERROR
+	OFFLINEERROR
 )
 
+// ErrOffline is what an offline client answers for anything the cache or
+// the local store does not hold, instead of opening a connection.
+var ErrOffline = errors.New("offline")
+
 type HTTPError struct {
 	Code    int
 	Message string
@@ -91,8 +100,31 @@ func NewIMDBClientApplicationError(appMessage string, clientError error) *IMDBCl
 	}
 }
 
+// RetryError collects the failure of every attempt made for one request,
+// oldest first.
+type RetryError struct {
+	Attempts []error
+}
+
+func (e *RetryError) Error() string {
+	messages := make([]string, 0, len(e.Attempts))
+	for i, err := range e.Attempts {
+		messages = append(messages, fmt.Sprintf("attempt %d: %v", i+1, err))
+	}
+	return fmt.Sprintf("giving up after %d attempts: [%s]", len(e.Attempts), strings.Join(messages, "; "))
+}
+
+func (e *RetryError) Unwrap() []error {
+	return e.Attempts
+}
+
 func RootCause(result error) {
 	for err := error(result); err != nil; err = errors.Unwrap(err) {
 		log.Printf("Unwrapped error: %v", err)
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, inner := range multi.Unwrap() {
+				RootCause(inner)
+			}
+		}
 	}
 }
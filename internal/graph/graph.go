@@ -0,0 +1,269 @@
+// Package graph finds how two people are connected through the titles they
+// are credited in.
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/foursixnine/imdblookup/internal/cache"
+)
+
+var (
+	ErrNoPath          = errors.New("no path found")
+	ErrBudgetExhausted = errors.New("request budget exhausted")
+)
+
+// DefaultMaxDepth is the six of six degrees.
+const DefaultMaxDepth = 6
+
+// Credit is an edge of the graph, a name credited in a title.
+type Credit struct {
+	NameID   string
+	Name     string
+	TitleID  string
+	Title    string
+	Category string
+}
+
+// Source looks up the edges of a node. Each call counts once against the
+// request budget, however many pages it takes.
+type Source interface {
+	Filmography(ctx context.Context, nameID string) ([]Credit, error)
+	Credits(ctx context.Context, titleID string) ([]Credit, error)
+}
+
+// CastCategories are the credit categories "cast" stands for, "crew" being
+// every other one.
+var CastCategories = []string{"actor", "actress", "self", "archive_footage"}
+
+type Options struct {
+	// MaxDepth is the most titles a path may go through, zero meaning
+	// DefaultMaxDepth.
+	MaxDepth int
+	// Categories limits the credits followed to these categories, with
+	// "cast" and "crew" as shorthands. Empty follows every credit.
+	Categories []string
+	// Budget caps the number of Source lookups, zero means no cap.
+	Budget int
+	// Cache, when set, keeps found paths for CacheTTL.
+	Cache    cache.Cache
+	CacheTTL time.Duration
+}
+
+func (opts Options) follows(category string) bool {
+	if len(opts.Categories) == 0 {
+		return true
+	}
+	cast := slices.Contains(CastCategories, category)
+	for _, c := range opts.Categories {
+		if c == category || (c == "cast" && cast) || (c == "crew" && !cast) {
+			return true
+		}
+	}
+	return false
+}
+
+func (opts Options) cacheKey(from, to string) string {
+	categories := slices.Clone(opts.Categories)
+	slices.Sort(categories)
+	return fmt.Sprintf("graph:path:%s:%s:%d:%s", from, to, opts.MaxDepth, strings.Join(categories, ","))
+}
+
+// Node is a name or a title on a path.
+type Node struct {
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
+}
+
+// Path alternates names and titles, starting and ending with a name, each
+// title crediting the names on both sides of it.
+type Path struct {
+	Nodes []Node `json:"nodes"`
+	// Lookups is the number of Source lookups the search took, zero when
+	// the path came from the cache.
+	Lookups int `json:"lookups"`
+}
+
+// Degrees is the number of titles on the path.
+func (path *Path) Degrees() int {
+	return len(path.Nodes) / 2
+}
+
+func (path *Path) String() string {
+	parts := make([]string, len(path.Nodes))
+	for i, node := range path.Nodes {
+		parts[i] = node.ID
+		if node.Label != "" {
+			parts[i] = fmt.Sprintf("%s (%s)", node.Label, node.ID)
+		}
+	}
+	return strings.Join(parts, " -> ")
+}
+
+// side is one half of the bidirectional search.
+type side struct {
+	parent   map[string]string
+	depth    map[string]int
+	frontier []string
+}
+
+func newSide(start string) *side {
+	return &side{parent: map[string]string{start: ""}, depth: map[string]int{start: 0}, frontier: []string{start}}
+}
+
+func (s *side) chain(node string) []string {
+	var nodes []string
+	for ; node != ""; node = s.parent[node] {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+type search struct {
+	source  Source
+	opts    Options
+	labels  map[string]string
+	lookups int
+}
+
+func (s *search) neighbours(ctx context.Context, node string) ([]string, error) {
+	if s.opts.Budget > 0 && s.lookups >= s.opts.Budget {
+		return nil, fmt.Errorf("%w after %d lookups", ErrBudgetExhausted, s.lookups)
+	}
+	s.lookups++
+
+	isName := strings.HasPrefix(node, "nm")
+	var credits []Credit
+	var err error
+	if isName {
+		credits, err = s.source.Filmography(ctx, node)
+	} else {
+		credits, err = s.source.Credits(ctx, node)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var next []string
+	for _, credit := range credits {
+		s.label(credit.NameID, credit.Name)
+		s.label(credit.TitleID, credit.Title)
+		if !s.opts.follows(credit.Category) {
+			continue
+		}
+		if isName {
+			next = append(next, credit.TitleID)
+		} else {
+			next = append(next, credit.NameID)
+		}
+	}
+	return next, nil
+}
+
+func (s *search) label(id, label string) {
+	if id != "" && label != "" && s.labels[id] == "" {
+		s.labels[id] = label
+	}
+}
+
+// Find runs a bidirectional breadth first search between the names from
+// and to, always growing the smaller frontier by one level. Paths found
+// before are answered from opts.Cache without any lookup.
+func Find(ctx context.Context, source Source, from, to string, opts Options) (*Path, error) {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DefaultMaxDepth
+	}
+
+	key := opts.cacheKey(from, to)
+	if opts.Cache != nil {
+		if data, ok := opts.Cache.Get(key); ok {
+			var path Path
+			if err := json.Unmarshal(data, &path); err == nil {
+				path.Lookups = 0
+				return &path, nil
+			}
+		}
+	}
+
+	path, err := find(ctx, source, from, to, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Cache != nil {
+		if data, err := json.Marshal(path); err == nil {
+			opts.Cache.Set(key, data, opts.CacheTTL)
+		}
+	}
+	return path, nil
+}
+
+func find(ctx context.Context, source Source, from, to string, opts Options) (*Path, error) {
+	s := &search{source: source, opts: opts, labels: map[string]string{}}
+	if from == to {
+		return &Path{Nodes: []Node{{ID: from}}}, nil
+	}
+
+	forward, backward := newSide(from), newSide(to)
+	// Every title adds two edges, one in and one out.
+	maxEdges := 2 * opts.MaxDepth
+	for edges := 0; edges < maxEdges; edges++ {
+		if len(forward.frontier) == 0 || len(backward.frontier) == 0 {
+			break
+		}
+		grow, other := forward, backward
+		if len(backward.frontier) < len(forward.frontier) {
+			grow, other = backward, forward
+		}
+
+		meet, best := "", -1
+		var frontier []string
+		for _, node := range grow.frontier {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			next, err := s.neighbours(ctx, node)
+			if err != nil {
+				return nil, err
+			}
+			for _, neighbour := range next {
+				if _, seen := grow.depth[neighbour]; seen {
+					continue
+				}
+				grow.parent[neighbour] = node
+				grow.depth[neighbour] = grow.depth[node] + 1
+				frontier = append(frontier, neighbour)
+				if otherDepth, ok := other.depth[neighbour]; ok {
+					if total := grow.depth[neighbour] + otherDepth; best < 0 || total < best {
+						meet, best = neighbour, total
+					}
+				}
+			}
+		}
+		grow.frontier = frontier
+
+		if meet != "" {
+			return s.path(forward, backward, meet), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w between %s and %s within %d titles", ErrNoPath, from, to, opts.MaxDepth)
+}
+
+func (s *search) path(forward, backward *side, meet string) *Path {
+	ids := forward.chain(meet)
+	slices.Reverse(ids)
+	ids = append(ids, backward.chain(backward.parent[meet])...)
+
+	path := &Path{Lookups: s.lookups}
+	for _, id := range ids {
+		path.Nodes = append(path.Nodes, Node{ID: id, Label: s.labels[id]})
+	}
+	return path
+}
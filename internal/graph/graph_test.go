@@ -0,0 +1,157 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/foursixnine/imdblookup/internal/cache"
+)
+
+// fakeSource is a small credits graph where nm1 and nm3 share a title as
+// director and actor, and are two titles apart through cast credits only.
+type fakeSource struct {
+	credits []Credit
+	lookups int
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{credits: []Credit{
+		{NameID: "nm1", Name: "One", TitleID: "tt1", Title: "First", Category: "actor"},
+		{NameID: "nm2", Name: "Two", TitleID: "tt1", Title: "First", Category: "actress"},
+		{NameID: "nm2", Name: "Two", TitleID: "tt3", Title: "Third", Category: "actress"},
+		{NameID: "nm3", Name: "Three", TitleID: "tt3", Title: "Third", Category: "actor"},
+		{NameID: "nm1", Name: "One", TitleID: "tt2", Title: "Second", Category: "director"},
+		{NameID: "nm3", Name: "Three", TitleID: "tt2", Title: "Second", Category: "actor"},
+		{NameID: "nm4", Name: "Four", TitleID: "tt4", Title: "Fourth", Category: "actor"},
+	}}
+}
+
+func (s *fakeSource) Filmography(ctx context.Context, nameID string) ([]Credit, error) {
+	s.lookups++
+	return slices.DeleteFunc(slices.Clone(s.credits), func(c Credit) bool { return c.NameID != nameID }), nil
+}
+
+func (s *fakeSource) Credits(ctx context.Context, titleID string) ([]Credit, error) {
+	s.lookups++
+	return slices.DeleteFunc(slices.Clone(s.credits), func(c Credit) bool { return c.TitleID != titleID }), nil
+}
+
+func nodeIDs(path *Path) []string {
+	var nodes []string
+	for _, node := range path.Nodes {
+		nodes = append(nodes, node.ID)
+	}
+	return nodes
+}
+
+func TestFind(t *testing.T) {
+	testCases := map[string]struct {
+		from, to string
+		opts     Options
+		expected []string
+		err      error
+	}{
+		"any credit": {
+			from:     "nm1",
+			to:       "nm3",
+			expected: []string{"nm1", "tt2", "nm3"},
+		},
+		"reversed": {
+			from:     "nm3",
+			to:       "nm1",
+			expected: []string{"nm3", "tt2", "nm1"},
+		},
+		"cast only": {
+			from:     "nm1",
+			to:       "nm3",
+			opts:     Options{Categories: []string{"cast"}},
+			expected: []string{"nm1", "tt1", "nm2", "tt3", "nm3"},
+		},
+		"listed categories": {
+			from:     "nm1",
+			to:       "nm3",
+			opts:     Options{Categories: []string{"director", "actor"}},
+			expected: []string{"nm1", "tt2", "nm3"},
+		},
+		"crew only": {
+			from: "nm1",
+			to:   "nm3",
+			opts: Options{Categories: []string{"crew"}},
+			err:  ErrNoPath,
+		},
+		"too deep": {
+			from: "nm1",
+			to:   "nm3",
+			opts: Options{Categories: []string{"cast"}, MaxDepth: 1},
+			err:  ErrNoPath,
+		},
+		"unconnected": {
+			from: "nm1",
+			to:   "nm4",
+			err:  ErrNoPath,
+		},
+		"over budget": {
+			from: "nm1",
+			to:   "nm3",
+			opts: Options{Categories: []string{"cast"}, Budget: 2},
+			err:  ErrBudgetExhausted,
+		},
+		"same name": {
+			from:     "nm1",
+			to:       "nm1",
+			expected: []string{"nm1"},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		path, err := Find(context.Background(), newFakeSource(), testCase.from, testCase.to, testCase.opts)
+		if !errors.Is(err, testCase.err) {
+			t.Errorf("TestFind(%s) = got error (%v), want (%v).", testName, err, testCase.err)
+			continue
+		}
+		if err == nil && !slices.Equal(nodeIDs(path), testCase.expected) {
+			t.Errorf("TestFind(%s) = got (%v), want (%v).", testName, nodeIDs(path), testCase.expected)
+		}
+	}
+}
+
+func TestFindLabels(t *testing.T) {
+	path, err := Find(context.Background(), newFakeSource(), "nm1", "nm3", Options{})
+	if err != nil {
+		t.Fatalf("TestFindLabels = unexpected error (%v)", err)
+	}
+	if got := path.String(); got != "One (nm1) -> Second (tt2) -> Three (nm3)" {
+		t.Errorf("TestFindLabels = got (%s), want (%s).", got, "One (nm1) -> Second (tt2) -> Three (nm3)")
+	}
+	if path.Degrees() != 1 {
+		t.Errorf("TestFindLabels(degrees) = got (%d), want (1).", path.Degrees())
+	}
+}
+
+func TestFindCached(t *testing.T) {
+	opts := Options{Categories: []string{"cast"}, Cache: cache.NewLRU(1 << 20), CacheTTL: time.Hour}
+
+	source := newFakeSource()
+	first, err := Find(context.Background(), source, "nm1", "nm3", opts)
+	if err != nil || first.Lookups == 0 {
+		t.Fatalf("TestFindCached(first) = got (%v, %v), want a path found with lookups.", first, err)
+	}
+
+	source = newFakeSource()
+	second, err := Find(context.Background(), source, "nm1", "nm3", opts)
+	if err != nil || source.lookups != 0 || second.Lookups != 0 {
+		t.Errorf("TestFindCached(second) = got (%d lookups, %v), want a cached path.", source.lookups, err)
+	}
+	if !slices.Equal(nodeIDs(first), nodeIDs(second)) || second.Nodes[1].Label != "First" {
+		t.Errorf("TestFindCached = got (%v), want (%v).", second.Nodes, first.Nodes)
+	}
+
+	// A different filter is a different question.
+	opts.Categories = nil
+	if third, err := Find(context.Background(), source, "nm1", "nm3", opts); err != nil || third.Degrees() != 1 {
+		t.Errorf("TestFindCached(any credit) = got (%v, %v), want 1 degree.", third, err)
+	}
+}
@@ -0,0 +1,51 @@
+package graph
+
+import (
+	"context"
+	"iter"
+
+	"github.com/foursixnine/imdblookup/internal/client"
+	"github.com/foursixnine/imdblookup/internal/ids"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+type clientSource struct {
+	client *client.ImdbClient
+}
+
+// NewClientSource walks the graph through the filmography and credits
+// endpoints of the api.
+func NewClientSource(imdbClient *client.ImdbClient) Source {
+	return &clientSource{client: imdbClient}
+}
+
+func (s *clientSource) Filmography(ctx context.Context, nameID string) ([]Credit, error) {
+	return collect(s.client.NameFilmography(ctx, ids.NameID(nameID)), nameID, "")
+}
+
+func (s *clientSource) Credits(ctx context.Context, titleID string) ([]Credit, error) {
+	return collect(s.client.TitleCredits(ctx, ids.TitleID(titleID)), "", titleID)
+}
+
+// collect turns api credits into edges, filling in the side of the edge the
+// endpoint leaves out.
+func collect(seq iter.Seq2[*models.ImdbapiCredit, error], nameID, titleID string) ([]Credit, error) {
+	var credits []Credit
+	for credit, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		edge := Credit{NameID: nameID, TitleID: titleID, Category: credit.Category}
+		if credit.Name != nil {
+			edge.NameID, edge.Name = credit.Name.ID, credit.Name.DisplayName
+		}
+		if credit.Title != nil {
+			edge.TitleID, edge.Title = credit.Title.ID, credit.Title.PrimaryTitle
+		}
+		if edge.NameID == "" || edge.TitleID == "" {
+			continue
+		}
+		credits = append(credits, edge)
+	}
+	return credits, nil
+}
@@ -0,0 +1,239 @@
+// Package heatmap draws the episode ratings of a series as a season by
+// episode grid for the terminal.
+package heatmap
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/foursixnine/imdblookup/internal/client"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+type Options struct {
+	// Color draws cells with ANSI 256-colour backgrounds, otherwise the map
+	// is plain ASCII.
+	Color bool
+}
+
+// Rated is an episode carrying a rating, with the season it belongs to.
+type Rated struct {
+	Season  int
+	Episode *models.ImdbapiEpisode
+	Rating  float64
+}
+
+// SeasonAverage is the mean rating of the rated episodes of a season.
+type SeasonAverage struct {
+	Season  int
+	Average float64
+	Rated   int
+}
+
+// Summary holds the figures printed below the grid.
+type Summary struct {
+	Seasons []SeasonAverage
+	Best    *Rated
+	Worst   *Rated
+	// Slope is the least squares change in rating from one episode to the
+	// next, taking the seasons in order and each by episode number.
+	Slope float64
+	// Ratings holds every rated episode, season by season in episode number
+	// order.
+	Ratings []Rated
+}
+
+// Summarize computes the averages, extremes and trend of guide. It looks at
+// the regular seasons only, specials and unplaced episodes have no position
+// in the grid.
+func Summarize(guide *client.EpisodeGuide) Summary {
+	var summary Summary
+	for _, season := range guide.Seasons {
+		if season.Kind != client.SeasonRegular {
+			continue
+		}
+
+		average := SeasonAverage{Season: season.Number}
+		total := 0.0
+		for _, episode := range season.Episodes {
+			rating, ok := ratingOf(episode)
+			if !ok {
+				continue
+			}
+			rated := Rated{Season: season.Number, Episode: episode, Rating: rating}
+			summary.Ratings = append(summary.Ratings, rated)
+			if summary.Best == nil || rating > summary.Best.Rating {
+				summary.Best = &rated
+			}
+			if summary.Worst == nil || rating < summary.Worst.Rating {
+				summary.Worst = &rated
+			}
+			total += rating
+			average.Rated++
+		}
+		if average.Rated > 0 {
+			average.Average = total / float64(average.Rated)
+		}
+		summary.Seasons = append(summary.Seasons, average)
+	}
+
+	summary.Slope = slope(summary.Ratings)
+	return summary
+}
+
+func ratingOf(episode *models.ImdbapiEpisode) (float64, bool) {
+	if episode == nil || episode.Rating == nil || episode.Rating.AggregateRating <= 0 {
+		return 0, false
+	}
+	// Ratings come as float32, round to a tenth so 8.4 does not print as
+	// 8.399999.
+	return math.Round(float64(episode.Rating.AggregateRating)*10) / 10, true
+}
+
+func slope(ratings []Rated) float64 {
+	n := float64(len(ratings))
+	if n < 2 {
+		return 0
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for i, rated := range ratings {
+		x := float64(i)
+		sumX += x
+		sumY += rated.Rating
+		sumXY += x * rated.Rating
+		sumXX += x * x
+	}
+	return (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+}
+
+// Render writes the grid of guide followed by the season averages, the best
+// and worst episodes and the trend of the series.
+func Render(w io.Writer, guide *client.EpisodeGuide, opts Options) error {
+	summary := Summarize(guide)
+	if len(summary.Seasons) == 0 {
+		_, err := fmt.Fprintln(w, "No numbered seasons to draw.")
+		return err
+	}
+
+	width := 0
+	for _, season := range guide.Seasons {
+		if season.Kind == client.SeasonRegular {
+			for _, episode := range season.Episodes {
+				width = max(width, int(episode.EpisodeNumber))
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("     ")
+	for episode := 1; episode <= width; episode++ {
+		fmt.Fprintf(&b, " E%-3d", episode)
+	}
+	b.WriteString("   avg\n")
+
+	averages := map[int]SeasonAverage{}
+	for _, average := range summary.Seasons {
+		averages[average.Season] = average
+	}
+	for _, season := range guide.Seasons {
+		if season.Kind != client.SeasonRegular {
+			continue
+		}
+		cells := make([]string, width)
+		for i := range cells {
+			cells[i] = "   - "
+		}
+		for _, episode := range season.Episodes {
+			if rating, ok := ratingOf(episode); ok && episode.EpisodeNumber > 0 {
+				cells[episode.EpisodeNumber-1] = cell(rating, opts)
+			}
+		}
+		fmt.Fprintf(&b, "S%-3d %s", season.Number, strings.Join(cells, ""))
+		if average := averages[season.Number]; average.Rated > 0 {
+			fmt.Fprintf(&b, "  %4.1f", average.Average)
+		} else {
+			b.WriteString("     -")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	if summary.Best != nil {
+		fmt.Fprintf(&b, "Best:  %s  %.1f  %s\n", label(summary.Best), summary.Best.Rating, summary.Best.Episode.Title)
+		fmt.Fprintf(&b, "Worst: %s  %.1f  %s\n", label(summary.Worst), summary.Worst.Rating, summary.Worst.Episode.Title)
+	}
+	fmt.Fprintf(&b, "Trend: %s %+.3f per episode (%s)\n", sparkline(summary.Ratings, opts), summary.Slope, direction(summary.Slope))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func label(rated *Rated) string {
+	return fmt.Sprintf("S%02dE%02d", rated.Season, rated.Episode.EpisodeNumber)
+}
+
+func direction(slope float64) string {
+	switch {
+	case slope > 0.01:
+		return "rising"
+	case slope < -0.01:
+		return "falling"
+	}
+	return "flat"
+}
+
+// palette maps ratings to xterm-256 background colours, from red for
+// anything below 5 through to deep green for 9 and above.
+var palette = []struct {
+	below float64
+	color int
+}{
+	{5, 124}, {6, 160}, {7, 208}, {7.5, 220}, {8, 226}, {8.5, 148}, {9, 34}, {math.Inf(1), 28},
+}
+
+// shades is the ASCII stand-in for the palette.
+const shades = " .:-=+*#"
+
+func bucket(rating float64) int {
+	for i, p := range palette {
+		if rating < p.below {
+			return i
+		}
+	}
+	return len(palette) - 1
+}
+
+// cell draws rating five characters wide like the header and the empty
+// cells, a 10.0 taking the place of the leading space.
+func cell(rating float64, opts Options) string {
+	if opts.Color {
+		return fmt.Sprintf("\x1b[38;5;16;48;5;%dm%4.1f\x1b[0m ", palette[bucket(rating)].color, rating)
+	}
+	return fmt.Sprintf("%4.1f%c", rating, shades[bucket(rating)])
+}
+
+func sparkline(ratings []Rated, opts Options) string {
+	if len(ratings) == 0 {
+		return ""
+	}
+	low, high := ratings[0].Rating, ratings[0].Rating
+	for _, rated := range ratings {
+		low, high = math.Min(low, rated.Rating), math.Max(high, rated.Rating)
+	}
+
+	levels := []rune(shades[1:])
+	if opts.Color {
+		levels = []rune("▁▂▃▄▅▆▇█")
+	}
+	var b strings.Builder
+	for _, rated := range ratings {
+		level := len(levels) - 1
+		if high > low {
+			level = int((rated.Rating - low) / (high - low) * float64(len(levels)-1))
+		}
+		b.WriteRune(levels[level])
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package heatmap
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/foursixnine/imdblookup/internal/client"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+func episode(number int32, rating float32) *models.ImdbapiEpisode {
+	e := &models.ImdbapiEpisode{EpisodeNumber: number, Title: "Episode"}
+	if rating > 0 {
+		e.Rating = &models.ImdbapiRating{AggregateRating: rating}
+	}
+	return e
+}
+
+var guide = &client.EpisodeGuide{
+	SeriesID: "tt0000002",
+	Seasons: []*client.SeasonGuide{
+		{Season: "1", Number: 1, Kind: client.SeasonRegular, Episodes: []*models.ImdbapiEpisode{episode(1, 7.0), episode(2, 8.0), episode(3, 9.0)}},
+		{Season: "2", Number: 2, Kind: client.SeasonRegular, Episodes: []*models.ImdbapiEpisode{episode(1, 9.5), episode(2, 0)}},
+		{Season: "Specials", Kind: client.SeasonSpecials, Episodes: []*models.ImdbapiEpisode{episode(0, 10)}},
+	},
+}
+
+func TestSummarize(t *testing.T) {
+	summary := Summarize(guide)
+
+	averages := []SeasonAverage{{Season: 1, Average: 8, Rated: 3}, {Season: 2, Average: 9.5, Rated: 1}}
+	if len(summary.Seasons) != len(averages) {
+		t.Fatalf("TestSummarize = got (%v), want (%v).", summary.Seasons, averages)
+	}
+	for i, average := range averages {
+		if summary.Seasons[i] != average {
+			t.Errorf("TestSummarize(season %d) = got (%v), want (%v).", i+1, summary.Seasons[i], average)
+		}
+	}
+	if summary.Best.Season != 2 || summary.Best.Rating != 9.5 {
+		t.Errorf("TestSummarize(best) = got (%v), want (S2 9.5).", summary.Best)
+	}
+	if summary.Worst.Season != 1 || summary.Worst.Rating != 7 {
+		t.Errorf("TestSummarize(worst) = got (%v), want (S1 7.0).", summary.Worst)
+	}
+	if math.Abs(summary.Slope-0.85) > 1e-9 {
+		t.Errorf("TestSummarize(slope) = got (%v), want (%v).", summary.Slope, 0.85)
+	}
+}
+
+func TestRender(t *testing.T) {
+	testCases := map[string]struct {
+		opts     Options
+		expected []string
+		absent   []string
+	}{
+		"plain": {
+			opts:     Options{},
+			expected: []string{"      E1   E2   E3     avg\n", "S1    7.0- 8.0+ 9.0#   8.0\n", "S2    9.5#   -    -    9.5\n", "Best:  S02E01  9.5", "Worst: S01E01  7.0", "Trend: .-+# +0.850 per episode (rising)"},
+			absent:   []string{"\x1b["},
+		},
+		"color": {
+			opts:     Options{Color: true},
+			expected: []string{"\x1b[38;5;16;48;5;220m 7.0\x1b[0m ", "\x1b[38;5;16;48;5;28m 9.5\x1b[0m ", "Trend: ▁▃▆█"},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		var out bytes.Buffer
+		if err := Render(&out, guide, testCase.opts); err != nil {
+			t.Fatalf("TestRender(%s) = unexpected error (%v)", testName, err)
+		}
+		for _, expected := range testCase.expected {
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf("TestRender(%s) = got (%q), want it to contain (%q).", testName, out.String(), expected)
+			}
+		}
+		for _, absent := range testCase.absent {
+			if strings.Contains(out.String(), absent) {
+				t.Errorf("TestRender(%s) = got (%q), want no (%q).", testName, out.String(), absent)
+			}
+		}
+	}
+}
+
+func TestRenderPerfectRating(t *testing.T) {
+	perfect := &client.EpisodeGuide{Seasons: []*client.SeasonGuide{
+		{Season: "1", Number: 1, Kind: client.SeasonRegular, Episodes: []*models.ImdbapiEpisode{episode(1, 10), episode(2, 9.0)}},
+		{Season: "2", Number: 2, Kind: client.SeasonRegular, Episodes: []*models.ImdbapiEpisode{episode(1, 8.0)}},
+	}}
+	var out bytes.Buffer
+	if err := Render(&out, perfect, Options{}); err != nil {
+		t.Fatalf("TestRenderPerfectRating = unexpected error (%v)", err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if len(lines[1]) != len(lines[0]) || len(lines[2]) != len(lines[0]) || !strings.HasPrefix(lines[1], "S1   10.0# 9.0#") {
+		t.Errorf("TestRenderPerfectRating = got (%q), want rows as wide as the header.", lines[:3])
+	}
+}
+
+func TestRenderWithoutSeasons(t *testing.T) {
+	var out bytes.Buffer
+	if err := Render(&out, &client.EpisodeGuide{}, Options{}); err != nil || out.String() != "No numbered seasons to draw.\n" {
+		t.Errorf("TestRenderWithoutSeasons = got (%q, %v), want (%q, nil).", out.String(), err, "No numbered seasons to draw.\n")
+	}
+}
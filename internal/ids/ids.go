@@ -0,0 +1,148 @@
+package ids
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// IMDb identifiers are a two letter prefix followed by seven or eight digits.
+const (
+	minDigits = 7
+	maxDigits = 8
+)
+
+type TitleID string
+type NameID string
+type InterestID string
+type EventID string
+type CompanyID string
+
+// InvalidIDError is returned for a value that is neither a well formed ID of
+// the expected kind nor an imdb.com URL carrying one.
+type InvalidIDError struct {
+	Value  string
+	Prefix string
+}
+
+func (e *InvalidIDError) Error() string {
+	return fmt.Sprintf("invalid imdb id %q: expected %s followed by %d to %d digits, or an imdb.com url", e.Value, e.Prefix, minDigits, maxDigits)
+}
+
+var patterns = map[string]*regexp.Regexp{}
+
+func init() {
+	for _, prefix := range []string{"tt", "nm", "in", "ev", "co"} {
+		patterns[prefix] = regexp.MustCompile(fmt.Sprintf(`(?:^|[^a-z0-9])(%s[0-9]{%d,%d})(?:$|[^0-9])`, prefix, minDigits, maxDigits))
+	}
+}
+
+// parse accepts either a bare ID or an imdb.com URL mentioning one, such as
+// https://www.imdb.com/title/tt0111161/?ref_=nv_sr_srsg_0.
+func parse(prefix, value string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	if isBare(prefix, trimmed) {
+		return trimmed, nil
+	}
+
+	if !strings.Contains(trimmed, "://") {
+		trimmed = "https://" + trimmed
+	}
+	u, err := url.Parse(trimmed)
+	if err != nil || !isIMDbHost(u.Hostname()) {
+		return "", &InvalidIDError{Value: value, Prefix: prefix}
+	}
+
+	if match := patterns[prefix].FindStringSubmatch(u.EscapedPath() + "?" + u.RawQuery); match != nil {
+		return match[1], nil
+	}
+	return "", &InvalidIDError{Value: value, Prefix: prefix}
+}
+
+func isBare(prefix, value string) bool {
+	digits, ok := strings.CutPrefix(value, prefix)
+	if !ok || len(digits) < minDigits || len(digits) > maxDigits {
+		return false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isIMDbHost(host string) bool {
+	host = strings.ToLower(host)
+	return host == "imdb.com" || strings.HasSuffix(host, ".imdb.com")
+}
+
+func ParseTitleID(value string) (TitleID, error) {
+	id, err := parse("tt", value)
+	return TitleID(id), err
+}
+
+func ParseNameID(value string) (NameID, error) {
+	id, err := parse("nm", value)
+	return NameID(id), err
+}
+
+func ParseInterestID(value string) (InterestID, error) {
+	id, err := parse("in", value)
+	return InterestID(id), err
+}
+
+func ParseEventID(value string) (EventID, error) {
+	id, err := parse("ev", value)
+	return EventID(id), err
+}
+
+func ParseCompanyID(value string) (CompanyID, error) {
+	id, err := parse("co", value)
+	return CompanyID(id), err
+}
+
+func (id TitleID) String() string    { return string(id) }
+func (id NameID) String() string     { return string(id) }
+func (id InterestID) String() string { return string(id) }
+func (id EventID) String() string    { return string(id) }
+func (id CompanyID) String() string  { return string(id) }
+
+// IDs are read and written as text so that files holding them, such as the
+// watchlist, are checked when loaded and can only hold canonical IDs.
+func (id TitleID) MarshalText() ([]byte, error)    { return marshal("tt", string(id)) }
+func (id NameID) MarshalText() ([]byte, error)     { return marshal("nm", string(id)) }
+func (id InterestID) MarshalText() ([]byte, error) { return marshal("in", string(id)) }
+func (id EventID) MarshalText() ([]byte, error)    { return marshal("ev", string(id)) }
+func (id CompanyID) MarshalText() ([]byte, error)  { return marshal("co", string(id)) }
+
+func (id *TitleID) UnmarshalText(text []byte) error    { return unmarshal(id, text, ParseTitleID) }
+func (id *NameID) UnmarshalText(text []byte) error     { return unmarshal(id, text, ParseNameID) }
+func (id *InterestID) UnmarshalText(text []byte) error { return unmarshal(id, text, ParseInterestID) }
+func (id *EventID) UnmarshalText(text []byte) error    { return unmarshal(id, text, ParseEventID) }
+func (id *CompanyID) UnmarshalText(text []byte) error  { return unmarshal(id, text, ParseCompanyID) }
+
+// marshal refuses to write out an ID that could not be parsed back, the
+// zero value is written as an empty string.
+func marshal(prefix, id string) ([]byte, error) {
+	if id != "" && !isBare(prefix, id) {
+		return nil, &InvalidIDError{Value: id, Prefix: prefix}
+	}
+	return []byte(id), nil
+}
+
+// unmarshal parses text into id, an empty text being the zero value so
+// that what marshal writes always reads back.
+func unmarshal[ID ~string](id *ID, text []byte, parse func(string) (ID, error)) error {
+	if len(text) == 0 {
+		*id = ""
+		return nil
+	}
+	parsed, err := parse(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
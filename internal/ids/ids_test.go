@@ -0,0 +1,101 @@
+package ids
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseTitleID(t *testing.T) {
+	testCases := map[string]struct {
+		value    string
+		expected TitleID
+		error    bool
+	}{
+		"bare id":              {value: "tt0111161", expected: "tt0111161"},
+		"eight digits":         {value: "tt10872600", expected: "tt10872600"},
+		"surrounding spaces":   {value: "  tt0111161\n", expected: "tt0111161"},
+		"title url":            {value: "https://www.imdb.com/title/tt0111161/", expected: "tt0111161"},
+		"url with ref":         {value: "https://www.imdb.com/title/tt0111161/?ref_=nv_sr_srsg_0", expected: "tt0111161"},
+		"mobile url":           {value: "https://m.imdb.com/title/tt0111161/reviews", expected: "tt0111161"},
+		"url without scheme":   {value: "imdb.com/title/tt0111161", expected: "tt0111161"},
+		"six digits":           {value: "tt011116", error: true},
+		"nine digits":          {value: "tt011116100", error: true},
+		"wrong prefix":         {value: "nm0000102", error: true},
+		"empty":                {value: "", error: true},
+		"other host":           {value: "https://example.com/title/tt0111161/", error: true},
+		"url with a name id":   {value: "https://www.imdb.com/name/nm0000102/", error: true},
+		"digits glued to text": {value: "https://www.imdb.com/title/xtt0111161/", error: true},
+	}
+
+	for testName, testCase := range testCases {
+		id, err := ParseTitleID(testCase.value)
+		if testCase.error {
+			var invalid *InvalidIDError
+			if !errors.As(err, &invalid) {
+				t.Errorf("ParseTitleID(%s) = got (%v, %v), want an InvalidIDError", testName, id, err)
+			}
+			continue
+		}
+		if err != nil || id != testCase.expected {
+			t.Errorf("ParseTitleID(%s) = got (%v, %v), want (%v).", testName, id, err, testCase.expected)
+		}
+	}
+}
+
+func TestParseOtherIDs(t *testing.T) {
+	if id, err := ParseNameID("https://www.imdb.com/name/nm0000102/?ref_=fn_al_nm_1"); err != nil || id != "nm0000102" {
+		t.Errorf("ParseNameID = got (%v, %v), want nm0000102", id, err)
+	}
+	if id, err := ParseInterestID("in0000001"); err != nil || id != "in0000001" {
+		t.Errorf("ParseInterestID = got (%v, %v), want in0000001", id, err)
+	}
+	if _, err := ParseInterestID("tt0111161"); err == nil {
+		t.Error("ParseInterestID(tt0111161) = expected an error")
+	}
+	if id, err := ParseEventID("https://www.imdb.com/event/ev0000003/2024/1/"); err != nil || id != "ev0000003" {
+		t.Errorf("ParseEventID = got (%v, %v), want ev0000003", id, err)
+	}
+	if id, err := ParseCompanyID("https://www.imdb.com/search/title/?companies=co0000001"); err != nil || id != "co0000001" {
+		t.Errorf("ParseCompanyID = got (%v, %v), want co0000001", id, err)
+	}
+	if _, err := ParseCompanyID("tt0111161"); err == nil {
+		t.Error("ParseCompanyID(tt0111161) = expected an error")
+	}
+}
+
+func TestTextMarshaling(t *testing.T) {
+	type entry struct {
+		Title    TitleID    `json:"title"`
+		Name     NameID     `json:"name"`
+		Interest InterestID `json:"interest"`
+		Event    EventID    `json:"event"`
+		Company  CompanyID  `json:"company"`
+	}
+
+	var decoded entry
+	if err := json.Unmarshal([]byte(`{"title":"https://www.imdb.com/title/tt0111161/","name":"nm0000102","interest":"in0000001","event":"ev0000003","company":"co0000001"}`), &decoded); err != nil {
+		t.Fatalf("TestTextMarshaling = unexpected error (%v)", err)
+	}
+	encoded, err := json.Marshal(decoded)
+	if err != nil || string(encoded) != `{"title":"tt0111161","name":"nm0000102","interest":"in0000001","event":"ev0000003","company":"co0000001"}` {
+		t.Errorf("TestTextMarshaling = got (%s, %v)", encoded, err)
+	}
+
+	var zero entry
+	encoded, err = json.Marshal(zero)
+	if err != nil || json.Unmarshal(encoded, &decoded) != nil || decoded != zero {
+		t.Errorf("TestTextMarshaling = got (%+v, %v) round-tripping the zero value (%s)", decoded, err, encoded)
+	}
+
+	for _, malformed := range []string{`{"title":"tt12"}`, `{"name":"tt0111161"}`, `{"event":"ev1"}`, `{"company":"co"}`} {
+		if err := json.Unmarshal([]byte(malformed), &decoded); err == nil {
+			t.Errorf("TestTextMarshaling(%s) = expected an error unmarshaling a malformed id", malformed)
+		}
+	}
+	for _, malformed := range []entry{{Title: "bogus"}, {Interest: "in1"}, {Company: "ev0000003"}} {
+		if _, err := json.Marshal(malformed); err == nil {
+			t.Errorf("TestTextMarshaling(%+v) = expected an error marshaling a malformed id", malformed)
+		}
+	}
+}
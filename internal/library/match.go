@@ -0,0 +1,233 @@
+package library
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// Match statuses. A file is Matched when its best candidate is both
+// confident and clearly ahead of the next one, Ambiguous when it needs a
+// human to pick, and Unmatched when nothing came close.
+const (
+	Matched   = "matched"
+	Ambiguous = "ambiguous"
+	Unmatched = "unmatched"
+)
+
+// Source looks up candidate titles for a release.
+type Source interface {
+	// Search finds titles by name.
+	Search(ctx context.Context, query string) ([]*models.ImdbapiTitle, error)
+	// List finds titles of one of types that started in year, it is only
+	// asked when Search came back empty.
+	List(ctx context.Context, year int, types []models.ImdbapiTitleType) ([]*models.ImdbapiTitle, error)
+}
+
+type Options struct {
+	// MinConfidence is the lowest score a match is accepted at,
+	// DefaultMinConfidence when 0.
+	MinConfidence float64
+	// Margin is how far ahead of the runner up the best candidate has to
+	// be not to be flagged ambiguous, DefaultMargin when 0.
+	Margin float64
+	// Candidates caps how many scored candidates a Match keeps, all of
+	// them when 0.
+	Candidates int
+}
+
+const (
+	DefaultMinConfidence = 0.6
+	DefaultMargin        = 0.1
+)
+
+var (
+	seriesTypes = []models.ImdbapiTitleType{models.ImdbapiTitleTypeTVSERIES, models.ImdbapiTitleTypeTVMINISERIES}
+	movieTypes  = []models.ImdbapiTitleType{models.ImdbapiTitleTypeMOVIE, models.ImdbapiTitleTypeTVMOVIE, models.ImdbapiTitleTypeVIDEO, models.ImdbapiTitleTypeSHORT, models.ImdbapiTitleTypeTVSPECIAL}
+)
+
+// Candidate is a title scored against a release, Confidence is between 0
+// and 1.
+type Candidate struct {
+	Title      *models.ImdbapiTitle `json:"title"`
+	Confidence float64              `json:"confidence"`
+}
+
+// Match is the outcome for one file. Title is the best candidate, set
+// for ambiguous files too so they can be reviewed.
+type Match struct {
+	Release    Release              `json:"release"`
+	Status     string               `json:"status"`
+	Title      *models.ImdbapiTitle `json:"title,omitempty"`
+	Confidence float64              `json:"confidence"`
+	Candidates []Candidate          `json:"candidates,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// Matcher resolves releases through a Source, asking it once for all the
+// files sharing a title, year and kind.
+type Matcher struct {
+	source Source
+	opts   Options
+	seen   map[lookupKey][]*models.ImdbapiTitle
+}
+
+type lookupKey struct {
+	title   string
+	year    int
+	episode bool
+}
+
+func NewMatcher(source Source, opts Options) *Matcher {
+	opts.MinConfidence = cmp.Or(opts.MinConfidence, DefaultMinConfidence)
+	opts.Margin = cmp.Or(opts.Margin, DefaultMargin)
+	return &Matcher{source: source, opts: opts, seen: map[lookupKey][]*models.ImdbapiTitle{}}
+}
+
+// Match scores the candidates of release. A failed lookup is reported in
+// the Match rather than returned, so one bad file does not stop a scan.
+func (m *Matcher) Match(ctx context.Context, release Release) Match {
+	match := Match{Release: release, Status: Unmatched}
+	if release.Title == "" {
+		match.Error = "no title in file name"
+		return match
+	}
+
+	titles, err := m.lookup(ctx, release)
+	if err != nil {
+		match.Error = err.Error()
+		return match
+	}
+
+	for _, title := range titles {
+		if title != nil {
+			match.Candidates = append(match.Candidates, Candidate{Title: title, Confidence: Score(release, title)})
+		}
+	}
+	slices.SortStableFunc(match.Candidates, func(a, b Candidate) int { return cmp.Compare(b.Confidence, a.Confidence) })
+	if m.opts.Candidates > 0 && len(match.Candidates) > m.opts.Candidates {
+		match.Candidates = match.Candidates[:m.opts.Candidates]
+	}
+	if len(match.Candidates) == 0 {
+		return match
+	}
+
+	best := match.Candidates[0]
+	match.Title, match.Confidence = best.Title, best.Confidence
+	switch {
+	case best.Confidence < m.opts.MinConfidence:
+		match.Title = nil
+	case len(match.Candidates) > 1 && best.Confidence-match.Candidates[1].Confidence < m.opts.Margin:
+		match.Status = Ambiguous
+	default:
+		match.Status = Matched
+	}
+	return match
+}
+
+func (m *Matcher) lookup(ctx context.Context, release Release) ([]*models.ImdbapiTitle, error) {
+	key := lookupKey{title: normalize(release.Title), year: release.Year, episode: release.IsEpisode()}
+	if titles, ok := m.seen[key]; ok {
+		return titles, nil
+	}
+
+	titles, err := m.source.Search(ctx, release.Title)
+	if err == nil && len(titles) == 0 && release.Year != 0 {
+		types := movieTypes
+		if release.IsEpisode() {
+			types = seriesTypes
+		}
+		titles, err = m.source.List(ctx, release.Year, types)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.seen[key] = titles
+	return titles, nil
+}
+
+// Score rates how well title fits release: mostly on the name, then on
+// the year and on whether the kind of title suits a movie or an episode
+// file. Unknown years and types count half.
+func Score(release Release, title *models.ImdbapiTitle) float64 {
+	name := similarity(release.Title, title.PrimaryTitle)
+	if title.OriginalTitle != "" {
+		name = max(name, similarity(release.Title, title.OriginalTitle))
+	}
+
+	year := 0.5
+	if release.Year != 0 && title.StartYear != 0 {
+		switch diff := abs(release.Year - int(title.StartYear)); {
+		case diff == 0:
+			year = 1
+		case diff == 1:
+			year = 0.7
+		case release.IsEpisode() && title.EndYear != 0 && release.Year > int(title.StartYear) && release.Year <= int(title.EndYear):
+			// An episode can air any year the series runs.
+			year = 0.9
+		default:
+			year = 0
+		}
+	}
+
+	kind := 0.5
+	if title.Type != "" {
+		wanted := movieTypes
+		if release.IsEpisode() {
+			wanted = seriesTypes
+		}
+		kind = 0
+		if slices.Contains(wanted, models.ImdbapiTitleType(title.Type)) {
+			kind = 1
+		}
+	}
+
+	return round(0.6*name + 0.25*year + 0.15*kind)
+}
+
+// similarity is the share of words the two names have in common, 1 when
+// they are the same once case and punctuation are ignored.
+func similarity(a, b string) float64 {
+	a, b = normalize(a), normalize(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	words := strings.Fields(a)
+	other := strings.Fields(b)
+	common := 0
+	for _, word := range words {
+		if i := slices.Index(other, word); i >= 0 {
+			common++
+			other = slices.Delete(other, i, i+1)
+		}
+	}
+	return float64(common) / float64(max(len(words), len(strings.Fields(b))))
+}
+
+// normalize lowers s and keeps letters and digits only, words separated by
+// single spaces. "&" reads as "and".
+func normalize(s string) string {
+	s = strings.ReplaceAll(strings.ToLower(s), "&", " and ")
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}), " ")
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func round(f float64) float64 {
+	return float64(int(f*1000+0.5)) / 1000
+}
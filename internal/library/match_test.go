@@ -0,0 +1,117 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+type fakeSource struct {
+	search   map[string][]*models.ImdbapiTitle
+	byYear   map[int][]*models.ImdbapiTitle
+	searches int
+}
+
+func (s *fakeSource) Search(ctx context.Context, query string) ([]*models.ImdbapiTitle, error) {
+	s.searches++
+	if query == "Broken" {
+		return nil, errors.New("search failed")
+	}
+	return s.search[query], nil
+}
+
+func (s *fakeSource) List(ctx context.Context, year int, types []models.ImdbapiTitleType) ([]*models.ImdbapiTitle, error) {
+	return s.byYear[year], nil
+}
+
+var (
+	heat       = &models.ImdbapiTitle{ID: "tt0113277", PrimaryTitle: "Heat", Type: "MOVIE", StartYear: 1995}
+	heat1986   = &models.ImdbapiTitle{ID: "tt0093164", PrimaryTitle: "Heat", Type: "MOVIE", StartYear: 1986}
+	stranger   = &models.ImdbapiTitle{ID: "tt4574334", PrimaryTitle: "Stranger Things", Type: "TV_SERIES", StartYear: 2016, EndYear: 2025}
+	strangerMv = &models.ImdbapiTitle{ID: "tt0000010", PrimaryTitle: "Stranger Things", Type: "MOVIE", StartYear: 2016}
+	dark       = &models.ImdbapiTitle{ID: "tt5753856", PrimaryTitle: "Dark", Type: "TV_SERIES", StartYear: 2017}
+	darkMovie  = &models.ImdbapiTitle{ID: "tt0000020", PrimaryTitle: "Dark", Type: "MOVIE", StartYear: 2017}
+	obscure    = &models.ImdbapiTitle{ID: "tt0000030", PrimaryTitle: "Obscure Film", Type: "MOVIE", StartYear: 1971}
+)
+
+func TestMatch(t *testing.T) {
+	source := &fakeSource{
+		search: map[string][]*models.ImdbapiTitle{
+			"Heat":            {heat1986, heat},
+			"Stranger Things": {strangerMv, stranger},
+			"Dark":            {dark, darkMovie},
+		},
+		byYear: map[int][]*models.ImdbapiTitle{1971: {obscure}},
+	}
+	testCases := map[string]struct {
+		release Release
+		status  string
+		id      string
+		error   bool
+	}{
+		"with year picking the remake": {
+			release: Release{Title: "Heat", Year: 1995},
+			status:  Matched,
+			id:      "tt0113277",
+		},
+		"without year between remakes": {
+			release: Release{Title: "Heat"},
+			status:  Ambiguous,
+			id:      "tt0093164",
+		},
+		"with episode preferring the series": {
+			release: Release{Title: "Stranger Things", Season: 2, Episode: 3},
+			status:  Matched,
+			id:      "tt4574334",
+		},
+		"with episode inside the run": {
+			release: Release{Title: "Stranger Things", Year: 2019, Season: 3, Episode: 1},
+			status:  Matched,
+			id:      "tt4574334",
+		},
+		"with movie file of a series name": {
+			release: Release{Title: "Dark", Year: 2017},
+			status:  Matched,
+			id:      "tt0000020",
+		},
+		"with fallback to the year listing": {
+			release: Release{Title: "Obscure Film", Year: 1971},
+			status:  Matched,
+			id:      "tt0000030",
+		},
+		"with nothing close": {
+			release: Release{Title: "Nothing Like It"},
+			status:  Unmatched,
+		},
+		"with failed lookup": {
+			release: Release{Title: "Broken"},
+			status:  Unmatched,
+			error:   true,
+		},
+	}
+
+	matcher := NewMatcher(source, Options{})
+	for testName, testCase := range testCases {
+		match := matcher.Match(t.Context(), testCase.release)
+		id := ""
+		if match.Title != nil {
+			id = match.Title.ID
+		}
+		if match.Status != testCase.status || id != testCase.id || (match.Error != "") != testCase.error {
+			t.Errorf("TestMatch(%s) = got (%s %s %v %q), want (%s %s).", testName, match.Status, id, match.Confidence, match.Error, testCase.status, testCase.id)
+		}
+	}
+}
+
+func TestMatchAsksOncePerShow(t *testing.T) {
+	source := &fakeSource{search: map[string][]*models.ImdbapiTitle{"Dark": {dark}}}
+	matcher := NewMatcher(source, Options{})
+	for episode := 1; episode <= 3; episode++ {
+		matcher.Match(t.Context(), Release{Title: "Dark", Season: 1, Episode: episode})
+	}
+	if source.searches != 1 {
+		t.Errorf("TestMatchAsksOncePerShow = got %d searches, want 1.", source.searches)
+	}
+}
@@ -0,0 +1,118 @@
+// Package library matches the video files of a local archive to IMDb titles
+// from their release-style names.
+package library
+
+import (
+	"cmp"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VideoExtensions are the file extensions Scan picks up, lower case.
+var VideoExtensions = []string{".mkv", ".mp4", ".avi", ".m4v", ".mov", ".wmv", ".mpg", ".mpeg", ".ts", ".webm"}
+
+// Release is what a file name tells about its contents. Year, Season and
+// Episode are 0 when the name does not carry them.
+type Release struct {
+	Path    string `json:"path"`
+	Title   string `json:"title"`
+	Year    int    `json:"year,omitempty"`
+	Season  int    `json:"season,omitempty"`
+	Episode int    `json:"episode,omitempty"`
+}
+
+// IsEpisode reports whether the name placed the file in a season.
+func (r Release) IsEpisode() bool {
+	return r.Season > 0 || r.Episode > 0
+}
+
+var (
+	episodePattern = regexp.MustCompile(`(?i)^s(\d{1,2})[ ._-]?e(\d{1,3})(?:-?e\d{1,3})*$`)
+	crossPattern   = regexp.MustCompile(`(?i)^(\d{1,2})x(\d{2,3})$`)
+	yearPattern    = regexp.MustCompile(`^[(\[]?((?:19|20)\d{2})[)\]]?$`)
+	// noise ends the title part of a name: resolutions, sources, codecs
+	// and the other tags release groups put after it.
+	noisePattern = regexp.MustCompile(`(?i)^[(\[]?(?:\d{3,4}[pi]|4k|uhd|hdr\d*|dv|bluray|blu-ray|bdrip|brrip|dvdrip|dvd|web-?dl|webrip|hdtv|hdrip|remux|x26[45]|h\.?26[45]|hevc|avc|xvid|divx|aac\d?|ac3|dts|ddp?\d?|atmos|proper|repack|extended|unrated|remastered|internal|limited|multi|complete|season|s\d{1,2})[)\]]?$`)
+	separators   = strings.NewReplacer(".", " ", "_", " ")
+	// seasonDir names the directories between a show and its episodes.
+	seasonDir = regexp.MustCompile(`(?i)^(?:season|series|staffel|saison)[ ._-]*\d+$|^s\d{1,2}$|^specials$`)
+)
+
+// Parse reads a file name such as "Stranger.Things.S02E03.1080p.mkv" or
+// "Heat (1995).mkv". The title is whatever comes before the first year,
+// episode marker or release tag. Names without a title, such as
+// "Show/Season 2/S02E03.mkv", take it and the year from the nearest
+// directory that is not a season folder.
+func Parse(path string) Release {
+	name := filepath.Base(path)
+	release := parseName(strings.TrimSuffix(name, filepath.Ext(name)))
+	release.Path = path
+
+	for dir := filepath.Dir(path); release.Title == "" && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if seasonDir.MatchString(filepath.Base(dir)) {
+			continue
+		}
+		parent := parseName(filepath.Base(dir))
+		release.Title = parent.Title
+		release.Year = cmp.Or(release.Year, parent.Year)
+		break
+	}
+	return release
+}
+
+func parseName(name string) Release {
+	var release Release
+
+	var title []string
+	done := false
+	words := strings.Fields(separators.Replace(name))
+	for i, word := range words {
+		word = strings.Trim(word, "-")
+		if word == "" {
+			continue
+		}
+		if m := episodePattern.FindStringSubmatch(word); m != nil {
+			release.Season, _ = strconv.Atoi(m[1])
+			release.Episode, _ = strconv.Atoi(m[2])
+			done = true
+			continue
+		}
+		if m := crossPattern.FindStringSubmatch(word); m != nil && release.Season == 0 {
+			release.Season, _ = strconv.Atoi(m[1])
+			release.Episode, _ = strconv.Atoi(m[2])
+			done = true
+			continue
+		}
+		// A year as the very first word, or one followed by another year,
+		// is part of the title, as in "2001 A Space Odyssey" or
+		// "Blade Runner 2049 2017".
+		if m := yearPattern.FindStringSubmatch(word); m != nil && len(title) > 0 && (i+1 == len(words) || !yearPattern.MatchString(words[i+1])) {
+			if release.Year == 0 {
+				release.Year, _ = strconv.Atoi(m[1])
+			}
+			done = true
+			continue
+		}
+		if done || (len(title) > 0 && noisePattern.MatchString(word)) {
+			done = true
+			continue
+		}
+		title = append(title, word)
+	}
+
+	release.Title = strings.Join(title, " ")
+	return release
+}
+
+// ShowDir is the directory of a series an episode file belongs to: the one
+// holding its season folder, or the file's own directory when it is not
+// in one.
+func ShowDir(path string) string {
+	dir := filepath.Dir(path)
+	if seasonDir.MatchString(filepath.Base(dir)) {
+		return filepath.Dir(dir)
+	}
+	return dir
+}
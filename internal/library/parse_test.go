@@ -0,0 +1,81 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := map[string]struct {
+		path     string
+		expected Release
+	}{
+		"with dotted episode": {
+			path:     "Stranger.Things.S02E03.1080p.mkv",
+			expected: Release{Title: "Stranger Things", Season: 2, Episode: 3},
+		},
+		"with movie year in parens": {
+			path:     "Heat (1995).mkv",
+			expected: Release{Title: "Heat", Year: 1995},
+		},
+		"with release tags": {
+			path:     "Blade_Runner_2049_2017_2160p_UHD_BluRay_x265-GROUP.mkv",
+			expected: Release{Title: "Blade Runner 2049", Year: 2017},
+		},
+		"with year as first word": {
+			path:     "2001.A.Space.Odyssey.1968.mkv",
+			expected: Release{Title: "2001 A Space Odyssey", Year: 1968},
+		},
+		"with cross episode marker": {
+			path:     "The Office 2x05 Halloween.avi",
+			expected: Release{Title: "The Office", Season: 2, Episode: 5},
+		},
+		"with double episode and year": {
+			path:     "Doctor.Who.2005.S01E12E13.HDTV.mp4",
+			expected: Release{Title: "Doctor Who", Year: 2005, Season: 1, Episode: 12},
+		},
+		"with title from folders": {
+			path:     filepath.Join("shows", "Dark (2017)", "Season 1", "S01E04.mkv"),
+			expected: Release{Title: "Dark", Year: 2017, Season: 1, Episode: 4},
+		},
+		"with title starting like a tag": {
+			path:     "Season.of.the.Witch.2011.mkv",
+			expected: Release{Title: "Season of the Witch", Year: 2011},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		release := Parse(testCase.path)
+		testCase.expected.Path = testCase.path
+		if release != testCase.expected {
+			t.Errorf("TestParse(%s) = got (%+v), want (%+v).", testName, release, testCase.expected)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"Heat (1995).mkv", "notes.txt", "Show/S01E01.MP4", ".trash/Old.Movie.2001.mkv"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	releases, err := Scan(root)
+	if err != nil {
+		t.Fatalf("TestScan = unexpected error (%v)", err)
+	}
+	var titles []string
+	for _, release := range releases {
+		titles = append(titles, release.Title)
+	}
+	if expected := []string{"Heat", "Show"}; !slices.Equal(titles, expected) {
+		t.Errorf("TestScan = got (%v), want (%v).", titles, expected)
+	}
+}
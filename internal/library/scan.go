@@ -0,0 +1,30 @@
+package library
+
+import (
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Scan walks root and parses the name of every video file below it, in
+// lexical order. Hidden directories are skipped.
+func Scan(root string) ([]Release, error) {
+	var releases []Release
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if slices.Contains(VideoExtensions, strings.ToLower(filepath.Ext(path))) {
+			releases = append(releases, Parse(path))
+		}
+		return nil
+	})
+	return releases, err
+}
@@ -0,0 +1,43 @@
+package library
+
+import (
+	"context"
+
+	"github.com/foursixnine/imdblookup/internal/client"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// listLimit bounds how many titles of a year List collects.
+const listLimit = 100
+
+type clientSource struct {
+	client *client.ImdbClient
+}
+
+// NewClientSource looks titles up through the search endpoint, falling
+// back to listing the titles of the release year.
+func NewClientSource(imdbClient *client.ImdbClient) Source {
+	return &clientSource{client: imdbClient}
+}
+
+func (s *clientSource) Search(ctx context.Context, query string) ([]*models.ImdbapiTitle, error) {
+	titles, err := s.client.FindShowsByTitleContext(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+	return titles, nil
+}
+
+func (s *clientSource) List(ctx context.Context, year int, types []models.ImdbapiTitleType) ([]*models.ImdbapiTitle, error) {
+	titles, err := s.client.ListTitlesContext(ctx, &client.ListTitlesFilter{
+		Types:      types,
+		StartYear:  int32(year),
+		EndYear:    int32(year),
+		SortBy:     models.ImdbapiTitleSortBySORTBYPOPULARITY,
+		MaxResults: listLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return titles, nil
+}
@@ -0,0 +1,109 @@
+package nfo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is how many unchanged lines surround each hunk.
+const diffContext = 3
+
+// Diff is a unified diff of two texts, empty when they are equal. NFOs are
+// a few dozen lines, so the quadratic longest common subsequence is fine.
+func Diff(name string, old, new []byte) string {
+	a, b := lines(old), lines(new)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type edit struct {
+		op   byte
+		text string
+		// line numbers, from 1, in a and b before this edit
+		aLine, bLine int
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i], i, j})
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	var out strings.Builder
+	for start := 0; start < len(edits); {
+		// Find the next change and the run of edits it belongs to, changes
+		// closer than twice the context sharing a hunk.
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		last := first
+		for k := first; k < len(edits); k++ {
+			if edits[k].op != ' ' {
+				last = k
+			} else if k-last > 2*diffContext {
+				break
+			}
+		}
+		from, to := max(first-diffContext, 0), min(last+diffContext+1, len(edits))
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", name, name)
+		}
+		aCount, bCount := 0, 0
+		for _, e := range edits[from:to] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(edits[from].aLine, aCount), hunkRange(edits[from].bLine, bCount))
+		for _, e := range edits[from:to] {
+			fmt.Fprintf(&out, "%c%s\n", e.op, e.text)
+		}
+		start = to
+	}
+	return out.String()
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line)
+	}
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
+
+func lines(data []byte) []string {
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
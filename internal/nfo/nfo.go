@@ -0,0 +1,260 @@
+// Package nfo builds the XML sidecar files Kodi and Jellyfin read next to
+// video files: movie.nfo, tvshow.nfo and the episodedetails of each episode.
+package nfo
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// DefaultMaxActors is how many cast members an NFO lists when Options
+// leaves it at 0.
+const DefaultMaxActors = 15
+
+type Options struct {
+	// Country picks the certificate written as mpaa, US when empty.
+	Country   string
+	MaxActors int
+}
+
+// Details is what the api knows of a movie or series.
+type Details struct {
+	Title        *models.ImdbapiTitle
+	Credits      []*models.ImdbapiCredit
+	Certificates []*models.ImdbapiCertificate
+}
+
+type Rating struct {
+	Name    string  `xml:"name,attr"`
+	Max     int     `xml:"max,attr"`
+	Default bool    `xml:"default,attr,omitempty"`
+	Value   float64 `xml:"value"`
+	Votes   int32   `xml:"votes,omitempty"`
+}
+
+type UniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr"`
+	ID      string `xml:",chardata"`
+}
+
+type Thumb struct {
+	Aspect string `xml:"aspect,attr,omitempty"`
+	URL    string `xml:",chardata"`
+}
+
+type Actor struct {
+	Name  string `xml:"name"`
+	Role  string `xml:"role,omitempty"`
+	Order int    `xml:"order"`
+	Thumb string `xml:"thumb,omitempty"`
+}
+
+type Movie struct {
+	XMLName       xml.Name   `xml:"movie"`
+	Title         string     `xml:"title"`
+	OriginalTitle string     `xml:"originaltitle,omitempty"`
+	Ratings       []Rating   `xml:"ratings>rating,omitempty"`
+	Plot          string     `xml:"plot,omitempty"`
+	Runtime       int32      `xml:"runtime,omitempty"`
+	Thumbs        []Thumb    `xml:"thumb,omitempty"`
+	MPAA          string     `xml:"mpaa,omitempty"`
+	UniqueIDs     []UniqueID `xml:"uniqueid"`
+	Genres        []string   `xml:"genre,omitempty"`
+	Countries     []string   `xml:"country,omitempty"`
+	Writers       []string   `xml:"credits,omitempty"`
+	Directors     []string   `xml:"director,omitempty"`
+	Year          int32      `xml:"year,omitempty"`
+	Actors        []Actor    `xml:"actor,omitempty"`
+}
+
+type TVShow struct {
+	XMLName       xml.Name   `xml:"tvshow"`
+	Title         string     `xml:"title"`
+	OriginalTitle string     `xml:"originaltitle,omitempty"`
+	Ratings       []Rating   `xml:"ratings>rating,omitempty"`
+	Plot          string     `xml:"plot,omitempty"`
+	Thumbs        []Thumb    `xml:"thumb,omitempty"`
+	MPAA          string     `xml:"mpaa,omitempty"`
+	UniqueIDs     []UniqueID `xml:"uniqueid"`
+	Genres        []string   `xml:"genre,omitempty"`
+	Year          int32      `xml:"year,omitempty"`
+	Status        string     `xml:"status,omitempty"`
+	Actors        []Actor    `xml:"actor,omitempty"`
+}
+
+type Episode struct {
+	XMLName   xml.Name   `xml:"episodedetails"`
+	Title     string     `xml:"title"`
+	ShowTitle string     `xml:"showtitle,omitempty"`
+	Ratings   []Rating   `xml:"ratings>rating,omitempty"`
+	Season    int        `xml:"season"`
+	Episode   int32      `xml:"episode"`
+	Plot      string     `xml:"plot,omitempty"`
+	Runtime   int32      `xml:"runtime,omitempty"`
+	Thumbs    []Thumb    `xml:"thumb,omitempty"`
+	UniqueIDs []UniqueID `xml:"uniqueid"`
+	Aired     string     `xml:"aired,omitempty"`
+}
+
+func NewMovie(details Details, opts Options) *Movie {
+	title := details.Title
+	movie := &Movie{
+		Title:         title.PrimaryTitle,
+		OriginalTitle: originalTitle(title),
+		Ratings:       ratings(title.Rating, title.Metacritic),
+		Plot:          title.Plot,
+		Runtime:       title.RuntimeSeconds / 60,
+		Thumbs:        thumbs(title.PrimaryImage, "poster"),
+		MPAA:          certificate(details.Certificates, opts),
+		UniqueIDs:     []UniqueID{{Type: "imdb", Default: true, ID: title.ID}},
+		Genres:        title.Genres,
+		Year:          title.StartYear,
+		Actors:        actors(details.Credits, opts),
+	}
+	for _, country := range title.OriginCountries {
+		movie.Countries = append(movie.Countries, country.Name)
+	}
+	movie.Writers = names(title.Writers, details.Credits, "writer")
+	movie.Directors = names(title.Directors, details.Credits, "director")
+	return movie
+}
+
+func NewTVShow(details Details, opts Options) *TVShow {
+	title := details.Title
+	show := &TVShow{
+		Title:         title.PrimaryTitle,
+		OriginalTitle: originalTitle(title),
+		Ratings:       ratings(title.Rating, title.Metacritic),
+		Plot:          title.Plot,
+		Thumbs:        thumbs(title.PrimaryImage, "poster"),
+		MPAA:          certificate(details.Certificates, opts),
+		UniqueIDs:     []UniqueID{{Type: "imdb", Default: true, ID: title.ID}},
+		Genres:        title.Genres,
+		Year:          title.StartYear,
+		Actors:        actors(details.Credits, opts),
+	}
+	if title.EndYear != 0 {
+		show.Status = "Ended"
+	} else if title.StartYear != 0 {
+		show.Status = "Continuing"
+	}
+	return show
+}
+
+// NewEpisode describes episode of the series show. Episodes the api
+// places in no numbered season are written as season 0, the Kodi
+// convention for specials.
+func NewEpisode(show *models.ImdbapiTitle, episode *models.ImdbapiEpisode) *Episode {
+	season, _ := strconv.Atoi(episode.Season)
+	details := &Episode{
+		Title:     episode.Title,
+		ShowTitle: show.PrimaryTitle,
+		Ratings:   ratings(episode.Rating, nil),
+		Season:    season,
+		Episode:   episode.EpisodeNumber,
+		Plot:      episode.Plot,
+		Runtime:   episode.RuntimeSeconds / 60,
+		Thumbs:    thumbs(episode.PrimaryImage, ""),
+		UniqueIDs: []UniqueID{{Type: "imdb", Default: true, ID: episode.ID}},
+	}
+	if date := episode.ReleaseDate; date != nil && date.Year != 0 && date.Month != 0 && date.Day != 0 {
+		details.Aired = fmt.Sprintf("%04d-%02d-%02d", date.Year, date.Month, date.Day)
+	}
+	return details
+}
+
+// Marshal renders an NFO as an indented XML document ending in a newline.
+func Marshal(nfo any) ([]byte, error) {
+	data, err := xml.MarshalIndent(nfo, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header+string(data)), '\n'), nil
+}
+
+func originalTitle(title *models.ImdbapiTitle) string {
+	if title.OriginalTitle == title.PrimaryTitle {
+		return ""
+	}
+	return title.OriginalTitle
+}
+
+// ratings lists the imdb rating, as the default, and the Metacritic score.
+func ratings(rating *models.ImdbapiRating, metacritic *models.ImdbapiMetacritic) []Rating {
+	var list []Rating
+	if rating != nil && rating.AggregateRating > 0 {
+		value, _ := strconv.ParseFloat(strconv.FormatFloat(float64(rating.AggregateRating), 'f', 1, 32), 64)
+		list = append(list, Rating{Name: "imdb", Max: 10, Default: true, Value: value, Votes: rating.VoteCount})
+	}
+	if metacritic != nil && metacritic.Score > 0 {
+		list = append(list, Rating{Name: "metacritic", Max: 100, Value: float64(metacritic.Score), Votes: metacritic.ReviewCount})
+	}
+	return list
+}
+
+func thumbs(image *models.ImdbapiImage, aspect string) []Thumb {
+	if image == nil || image.URL == "" {
+		return nil
+	}
+	return []Thumb{{Aspect: aspect, URL: image.URL}}
+}
+
+// certificate is the rating given in the Options country, empty when that
+// country has none as another country's rating would be misleading.
+func certificate(certificates []*models.ImdbapiCertificate, opts Options) string {
+	country := cmp.Or(opts.Country, "US")
+	for _, c := range certificates {
+		if c.Country != nil && c.Country.Code == country {
+			return c.Rating
+		}
+	}
+	return ""
+}
+
+var castCategories = []string{"actor", "actress", "self"}
+
+func actors(credits []*models.ImdbapiCredit, opts Options) []Actor {
+	limit := cmp.Or(opts.MaxActors, DefaultMaxActors)
+	var list []Actor
+	for _, credit := range credits {
+		if len(list) == limit {
+			break
+		}
+		if credit.Name == nil || !slices.Contains(castCategories, credit.Category) {
+			continue
+		}
+		actor := Actor{Name: credit.Name.DisplayName, Order: len(list)}
+		if len(credit.Characters) > 0 {
+			actor.Role = credit.Characters[0]
+		}
+		if credit.Name.PrimaryImage != nil {
+			actor.Thumb = credit.Name.PrimaryImage.URL
+		}
+		list = append(list, actor)
+	}
+	return list
+}
+
+// names lists people from the title itself, or from its credits of
+// category when the title does not carry them.
+func names(people []*models.ImdbapiName, credits []*models.ImdbapiCredit, category string) []string {
+	var list []string
+	for _, name := range people {
+		list = append(list, name.DisplayName)
+	}
+	if len(list) > 0 {
+		return list
+	}
+	for _, credit := range credits {
+		if credit.Category == category && credit.Name != nil && !slices.Contains(list, credit.Name.DisplayName) {
+			list = append(list, credit.Name.DisplayName)
+		}
+	}
+	return list
+}
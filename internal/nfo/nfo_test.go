@@ -0,0 +1,125 @@
+package nfo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+var heat = Details{
+	Title: &models.ImdbapiTitle{
+		ID:              "tt0113277",
+		PrimaryTitle:    "Heat",
+		OriginalTitle:   "Heat",
+		Plot:            "A group of high-end professional thieves & a detective.",
+		RuntimeSeconds:  10200,
+		StartYear:       1995,
+		Genres:          []string{"Action", "Crime"},
+		OriginCountries: []*models.ImdbapiCountry{{Code: "US", Name: "United States"}},
+		Rating:          &models.ImdbapiRating{AggregateRating: 8.3, VoteCount: 750000},
+		Metacritic:      &models.ImdbapiMetacritic{Score: 76, ReviewCount: 22},
+		PrimaryImage:    &models.ImdbapiImage{URL: "https://example.com/heat.jpg"},
+		Directors:       []*models.ImdbapiName{{DisplayName: "Michael Mann"}},
+	},
+	Credits: []*models.ImdbapiCredit{
+		{Category: "actor", Name: &models.ImdbapiName{DisplayName: "Al Pacino"}, Characters: []string{"Vincent Hanna"}},
+		{Category: "director", Name: &models.ImdbapiName{DisplayName: "Michael Mann"}},
+		{Category: "writer", Name: &models.ImdbapiName{DisplayName: "Michael Mann"}},
+		{Category: "actor", Name: &models.ImdbapiName{DisplayName: "Robert De Niro"}, Characters: []string{"Neil McCauley"}},
+	},
+	Certificates: []*models.ImdbapiCertificate{
+		{Country: &models.ImdbapiCountry{Code: "GB"}, Rating: "15"},
+		{Country: &models.ImdbapiCountry{Code: "US"}, Rating: "R"},
+	},
+}
+
+func TestMarshalMovie(t *testing.T) {
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<movie>
+  <title>Heat</title>
+  <ratings>
+    <rating name="imdb" max="10" default="true">
+      <value>8.3</value>
+      <votes>750000</votes>
+    </rating>
+    <rating name="metacritic" max="100">
+      <value>76</value>
+      <votes>22</votes>
+    </rating>
+  </ratings>
+  <plot>A group of high-end professional thieves &amp; a detective.</plot>
+  <runtime>170</runtime>
+  <thumb aspect="poster">https://example.com/heat.jpg</thumb>
+  <mpaa>R</mpaa>
+  <uniqueid type="imdb" default="true">tt0113277</uniqueid>
+  <genre>Action</genre>
+  <genre>Crime</genre>
+  <country>United States</country>
+  <credits>Michael Mann</credits>
+  <director>Michael Mann</director>
+  <year>1995</year>
+  <actor>
+    <name>Al Pacino</name>
+    <role>Vincent Hanna</role>
+    <order>0</order>
+  </actor>
+  <actor>
+    <name>Robert De Niro</name>
+    <role>Neil McCauley</role>
+    <order>1</order>
+  </actor>
+</movie>
+`
+	data, err := Marshal(NewMovie(heat, Options{}))
+	if err != nil {
+		t.Fatalf("TestMarshalMovie = unexpected error (%v)", err)
+	}
+	if string(data) != expected {
+		t.Errorf("TestMarshalMovie = got\n%s\nwant\n%s", data, expected)
+	}
+}
+
+func TestNewMovieOptions(t *testing.T) {
+	testCases := map[string]struct {
+		opts   Options
+		mpaa   string
+		actors int
+	}{
+		"with defaults":        {opts: Options{}, mpaa: "R", actors: 2},
+		"with other country":   {opts: Options{Country: "GB"}, mpaa: "15"},
+		"with missing country": {opts: Options{Country: "JP"}, mpaa: ""},
+		"with one actor":       {opts: Options{MaxActors: 1}, mpaa: "R", actors: 1},
+	}
+
+	for testName, testCase := range testCases {
+		movie := NewMovie(heat, testCase.opts)
+		if movie.MPAA != testCase.mpaa || (testCase.actors != 0 && len(movie.Actors) != testCase.actors) {
+			t.Errorf("TestNewMovieOptions(%s) = got (%s, %d actors), want (%s, %d actors).", testName, movie.MPAA, len(movie.Actors), testCase.mpaa, testCase.actors)
+		}
+	}
+}
+
+func TestNewTVShowAndEpisode(t *testing.T) {
+	series := &models.ImdbapiTitle{ID: "tt4574334", PrimaryTitle: "Stranger Things", StartYear: 2016, EndYear: 2025}
+	if show := NewTVShow(Details{Title: series}, Options{}); show.Status != "Ended" || show.UniqueIDs[0].ID != "tt4574334" {
+		t.Errorf("TestNewTVShowAndEpisode = got show (%+v), want an ended tt4574334.", show)
+	}
+
+	episode := NewEpisode(series, &models.ImdbapiEpisode{
+		ID:            "tt4593118",
+		Title:         "Chapter One",
+		Season:        "1",
+		EpisodeNumber: 1,
+		ReleaseDate:   &models.ImdbapiPrecisionDate{Year: 2016, Month: 7, Day: 15},
+	})
+	data, err := Marshal(episode)
+	if err != nil {
+		t.Fatalf("TestNewTVShowAndEpisode = unexpected error (%v)", err)
+	}
+	for _, want := range []string{"<episodedetails>", "<showtitle>Stranger Things</showtitle>", "<season>1</season>", "<episode>1</episode>", "<aired>2016-07-15</aired>"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("TestNewTVShowAndEpisode = episode lacks (%s):\n%s", want, data)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package nfo
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Actions a Change reports.
+const (
+	Create    = "create"
+	Update    = "update"
+	Unchanged = "unchanged"
+)
+
+// Change is what writing one NFO did, or would do in a dry run. Diff is
+// set for updates.
+type Change struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`
+	Diff   string `json:"diff,omitempty"`
+}
+
+// Write puts data at path unless an identical file is already there, or
+// dryRun is set. The file is replaced through a rename, so a reader never
+// sees half an NFO, and keeps its permissions; new files are 0644.
+func Write(path string, data []byte, dryRun bool) (Change, error) {
+	change := Change{Path: path, Action: Create}
+	mode := fs.FileMode(0o644)
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil && bytes.Equal(existing, data):
+		change.Action = Unchanged
+		return change, nil
+	case err == nil:
+		change.Action = Update
+		change.Diff = Diff(path, existing, data)
+		info, err := os.Stat(path)
+		if err != nil {
+			return change, err
+		}
+		mode = info.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return change, err
+	}
+	if dryRun {
+		return change, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return change, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return change, err
+	}
+	if err := tmp.Close(); err != nil {
+		return change, err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return change, err
+	}
+	return change, os.Rename(tmp.Name(), path)
+}
+
+// MoviePath is where the NFO of a movie file goes: movie.nfo when the file
+// has its directory to itself, the file name with an .nfo extension
+// otherwise.
+func MoviePath(video string, sharesDirectory bool) string {
+	if sharesDirectory {
+		return EpisodePath(video)
+	}
+	return filepath.Join(filepath.Dir(video), "movie.nfo")
+}
+
+// TVShowPath is the tvshow.nfo of the series stored in showDir.
+func TVShowPath(showDir string) string {
+	return filepath.Join(showDir, "tvshow.nfo")
+}
+
+// EpisodePath is the NFO beside an episode file, the file name with an .nfo
+// extension.
+func EpisodePath(video string) string {
+	return strings.TrimSuffix(video, filepath.Ext(video)) + ".nfo"
+}
@@ -0,0 +1,96 @@
+package nfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := map[string]struct {
+		old, new string
+		expected string
+	}{
+		"with equal texts": {old: "a\nb\n", new: "a\nb\n", expected: ""},
+		"with one line changed": {
+			old:      "a\nb\nc\nd\ne\nf\ng\nh\n",
+			new:      "a\nb\nc\nd\nE\nf\ng\nh\n",
+			expected: "--- x\n+++ x\n@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n",
+		},
+		"with a new file": {
+			old:      "",
+			new:      "a\n",
+			expected: "--- x\n+++ x\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		"with two far apart changes": {
+			old:      "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			new:      "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			expected: "--- x\n+++ x\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		if diff := Diff("x", []byte(testCase.old), []byte(testCase.new)); diff != testCase.expected {
+			t.Errorf("TestDiff(%s) = got\n%s\nwant\n%s", testName, diff, testCase.expected)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "movie.nfo")
+	testCases := []struct {
+		name    string
+		data    string
+		dryRun  bool
+		action  string
+		content string
+	}{
+		{name: "dry run create", data: "a\n", dryRun: true, action: Create},
+		{name: "create", data: "a\n", action: Create, content: "a\n"},
+		{name: "unchanged", data: "a\n", action: Unchanged, content: "a\n"},
+		{name: "dry run update", data: "b\n", dryRun: true, action: Update, content: "a\n"},
+		{name: "update", data: "b\n", action: Update, content: "b\n"},
+	}
+
+	for _, testCase := range testCases {
+		change, err := Write(path, []byte(testCase.data), testCase.dryRun)
+		if err != nil {
+			t.Fatalf("TestWrite(%s) = unexpected error (%v)", testCase.name, err)
+		}
+		if change.Action != testCase.action || (change.Action == Update) != (change.Diff != "") {
+			t.Errorf("TestWrite(%s) = got (%+v), want action (%s).", testCase.name, change, testCase.action)
+		}
+		content, _ := os.ReadFile(path)
+		if string(content) != testCase.content {
+			t.Errorf("TestWrite(%s) = file holds (%q), want (%q).", testCase.name, content, testCase.content)
+		}
+	}
+
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Write(path, []byte("c\n"), false); err != nil {
+		t.Fatalf("TestWrite(private update) = unexpected error (%v)", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("TestWrite(private update) = got (%v, %v), want the mode kept at 0600.", info.Mode().Perm(), err)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	testCases := map[string]struct {
+		path     string
+		expected string
+	}{
+		"movie alone":     {path: MoviePath("lib/Heat (1995)/Heat.mkv", false), expected: "lib/Heat (1995)/movie.nfo"},
+		"movie in a pile": {path: MoviePath("lib/Heat (1995).mkv", true), expected: "lib/Heat (1995).nfo"},
+		"series":          {path: TVShowPath("lib/Dark"), expected: "lib/Dark/tvshow.nfo"},
+		"episode":         {path: EpisodePath("lib/Dark/S01E01.mkv"), expected: "lib/Dark/S01E01.nfo"},
+	}
+
+	for testName, testCase := range testCases {
+		if filepath.ToSlash(testCase.path) != testCase.expected {
+			t.Errorf("TestPaths(%s) = got (%s), want (%s).", testName, testCase.path, testCase.expected)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package output
+
+import "github.com/foursixnine/imdblookup/models"
+
+func init() {
+	RegisterColumns(models.ImdbapiTitle{}, "id", "type", "primaryTitle", "startYear", "rating.aggregateRating", "genres")
+	RegisterColumns(models.ImdbapiName{}, "id", "displayName", "primaryProfessions", "birthDate.year")
+	RegisterColumns(models.ImdbapiCredit{}, "name.id", "name.displayName", "category", "characters")
+	RegisterColumns(models.ImdbapiEpisode{}, "season", "episodeNumber", "id", "title", "rating.aggregateRating")
+	RegisterColumns(models.ImdbapiAwardNomination{}, "year", "event.name", "category", "isWinner", "nominees.displayName")
+	RegisterColumns(models.ImdbapiBoxOffice{}, "productionBudget.amount", "domesticGross.amount", "worldwideGross.amount", "openingWeekendGross.gross.amount")
+	RegisterColumns(models.ImdbapiInterestCategory{}, "category", "interests.name")
+}
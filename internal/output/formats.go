@@ -0,0 +1,105 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func init() {
+	Register("table", RendererFunc(renderTable))
+	Register("json", RendererFunc(renderJSON))
+	Register("ndjson", RendererFunc(renderNDJSON))
+	Register("csv", RendererFunc(renderCSV))
+	Register("yaml", RendererFunc(renderYAML))
+}
+
+func renderTable(w io.Writer, records []Record, opts Options) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(opts.Columns))
+	for i, column := range opts.Columns {
+		headers[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	for _, record := range records {
+		cells := make([]string, len(opts.Columns))
+		for i, column := range opts.Columns {
+			// Keep cells on one line so the columns stay aligned.
+			cells[i] = strings.Join(strings.Fields(record.Lookup(column)), " ")
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func renderCSV(w io.Writer, records []Record, opts Options) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(opts.Columns); err != nil {
+		return err
+	}
+	for _, record := range records {
+		row := make([]string, len(opts.Columns))
+		for i, column := range opts.Columns {
+			row[i] = record.Lookup(column)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func renderJSON(w io.Writer, records []Record, opts Options) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(values(records))
+}
+
+func renderNDJSON(w io.Writer, records []Record, opts Options) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderYAML goes through the JSON form so keys match the api's field names.
+func renderYAML(w io.Writer, records []Record, opts Options) error {
+	var document any
+	if len(records) == 1 && records[0].single {
+		document = records[0].Fields
+	} else {
+		fields := make([]map[string]any, len(records))
+		for i, record := range records {
+			fields[i] = record.Fields
+		}
+		document = fields
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func values(records []Record) any {
+	if len(records) == 1 && records[0].single {
+		return records[0].Value
+	}
+	values := make([]any, len(records))
+	for i, record := range records {
+		values[i] = record.Value
+	}
+	return values
+}
@@ -0,0 +1,213 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Options tunes a renderer. Columns are dotted paths over the JSON form of a
+// value, such as "id" or "rating.aggregateRating", and only apply to the
+// tabular formats.
+type Options struct {
+	Columns []string
+}
+
+// Renderer writes a result set. Renderers are independent of the model types
+// they are given, they only see Records.
+type Renderer interface {
+	Render(w io.Writer, records []Record, opts Options) error
+}
+
+type RendererFunc func(w io.Writer, records []Record, opts Options) error
+
+func (f RendererFunc) Render(w io.Writer, records []Record, opts Options) error {
+	return f(w, records, opts)
+}
+
+// Record is one item of a result set along with its JSON form.
+type Record struct {
+	Value  any
+	Fields map[string]any
+	single bool
+}
+
+var (
+	mu             sync.RWMutex
+	renderers      = map[string]Renderer{}
+	defaultColumns = map[reflect.Type][]string{}
+)
+
+func Register(format string, renderer Renderer) {
+	mu.Lock()
+	defer mu.Unlock()
+	renderers[format] = renderer
+}
+
+// RegisterColumns sets the columns used for values of the same type as
+// example when none are asked for. Types without registered columns fall
+// back to their scalar fields.
+func RegisterColumns(example any, columns ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultColumns[indirect(reflect.TypeOf(example))] = columns
+}
+
+func Formats() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	formats := make([]string, 0, len(renderers))
+	for format := range renderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Render writes value, a single item or a slice of items, in the given format.
+func Render(w io.Writer, format string, value any, opts Options) error {
+	mu.RLock()
+	renderer, ok := renderers[format]
+	mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(Formats(), ", "))
+	}
+
+	records, err := records(value)
+	if err != nil {
+		return err
+	}
+	if len(opts.Columns) == 0 && len(records) > 0 {
+		opts.Columns = columnsFor(records[0].Value)
+	}
+	return renderer.Render(w, records, opts)
+}
+
+func records(value any) ([]Record, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		record, err := newRecord(value)
+		record.single = true
+		return []Record{record}, err
+	}
+
+	records := make([]Record, 0, v.Len())
+	for i := range v.Len() {
+		record, err := newRecord(v.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func newRecord(value any) (Record, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return Record{}, err
+	}
+
+	record := Record{Value: value}
+	var fields any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Record{}, err
+	}
+	if m, ok := fields.(map[string]any); ok {
+		record.Fields = m
+	} else {
+		record.Fields = map[string]any{"value": fields}
+	}
+	return record, nil
+}
+
+func columnsFor(value any) []string {
+	t := indirect(reflect.TypeOf(value))
+
+	mu.RLock()
+	columns, ok := defaultColumns[t]
+	mu.RUnlock()
+	if ok {
+		return columns
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return []string{"value"}
+	}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		switch indirect(field.Type).Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Interface:
+			continue
+		}
+		columns = append(columns, name)
+	}
+	return columns
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// Lookup resolves a dotted column path against the record. Lists are
+// flattened and joined with ", ".
+func (r Record) Lookup(column string) string {
+	return format(lookup(r.Fields, strings.Split(column, ".")))
+}
+
+func lookup(value any, path []string) any {
+	if len(path) == 0 {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		return lookup(v[path[0]], path[1:])
+	case []any:
+		values := make([]any, 0, len(v))
+		for _, item := range v {
+			if found := lookup(item, path); found != nil {
+				values = append(values, found)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func format(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, format(item))
+		}
+		return strings.Join(slices.DeleteFunc(parts, func(s string) bool { return s == "" }), ", ")
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
@@ -0,0 +1,104 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+var titles = []*models.ImdbapiTitle{
+	{ID: "tt0111161", PrimaryTitle: "The Shawshank Redemption", StartYear: 1994, Type: "MOVIE", Genres: []string{"Drama"}, Rating: &models.ImdbapiRating{AggregateRating: 9.3}},
+	{ID: "tt4574334", PrimaryTitle: "Stranger Things", StartYear: 2016, Type: "TV_SERIES", Genres: []string{"Drama", "Fantasy"}},
+}
+
+func TestRender(t *testing.T) {
+	testCases := map[string]struct {
+		format   string
+		value    any
+		opts     Options
+		expected string
+	}{
+		"table with default columns": {
+			format: "table",
+			value:  titles,
+			expected: "ID         TYPE       PRIMARYTITLE              STARTYEAR  RATING.AGGREGATERATING  GENRES\n" +
+				"tt0111161  MOVIE      The Shawshank Redemption  1994       9.3                     Drama\n" +
+				"tt4574334  TV_SERIES  Stranger Things           2016                               Drama, Fantasy\n",
+		},
+		"csv with selected columns": {
+			format:   "csv",
+			value:    titles,
+			opts:     Options{Columns: []string{"id", "genres"}},
+			expected: "id,genres\ntt0111161,Drama\ntt4574334,\"Drama, Fantasy\"\n",
+		},
+		"ndjson": {
+			format: "ndjson",
+			value:  titles,
+			expected: `{"directors":null,"genres":["Drama"],"id":"tt0111161","interests":null,"originCountries":null,"primaryTitle":"The Shawshank Redemption","rating":{"aggregateRating":9.3},"spokenLanguages":null,"stars":null,"startYear":1994,"type":"MOVIE","writers":null}` + "\n" +
+				`{"directors":null,"genres":["Drama","Fantasy"],"id":"tt4574334","interests":null,"originCountries":null,"primaryTitle":"Stranger Things","spokenLanguages":null,"stars":null,"startYear":2016,"type":"TV_SERIES","writers":null}` + "\n",
+		},
+		"json single value": {
+			format:   "json",
+			value:    &models.ImdbapiCountry{Code: "US", Name: "United States"},
+			expected: "{\n  \"code\": \"US\",\n  \"name\": \"United States\"\n}\n",
+		},
+		"yaml single value": {
+			format:   "yaml",
+			value:    &models.ImdbapiCountry{Code: "US", Name: "United States"},
+			expected: "code: US\nname: United States\n",
+		},
+		"yaml list": {
+			format:   "yaml",
+			value:    []models.ImdbapiCountry{{Code: "US"}, {Code: "GB"}},
+			expected: "- code: US\n- code: GB\n",
+		},
+		"table for unregistered type": {
+			format:   "table",
+			value:    []*models.ImdbapiYearsInvolved{{StartYear: 2001, EndYear: 2003}},
+			expected: "ENDYEAR  STARTYEAR\n2003     2001\n",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := Render(&out, testCase.format, testCase.value, testCase.opts); err != nil {
+				t.Fatalf("TestRender(%s) = unexpected error (%v)", testName, err)
+			}
+			if out.String() != testCase.expected {
+				t.Errorf("TestRender(%s) = got\n%q\nwant\n%q", testName, out.String(), testCase.expected)
+			}
+		})
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	err := Render(&bytes.Buffer{}, "xml", titles, Options{})
+	if err == nil || !strings.Contains(err.Error(), "csv, json, ndjson, table, yaml") {
+		t.Errorf("TestRenderUnknownFormat = got (%v), want an error listing the formats", err)
+	}
+}
+
+func TestRecordLookup(t *testing.T) {
+	record, err := newRecord(&models.ImdbapiTitle{
+		Directors: []*models.ImdbapiName{{DisplayName: "Frank Darabont"}, {DisplayName: "Someone Else"}},
+		Rating:    &models.ImdbapiRating{VoteCount: 3000000},
+	})
+	if err != nil {
+		t.Fatalf("TestRecordLookup = unexpected error (%v)", err)
+	}
+
+	testCases := map[string]string{
+		"directors.displayName": "Frank Darabont, Someone Else",
+		"rating.voteCount":      "3000000",
+		"rating.missing":        "",
+		"plot":                  "",
+	}
+	for column, expected := range testCases {
+		if result := record.Lookup(column); result != expected {
+			t.Errorf("TestRecordLookup(%s) = got (%q), want (%q).", column, result, expected)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// Funcs are the helpers available to --format templates.
+var Funcs = template.FuncMap{
+	"runtime": Runtime,
+	"date":    Date,
+	"join":    Join,
+	"stars":   Stars,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+}
+
+// NewTemplate parses a docker-style format string, executed once per item.
+// Escaped tabs and newlines are unescaped so they can be typed on a shell.
+func NewTemplate(format string) (*template.Template, error) {
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+	return template.New("format").Funcs(Funcs).Parse(format)
+}
+
+// RenderTemplate executes tmpl against value, or against every element when
+// value is a slice, each followed by a newline.
+func RenderTemplate(w io.Writer, tmpl *template.Template, value any) error {
+	records, err := records(value)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := tmpl.Execute(w, record.Value); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Runtime renders a duration in seconds, such as RuntimeSeconds, as "2h 22m".
+func Runtime(seconds int32) string {
+	if seconds <= 0 {
+		return ""
+	}
+	d := time.Duration(seconds) * time.Second
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
+
+// Date renders a precision date with as much precision as it carries:
+// "1994", "1994-09" or "1994-09-23".
+func Date(date *models.ImdbapiPrecisionDate) string {
+	switch {
+	case date == nil || date.Year == 0:
+		return ""
+	case date.Month == 0:
+		return fmt.Sprintf("%04d", date.Year)
+	case date.Day == 0:
+		return fmt.Sprintf("%04d-%02d", date.Year, date.Month)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", date.Year, date.Month, date.Day)
+}
+
+// Join joins strings such as Genres, separator defaulting to ", ".
+func Join(values []string, separator ...string) string {
+	if len(separator) > 0 {
+		return strings.Join(values, separator[0])
+	}
+	return strings.Join(values, ", ")
+}
+
+// Stars draws a rating out of ten as five stars, rounding to half stars.
+func Stars(rating any) string {
+	var value float64
+	switch r := rating.(type) {
+	case *models.ImdbapiRating:
+		if r == nil {
+			return ""
+		}
+		value = float64(r.AggregateRating)
+	case float32:
+		value = float64(r)
+	case float64:
+		value = r
+	default:
+		return ""
+	}
+
+	halves := int(math.Round(math.Max(0, math.Min(value, 10))))
+	return strings.Repeat("★", halves/2) + strings.Repeat("⯪", halves%2) + strings.Repeat("☆", 5-halves/2-halves%2)
+}
@@ -0,0 +1,80 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	testCases := map[string]struct {
+		format   string
+		value    any
+		expected string
+	}{
+		"fields of every title": {
+			format:   `{{.ID}}\t{{.PrimaryTitle}} ({{.StartYear}})`,
+			value:    titles,
+			expected: "tt0111161\tThe Shawshank Redemption (1994)\ntt4574334\tStranger Things (2016)\n",
+		},
+		"helpers": {
+			format:   `{{runtime .RuntimeSeconds}} {{join .Genres "/"}} {{stars .Rating}}`,
+			value:    &models.ImdbapiTitle{RuntimeSeconds: 8520, Genres: []string{"Drama", "Crime"}, Rating: &models.ImdbapiRating{AggregateRating: 9.3}},
+			expected: "2h 22m Drama/Crime ★★★★⯪\n",
+		},
+		"name dates": {
+			format:   `{{.DisplayName}} {{date .BirthDate}} {{date .DeathDate}}`,
+			value:    &models.ImdbapiName{DisplayName: "Kevin Bacon", BirthDate: &models.ImdbapiPrecisionDate{Year: 1958, Month: 7, Day: 8}},
+			expected: "Kevin Bacon 1958-07-08 \n",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		tmpl, err := NewTemplate(testCase.format)
+		if err != nil {
+			t.Fatalf("TestRenderTemplate(%s) = unexpected parse error (%v)", testName, err)
+		}
+		var out bytes.Buffer
+		if err := RenderTemplate(&out, tmpl, testCase.value); err != nil {
+			t.Fatalf("TestRenderTemplate(%s) = unexpected error (%v)", testName, err)
+		}
+		if out.String() != testCase.expected {
+			t.Errorf("TestRenderTemplate(%s) = got (%q), want (%q).", testName, out.String(), testCase.expected)
+		}
+	}
+}
+
+func TestTemplateHelpers(t *testing.T) {
+	runtimes := map[int32]string{0: "", 45 * 60: "45m", 7200: "2h", 8520: "2h 22m"}
+	for seconds, expected := range runtimes {
+		if result := Runtime(seconds); result != expected {
+			t.Errorf("Runtime(%d) = got (%q), want (%q).", seconds, result, expected)
+		}
+	}
+
+	dates := map[string]struct {
+		date     *models.ImdbapiPrecisionDate
+		expected string
+	}{
+		"nil":        {nil, ""},
+		"year":       {&models.ImdbapiPrecisionDate{Year: 1994}, "1994"},
+		"year month": {&models.ImdbapiPrecisionDate{Year: 1994, Month: 9}, "1994-09"},
+		"full":       {&models.ImdbapiPrecisionDate{Year: 1994, Month: 9, Day: 23}, "1994-09-23"},
+	}
+	for name, testCase := range dates {
+		if result := Date(testCase.date); result != testCase.expected {
+			t.Errorf("Date(%s) = got (%q), want (%q).", name, result, testCase.expected)
+		}
+	}
+
+	stars := map[float64]string{0: "☆☆☆☆☆", 5: "★★⯪☆☆", 7.8: "★★★★☆", 10: "★★★★★", 12: "★★★★★"}
+	for rating, expected := range stars {
+		if result := Stars(rating); result != expected {
+			t.Errorf("Stars(%v) = got (%q), want (%q).", rating, result, expected)
+		}
+	}
+	if result := Stars((*models.ImdbapiRating)(nil)); result != "" {
+		t.Errorf("Stars(nil) = got (%q), want empty.", result)
+	}
+}
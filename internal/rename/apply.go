@@ -0,0 +1,185 @@
+package rename
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// ErrStale is returned by Apply when the filesystem no longer matches the
+// plan, nothing having been moved.
+var ErrStale = errors.New("plan is out of date")
+
+// Journal operations.
+const (
+	opMkdir = "mkdir"
+	opMove  = "move"
+)
+
+// entry is a line of the journal. Entries are written before the step
+// they describe, so a journal may end in a step that never happened.
+type entry struct {
+	Op   string `json:"op"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("reading plan %s: %w", path, err)
+	}
+	return &plan, nil
+}
+
+func (plan *Plan) Save(path string) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Check verifies every source is still there and every target still free,
+// reporting all the moves that are not.
+func (plan *Plan) Check() error {
+	var problems []error
+	for _, move := range plan.Moves {
+		if _, err := os.Lstat(move.From); err != nil {
+			problems = append(problems, fmt.Errorf("%w: %s is gone", ErrStale, move.From))
+		}
+		if occupied(move.To, move.From) {
+			problems = append(problems, fmt.Errorf("%w: %s already exists", ErrStale, move.To))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// Apply checks plan and then performs its moves in order, creating the
+// directories they need. Every step goes to the journal at journalPath,
+// which must not exist yet, before it is taken. On error the moves done so
+// far are returned and stay in place, Undo reverts them.
+func Apply(plan *Plan, journalPath string) ([]Move, error) {
+	if err := plan.Check(); err != nil {
+		return nil, err
+	}
+
+	journal, err := os.OpenFile(journalPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer journal.Close()
+	record := func(e entry) error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := journal.Write(append(data, '\n')); err != nil {
+			return err
+		}
+		return journal.Sync()
+	}
+
+	var done []Move
+	for _, move := range plan.Moves {
+		for _, dir := range missingDirs(filepath.Dir(move.To)) {
+			if err := record(entry{Op: opMkdir, Path: dir}); err != nil {
+				return done, err
+			}
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				return done, err
+			}
+		}
+		if err := record(entry{Op: opMove, From: move.From, To: move.To}); err != nil {
+			return done, err
+		}
+		// Rename replaces an existing target without asking, so look once
+		// more right before moving.
+		if occupied(move.To, move.From) {
+			return done, fmt.Errorf("%w: %s already exists", ErrStale, move.To)
+		}
+		if err := os.Rename(move.From, move.To); err != nil {
+			return done, err
+		}
+		done = append(done, move)
+	}
+	return done, nil
+}
+
+// missingDirs lists the directories to create for dir to exist, outermost
+// first.
+func missingDirs(dir string) []string {
+	var missing []string
+	for ; ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(dir); err == nil || dir == filepath.Dir(dir) {
+			break
+		}
+		missing = append(missing, dir)
+	}
+	slices.Reverse(missing)
+	return missing
+}
+
+// Undo reverts the journal at path, latest step first. Moves that never
+// happened are passed over, and directories are only removed once empty.
+// It carries on past a move it cannot revert and reports them all.
+func Undo(path string) ([]Move, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// A torn last line is a step that was never taken.
+			break
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var undone []Move
+	var problems []error
+	for _, e := range slices.Backward(entries) {
+		switch e.Op {
+		case opMkdir:
+			// Fails when the directory holds files not put there by us,
+			// which then stay.
+			os.Remove(e.Path)
+		case opMove:
+			_, toErr := os.Lstat(e.To)
+			_, fromErr := os.Lstat(e.From)
+			switch {
+			case errors.Is(toErr, fs.ErrNotExist) && fromErr == nil:
+				continue
+			case toErr != nil:
+				problems = append(problems, fmt.Errorf("cannot move back %s: %w", e.To, toErr))
+			case occupied(e.From, e.To):
+				problems = append(problems, fmt.Errorf("cannot move back %s: %s is taken", e.To, e.From))
+			default:
+				if err := os.Rename(e.To, e.From); err != nil {
+					problems = append(problems, err)
+					continue
+				}
+				undone = append(undone, Move{From: e.To, To: e.From})
+			}
+		}
+	}
+	return undone, errors.Join(problems...)
+}
@@ -0,0 +1,126 @@
+package rename
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyUndo(t *testing.T) {
+	root := t.TempDir()
+	touch(t, root, "heat.mkv", "got.s01e09.mkv")
+	plan := &Plan{Root: root, Moves: []Move{
+		{From: filepath.Join(root, "heat.mkv"), To: filepath.Join(root, "Heat", "Heat.mkv")},
+		{From: filepath.Join(root, "got.s01e09.mkv"), To: filepath.Join(root, "GoT", "Season 01", "GoT S01E09.mkv")},
+	}}
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	if err := plan.Save(planPath); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPlan(planPath)
+	if err != nil || len(loaded.Moves) != len(plan.Moves) {
+		t.Fatalf("TestApplyUndo() = got (%v, %v) loading the plan, want its moves.", loaded, err)
+	}
+
+	journal := planPath + ".journal"
+	done, err := Apply(loaded, journal)
+	if err != nil || len(done) != 2 {
+		t.Fatalf("TestApplyUndo() = got (%v, %v) applying, want 2 moves.", done, err)
+	}
+	for _, move := range plan.Moves {
+		if _, err := os.Stat(move.To); err != nil {
+			t.Errorf("TestApplyUndo() = got (%v), want %s moved.", err, move.To)
+		}
+	}
+	if _, err := Apply(loaded, journal); err == nil {
+		t.Errorf("TestApplyUndo() = got no error applying twice, want one.")
+	}
+
+	undone, err := Undo(journal)
+	if err != nil || len(undone) != 2 {
+		t.Fatalf("TestApplyUndo() = got (%v, %v) undoing, want 2 moves.", undone, err)
+	}
+	for _, move := range plan.Moves {
+		if _, err := os.Stat(move.From); err != nil {
+			t.Errorf("TestApplyUndo() = got (%v), want %s back.", err, move.From)
+		}
+	}
+	for _, dir := range []string{"Heat", "GoT"} {
+		if _, err := os.Stat(filepath.Join(root, dir)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("TestApplyUndo() = got (%v), want %s removed.", err, dir)
+		}
+	}
+}
+
+func TestApplyStale(t *testing.T) {
+	root := t.TempDir()
+	touch(t, root, "heat.mkv", "taken.mkv")
+	testCases := map[string]Move{
+		"with a gone source":  {From: filepath.Join(root, "gone.mkv"), To: filepath.Join(root, "new.mkv")},
+		"with a taken target": {From: filepath.Join(root, "heat.mkv"), To: filepath.Join(root, "taken.mkv")},
+	}
+
+	for testName, move := range testCases {
+		journal := filepath.Join(t.TempDir(), "journal")
+		if _, err := Apply(&Plan{Root: root, Moves: []Move{move}}, journal); !errors.Is(err, ErrStale) {
+			t.Errorf("TestApplyStale(%s) = got (%v), want (%v).", testName, err, ErrStale)
+		}
+		if _, err := os.Stat(journal); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("TestApplyStale(%s) = got a journal, want none.", testName)
+		}
+	}
+}
+
+func TestUndoTornJournal(t *testing.T) {
+	root := t.TempDir()
+	touch(t, root, "Heat.mkv", "other.mkv")
+	journal := filepath.Join(t.TempDir(), "journal")
+	lines := `{"op":"move","from":"` + filepath.Join(root, "heat.mkv") + `","to":"` + filepath.Join(root, "Heat.mkv") + `"}` + "\n" +
+		// A move recorded but never taken is passed over.
+		`{"op":"move","from":"` + filepath.Join(root, "other.mkv") + `","to":"` + filepath.Join(root, "Other.mkv") + `"}` + "\n" +
+		`{"op":"mo`
+	if err := os.WriteFile(journal, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	undone, err := Undo(journal)
+	if err != nil || len(undone) != 1 {
+		t.Fatalf("TestUndoTornJournal() = got (%v, %v), want 1 move.", undone, err)
+	}
+	for _, name := range []string{"heat.mkv", "other.mkv"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Errorf("TestUndoTornJournal() = got (%v), want %s in place.", err, name)
+		}
+	}
+}
+
+// TestCaseOnlyRename expects planning and applying to agree on what is a
+// collision. A hard link stands in for the other case of the name on a
+// case insensitive filesystem.
+func TestCaseOnlyRename(t *testing.T) {
+	root := t.TempDir()
+	touch(t, root, "heat.mkv", "other.mkv")
+	for _, name := range []string{"Heat.mkv", "linked.mkv"} {
+		if err := os.Link(filepath.Join(root, "heat.mkv"), filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testCases := map[string]struct {
+		to       string
+		expected bool
+	}{
+		"with another case":      {to: "Heat.mkv"},
+		"with another file":      {to: "other.mkv", expected: true},
+		"with another hard link": {to: "linked.mkv", expected: true},
+	}
+
+	for testName, testCase := range testCases {
+		move := Move{From: filepath.Join(root, "heat.mkv"), To: filepath.Join(root, testCase.to)}
+		planned := taken(move.To, move.From, map[string]bool{})
+		checked := (&Plan{Root: root, Moves: []Move{move}}).Check() != nil
+		if planned != testCase.expected || checked != testCase.expected {
+			t.Errorf("TestCaseOnlyRename(%s) = got (%v, %v) planning and checking, want (%v).", testName, planned, checked, testCase.expected)
+		}
+	}
+}
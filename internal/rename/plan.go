@@ -0,0 +1,148 @@
+package rename
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Collision policies, for a target that exists or that several files
+// would move to.
+const (
+	// Skip leaves the file where it is.
+	Skip = "skip"
+	// Suffix numbers the target, as in "Heat (1995) (2).mkv".
+	Suffix = "suffix"
+	// Fail refuses to build the plan.
+	Fail = "fail"
+)
+
+var ErrCollision = errors.New("rename target collides")
+
+type Options struct {
+	MovieTemplate   string
+	EpisodeTemplate string
+	// Collision is one of Skip, Suffix or Fail, Skip when empty.
+	Collision string
+}
+
+// Item is a file to rename with what its template is given.
+type Item struct {
+	Path   string
+	Fields Fields
+}
+
+type Move struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Skipped struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// Plan is the reviewed half of a rename. Targets are absolute, under
+// Root.
+type Plan struct {
+	Root    string    `json:"root"`
+	Moves   []Move    `json:"moves"`
+	Skipped []Skipped `json:"skipped,omitempty"`
+}
+
+// NewPlan renders the target of every item under root, without touching
+// the filesystem beyond looking at what is already there.
+func NewPlan(root string, items []Item, opts Options) (*Plan, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	collision := cmp.Or(opts.Collision, Skip)
+	if collision != Skip && collision != Suffix && collision != Fail {
+		return nil, fmt.Errorf("unknown collision policy %q", collision)
+	}
+
+	movie, episode, err := Templates(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Root: root}
+	claimed := map[string]bool{}
+	for _, item := range items {
+		from, err := filepath.Abs(item.Path)
+		if err != nil {
+			return nil, err
+		}
+		tmpl := movie
+		if item.Fields.IsEpisode() {
+			tmpl = episode
+		}
+		relative, err := Render(tmpl, item.Fields)
+		if err != nil {
+			plan.Skipped = append(plan.Skipped, Skipped{Path: from, Reason: err.Error()})
+			continue
+		}
+		to := filepath.Join(root, relative)
+		if to == from {
+			plan.Skipped = append(plan.Skipped, Skipped{Path: from, Reason: "already in place"})
+			continue
+		}
+
+		if taken(to, from, claimed) {
+			switch collision {
+			case Fail:
+				return nil, fmt.Errorf("%w: %s and %s", ErrCollision, from, to)
+			case Skip:
+				plan.Skipped = append(plan.Skipped, Skipped{Path: from, Reason: "target exists: " + to})
+				continue
+			case Suffix:
+				ext := filepath.Ext(to)
+				base := strings.TrimSuffix(to, ext)
+				for n := 2; taken(to, from, claimed); n++ {
+					to = fmt.Sprintf("%s (%d)%s", base, n, ext)
+				}
+			}
+		}
+		claimed[to] = true
+		plan.Moves = append(plan.Moves, Move{From: from, To: to})
+	}
+	return plan, nil
+}
+
+// taken reports whether another file of the plan, or of the disk, already
+// holds to.
+func taken(to, from string, claimed map[string]bool) bool {
+	return claimed[to] || occupied(to, from)
+}
+
+// occupied reports whether a file other than from is at to on the disk.
+// Moving onto itself under another case, as on case insensitive
+// filesystems, is not a collision.
+func occupied(to, from string) bool {
+	info, err := os.Lstat(to)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+	if self, serr := os.Lstat(from); err == nil && serr == nil && strings.EqualFold(to, from) && os.SameFile(info, self) {
+		return false
+	}
+	return true
+}
+
+// Templates parses the templates of opts, the defaults standing in for
+// empty ones, so errors can be reported before a library is scanned.
+func Templates(opts Options) (movie, episode *template.Template, err error) {
+	if movie, err = NewTemplate(cmp.Or(opts.MovieTemplate, DefaultMovieTemplate)); err != nil {
+		return nil, nil, fmt.Errorf("movie template: %w", err)
+	}
+	if episode, err = NewTemplate(cmp.Or(opts.EpisodeTemplate, DefaultEpisodeTemplate)); err != nil {
+		return nil, nil, fmt.Errorf("episode template: %w", err)
+	}
+	return movie, episode, nil
+}
@@ -0,0 +1,124 @@
+package rename
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// touch creates the files at paths under dir, with their directories.
+func touch(t *testing.T, dir string, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(path), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNewPlan(t *testing.T) {
+	heat := &models.ImdbapiTitle{ID: "tt0113277", PrimaryTitle: "Heat", StartYear: 1995}
+	opts := Options{MovieTemplate: "{{.PrimaryTitle}} ({{.StartYear}})"}
+	testCases := map[string]struct {
+		files     []string
+		items     []string
+		collision string
+		moves     map[string]string
+		skipped   []string
+		err       error
+	}{
+		"with a free target": {
+			files: []string{"heat.mkv"},
+			items: []string{"heat.mkv"},
+			moves: map[string]string{"heat.mkv": "Heat (1995).mkv"},
+		},
+		"with a file in place": {
+			files:   []string{"Heat (1995).mkv"},
+			items:   []string{"Heat (1995).mkv"},
+			skipped: []string{"Heat (1995).mkv"},
+		},
+		"with a taken target skipped": {
+			files:   []string{"heat.mkv", "Heat (1995).mkv"},
+			items:   []string{"heat.mkv"},
+			skipped: []string{"heat.mkv"},
+		},
+		"with two files on one target skipped": {
+			files:   []string{"a/heat.mkv", "b/heat.mkv"},
+			items:   []string{"a/heat.mkv", "b/heat.mkv"},
+			moves:   map[string]string{"a/heat.mkv": "Heat (1995).mkv"},
+			skipped: []string{"b/heat.mkv"},
+		},
+		"with taken targets suffixed": {
+			files:     []string{"a/heat.mkv", "b/heat.mkv", "c/heat.mkv", "Heat (1995).mkv"},
+			items:     []string{"a/heat.mkv", "b/heat.mkv", "c/heat.mkv"},
+			collision: Suffix,
+			moves: map[string]string{
+				"a/heat.mkv": "Heat (1995) (2).mkv",
+				"b/heat.mkv": "Heat (1995) (3).mkv",
+				"c/heat.mkv": "Heat (1995) (4).mkv",
+			},
+		},
+		"with a taken target failing": {
+			files:     []string{"a/heat.mkv", "b/heat.mkv"},
+			items:     []string{"a/heat.mkv", "b/heat.mkv"},
+			collision: Fail,
+			err:       ErrCollision,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		root := t.TempDir()
+		touch(t, root, testCase.files...)
+		var items []Item
+		for _, item := range testCase.items {
+			items = append(items, Item{Path: filepath.Join(root, item), Fields: Fields{ImdbapiTitle: heat, Ext: ".mkv"}})
+		}
+
+		opts.Collision = testCase.collision
+		plan, err := NewPlan(root, items, opts)
+		if !errors.Is(err, testCase.err) {
+			t.Errorf("TestNewPlan(%s) = got error (%v), want (%v).", testName, err, testCase.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		moves := map[string]string{}
+		for _, move := range plan.Moves {
+			from, _ := filepath.Rel(root, move.From)
+			to, _ := filepath.Rel(root, move.To)
+			moves[filepath.ToSlash(from)] = filepath.ToSlash(to)
+		}
+		if len(moves) != len(testCase.moves) {
+			t.Errorf("TestNewPlan(%s) = got moves (%v), want (%v).", testName, moves, testCase.moves)
+		}
+		for from, to := range testCase.moves {
+			if moves[from] != to {
+				t.Errorf("TestNewPlan(%s) = got %s moved to (%s), want (%s).", testName, from, moves[from], to)
+			}
+		}
+		if len(plan.Skipped) != len(testCase.skipped) {
+			t.Errorf("TestNewPlan(%s) = got skipped (%v), want (%v).", testName, plan.Skipped, testCase.skipped)
+			continue
+		}
+		for i, skipped := range testCase.skipped {
+			if plan.Skipped[i].Path != filepath.Join(root, skipped) {
+				t.Errorf("TestNewPlan(%s) = got skipped (%s), want (%s).", testName, plan.Skipped[i].Path, skipped)
+			}
+		}
+	}
+}
+
+func TestNewPlanUnknownCollision(t *testing.T) {
+	if _, err := NewPlan(t.TempDir(), nil, Options{Collision: "overwrite"}); err == nil {
+		t.Errorf("TestNewPlanUnknownCollision() = got no error, want one.")
+	}
+}
@@ -0,0 +1,129 @@
+// Package rename moves library files to paths built from templates over
+// their matched titles, in two steps: a plan that can be reviewed, then
+// an apply that records every move in a journal so it can be undone.
+package rename
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/foursixnine/imdblookup/internal/output"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+const (
+	DefaultMovieTemplate   = `{{.PrimaryTitle}}{{with .StartYear}} ({{.}}){{end}} [{{.ID}}]/{{.PrimaryTitle}}{{with .StartYear}} ({{.}}){{end}}`
+	DefaultEpisodeTemplate = `{{.PrimaryTitle}}{{with .StartYear}} ({{.}}){{end}} [{{.ID}}]/Season {{pad .Season}}/{{.PrimaryTitle}} S{{pad .Season}}E{{pad .Episode}}{{with .EpisodeTitle}} - {{.}}{{end}}`
+)
+
+// maxSegment is the longest file or directory name most filesystems take,
+// in bytes.
+const maxSegment = 255
+
+// Fields is what a template sees: the title's fields, plus where an
+// episode sits in its series. Ext, the original extension, is appended to
+// the rendered path and Name is the original file name without it.
+type Fields struct {
+	*models.ImdbapiTitle
+	Season       int
+	Episode      int
+	EpisodeTitle string
+	Ext          string
+	Name         string
+}
+
+// IsEpisode picks the episode template over the movie one.
+func (f Fields) IsEpisode() bool {
+	return f.Season > 0 || f.Episode > 0
+}
+
+// NewTemplate parses a rename template, which may use the --format helpers
+// and pad, zero padding a number to two digits. Slashes in the template
+// separate directories, slashes in the values do not.
+func NewTemplate(text string) (*template.Template, error) {
+	funcs := maps.Clone(output.Funcs)
+	funcs["pad"] = func(n int) string { return fmt.Sprintf("%02d", n) }
+	return template.New("rename").Funcs(funcs).Parse(text)
+}
+
+// Render executes tmpl and turns the result into a relative path of
+// sanitised segments, ending in the file's extension.
+func Render(tmpl *template.Template, fields Fields) (string, error) {
+	if fields.ImdbapiTitle != nil {
+		title := *fields.ImdbapiTitle
+		title.PrimaryTitle = hideSlashes(title.PrimaryTitle)
+		title.OriginalTitle = hideSlashes(title.OriginalTitle)
+		fields.ImdbapiTitle = &title
+	}
+	fields.EpisodeTitle = hideSlashes(fields.EpisodeTitle)
+	fields.Name = hideSlashes(fields.Name)
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, fields); err != nil {
+		return "", err
+	}
+
+	var segments []string
+	for segment := range strings.SplitSeq(rendered.String(), "/") {
+		if segment = Sanitize(strings.ReplaceAll(segment, valueSlash, "/")); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		return "", fmt.Errorf("template rendered an empty path for %s", fields.Name)
+	}
+
+	last := len(segments) - 1
+	segments[last] = truncate(segments[last], maxSegment-len(fields.Ext)) + fields.Ext
+	return filepath.Join(segments...), nil
+}
+
+// valueSlash stands in for the slashes of values while a template runs,
+// so "Face/Off" stays one segment.
+const valueSlash = "\x00"
+
+func hideSlashes(value string) string {
+	return strings.ReplaceAll(value, "/", valueSlash)
+}
+
+var (
+	reservedNames = map[string]bool{"CON": true, "PRN": true, "AUX": true, "NUL": true}
+	unsafeRunes   = strings.NewReplacer(":", " -", "/", "-", `\`, "-", "<", "", ">", "", `"`, "'", "|", "-", "?", "", "*", "")
+)
+
+// Sanitize makes one path segment safe on Linux, macOS and Windows alike:
+// no reserved characters or control codes, no trailing dots or spaces, no
+// device names, and at most 255 bytes.
+func Sanitize(segment string) string {
+	segment = unsafeRunes.Replace(segment)
+	segment = strings.Join(strings.Fields(segment), " ")
+	segment = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, segment)
+	segment = strings.TrimRight(strings.TrimLeft(segment, ". "), ". ")
+
+	base := strings.ToUpper(strings.SplitN(segment, ".", 2)[0])
+	if reservedNames[base] || (len(base) == 4 && (strings.HasPrefix(base, "COM") || strings.HasPrefix(base, "LPT")) && base[3] >= '1' && base[3] <= '9') {
+		segment = "_" + segment
+	}
+	return truncate(segment, maxSegment)
+}
+
+// truncate cuts s to at most n bytes without splitting a character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return strings.TrimRight(s[:n], ". ")
+}
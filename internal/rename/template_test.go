@@ -0,0 +1,108 @@
+package rename
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+func TestSanitize(t *testing.T) {
+	testCases := map[string]struct {
+		segment  string
+		expected string
+	}{
+		"with a plain name":          {segment: "Heat (1995)", expected: "Heat (1995)"},
+		"with a colon":               {segment: "Mission: Impossible", expected: "Mission - Impossible"},
+		"with reserved characters":   {segment: `What? <Why> "Now"*`, expected: "What Why 'Now'"},
+		"with a slash in the title":  {segment: "Face/Off", expected: "Face-Off"},
+		"with control characters":    {segment: "Tab\tand\x00nul", expected: "Tab andnul"},
+		"with trailing dots":         {segment: "Se7en...", expected: "Se7en"},
+		"with leading dots":          {segment: "..hidden", expected: "hidden"},
+		"with a device name":         {segment: "Con", expected: "_Con"},
+		"with a device name and ext": {segment: "com1.mkv", expected: "_com1.mkv"},
+		"with a longer name":         {segment: "Console", expected: "Console"},
+		"with only reserved":         {segment: "???", expected: ""},
+	}
+
+	for testName, testCase := range testCases {
+		if got := Sanitize(testCase.segment); got != testCase.expected {
+			t.Errorf("TestSanitize(%s) = got (%q), want (%q).", testName, got, testCase.expected)
+		}
+	}
+}
+
+func TestSanitizeLength(t *testing.T) {
+	got := Sanitize(strings.Repeat("é", 200))
+	if len(got) > maxSegment || !strings.HasPrefix(got, "é") || strings.ContainsRune(got, '�') {
+		t.Errorf("TestSanitizeLength() = got %d bytes (%q), want at most %d whole characters.", len(got), got, maxSegment)
+	}
+}
+
+func TestRender(t *testing.T) {
+	heat := &models.ImdbapiTitle{ID: "tt0113277", PrimaryTitle: "Heat", StartYear: 1995}
+	series := &models.ImdbapiTitle{ID: "tt0944947", PrimaryTitle: "Game of Thrones", StartYear: 2011}
+	testCases := map[string]struct {
+		template string
+		fields   Fields
+		expected string
+		err      bool
+	}{
+		"with the movie template": {
+			template: DefaultMovieTemplate,
+			fields:   Fields{ImdbapiTitle: heat, Ext: ".mkv"},
+			expected: "Heat (1995) [tt0113277]/Heat (1995).mkv",
+		},
+		"without a year": {
+			template: DefaultMovieTemplate,
+			fields:   Fields{ImdbapiTitle: &models.ImdbapiTitle{ID: "tt0000001", PrimaryTitle: "First"}, Ext: ".mkv"},
+			expected: "First [tt0000001]/First.mkv",
+		},
+		"with the episode template": {
+			template: DefaultEpisodeTemplate,
+			fields:   Fields{ImdbapiTitle: series, Season: 1, Episode: 9, EpisodeTitle: "Baelor", Ext: ".mkv"},
+			expected: "Game of Thrones (2011) [tt0944947]/Season 01/Game of Thrones S01E09 - Baelor.mkv",
+		},
+		"without an episode title": {
+			template: DefaultEpisodeTemplate,
+			fields:   Fields{ImdbapiTitle: series, Season: 2, Episode: 1, Ext: ".mkv"},
+			expected: "Game of Thrones (2011) [tt0944947]/Season 02/Game of Thrones S02E01.mkv",
+		},
+		"with a slash in a value": {
+			template: "{{.PrimaryTitle}}",
+			fields:   Fields{ImdbapiTitle: &models.ImdbapiTitle{PrimaryTitle: "Face/Off"}, Ext: ".avi"},
+			expected: "Face-Off.avi",
+		},
+		"with empty segments": {
+			template: "//{{.PrimaryTitle}}//{{.Name}}",
+			fields:   Fields{ImdbapiTitle: heat, Name: "heat.1995", Ext: ".mkv"},
+			expected: "Heat/heat.1995.mkv",
+		},
+		"with an empty result": {
+			template: "{{.EpisodeTitle}}",
+			fields:   Fields{ImdbapiTitle: heat, Name: "heat", Ext: ".mkv"},
+			err:      true,
+		},
+		"with a missing field": {
+			template: "{{.Nope}}",
+			fields:   Fields{ImdbapiTitle: heat},
+			err:      true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		tmpl, err := NewTemplate(testCase.template)
+		if err != nil {
+			t.Fatalf("TestRender(%s) = got (%v) parsing the template.", testName, err)
+		}
+		got, err := Render(tmpl, testCase.fields)
+		if (err != nil) != testCase.err {
+			t.Errorf("TestRender(%s) = got error (%v), want error (%v).", testName, err, testCase.err)
+			continue
+		}
+		if expected := filepath.FromSlash(testCase.expected); !testCase.err && got != expected {
+			t.Errorf("TestRender(%s) = got (%s), want (%s).", testName, got, expected)
+		}
+	}
+}
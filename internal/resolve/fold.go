@@ -0,0 +1,96 @@
+package resolve
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// articles are the leading articles titles are sorted without, in the
+// languages IMDb titles mostly come in. Single letter ones that are also
+// common words elsewhere, like the Italian "i", are left out.
+var articles = map[string]bool{
+	"the": true, "a": true, "an": true,
+	"le": true, "la": true, "les": true, "l": true, "un": true, "une": true,
+	"der": true, "die": true, "das": true, "den": true, "ein": true, "eine": true,
+	"el": true, "los": true, "las": true, "una": true,
+	"il": true, "lo": true, "gli": true,
+	"het": true, "een": true,
+}
+
+// ligatures are the letters that do not decompose into a base letter and
+// marks.
+var ligatures = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "ł", "l", "đ", "d", "ð", "d", "þ", "th", "ı", "i",
+	"&", " and ",
+)
+
+// Fold lowers title, strips its diacritics and punctuation and unifies
+// the widths of CJK forms, so "Amélie" and "AMELIE" fold alike. Words are
+// separated by single spaces.
+func Fold(title string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), title)
+	if err != nil {
+		folded = title
+	}
+	folded = ligatures.Replace(strings.ToLower(folded))
+	return strings.Join(strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// Key folds title and drops its article, leading as in "Das Boot" or
+// trailing as in "Matrix, The", so titles compare by what they are sorted
+// on.
+func Key(title string) string {
+	if i := strings.LastIndex(title, ","); i >= 0 && articles[Fold(title[i+1:])] {
+		title = title[:i]
+	}
+	folded := Fold(title)
+	if first, rest, ok := strings.Cut(folded, " "); ok && articles[first] {
+		return rest
+	}
+	return folded
+}
+
+// Distance is the Levenshtein distance between a and b, in characters.
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := range ra {
+		current[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			current[j+1] = min(previous[j+1]+1, current[j]+1, previous[j]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// Similarity is 1 less the edit distance of a and b over the length of
+// the longer one, 1 for equal strings and 0 when either is empty.
+func Similarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	longest := max(len([]rune(a)), len([]rune(b)))
+	return 1 - float64(Distance(a, b))/float64(longest)
+}
+
+// nameSimilarity compares two titles folded, and without their articles,
+// keeping the better of the two so an article that is part of a name, as
+// in "L.A. Confidential", costs little.
+func nameSimilarity(a, b string) float64 {
+	return max(Similarity(Fold(a), Fold(b)), Similarity(Key(a), Key(b)))
+}
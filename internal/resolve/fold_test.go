@@ -0,0 +1,75 @@
+package resolve
+
+import "testing"
+
+func TestFold(t *testing.T) {
+	testCases := map[string]struct {
+		title    string
+		expected string
+	}{
+		"with diacritics":        {title: "Amélie", expected: "amelie"},
+		"with punctuation":       {title: "Spider-Man: No Way Home", expected: "spider man no way home"},
+		"with a sharp s":         {title: "Die Blechtrommel – Straße", expected: "die blechtrommel strasse"},
+		"with ligatures":         {title: "Œdipe Æon Ødegaard", expected: "oedipe aeon odegaard"},
+		"with an ampersand":      {title: "Fast & Furious", expected: "fast and furious"},
+		"with full width forms":  {title: "ＡＫＩＲＡ", expected: "akira"},
+		"with japanese":          {title: "千と千尋の神隠し", expected: "千と千尋の神隠し"},
+		"with decomposed kana":   {title: "ガ", expected: "カ"},
+		"with only punctuation":  {title: "?!", expected: ""},
+		"with repeated spaces":   {title: "  Das   Boot ", expected: "das boot"},
+		"with an apostrophe":     {title: "L'Avventura", expected: "l avventura"},
+		"with a turkish dotless": {title: "Kış Uykusu", expected: "kis uykusu"},
+	}
+
+	for testName, testCase := range testCases {
+		if got := Fold(testCase.title); got != testCase.expected {
+			t.Errorf("TestFold(%s) = got (%q), want (%q).", testName, got, testCase.expected)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	testCases := map[string]struct {
+		title    string
+		expected string
+	}{
+		"with an english article":  {title: "The Matrix", expected: "matrix"},
+		"with a german article":    {title: "Das Boot", expected: "boot"},
+		"with a french article":    {title: "Les Misérables", expected: "miserables"},
+		"with an elided article":   {title: "L'Avventura", expected: "avventura"},
+		"with a trailing article":  {title: "Matrix, The", expected: "matrix"},
+		"with a comma in the name": {title: "Good Night, and Good Luck", expected: "good night and good luck"},
+		"with only an article":     {title: "The", expected: "the"},
+		"without an article":       {title: "Heat", expected: "heat"},
+	}
+
+	for testName, testCase := range testCases {
+		if got := Key(testCase.title); got != testCase.expected {
+			t.Errorf("TestKey(%s) = got (%q), want (%q).", testName, got, testCase.expected)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	testCases := map[string]struct {
+		a, b     string
+		expected int
+	}{
+		"with equal strings":   {a: "heat", b: "heat", expected: 0},
+		"with an empty string": {a: "", b: "heat", expected: 4},
+		"with a substitution":  {a: "kitten", b: "sitten", expected: 1},
+		"with several edits":   {a: "kitten", b: "sitting", expected: 3},
+		"with multibyte runes": {a: "千と千尋", b: "千と千", expected: 1},
+		"with swapped letters": {a: "ab", b: "ba", expected: 2},
+		"with a longer second": {a: "boot", b: "das boot", expected: 4},
+	}
+
+	for testName, testCase := range testCases {
+		if got := Distance(testCase.a, testCase.b); got != testCase.expected {
+			t.Errorf("TestDistance(%s) = got (%d), want (%d).", testName, got, testCase.expected)
+		}
+		if got := Distance(testCase.b, testCase.a); got != testCase.expected {
+			t.Errorf("TestDistance(%s) reversed = got (%d), want (%d).", testName, got, testCase.expected)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Package resolve maps a title as someone would type it, in any language
+// and spelling, to IMDb titles ranked by how likely they are meant. The
+// search endpoint is asked for candidates, which are then scored on edit
+// distance against their names and AKAs, on year proximity, and on where
+// the name is used when the query says.
+package resolve
+
+import (
+	"cmp"
+	"context"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// Source looks up candidate titles and their AKAs.
+type Source interface {
+	// Search finds titles by name.
+	Search(ctx context.Context, query string) ([]*models.ImdbapiTitle, error)
+	// AKAs lists the other names a title is known by.
+	AKAs(ctx context.Context, titleID string) ([]*models.ImdbapiAKA, error)
+}
+
+// Query is a title to resolve. Year is optional, 0 when unknown. Country,
+// an ISO 3166-1 code such as "DE", and Language, an ISO 639-3 one such as
+// "deu", are optional too and hint at where the title goes by that name,
+// so one of its regional AKAs can outrank its original title.
+type Query struct {
+	Title    string
+	Year     int
+	Country  string
+	Language string
+}
+
+// regional reports whether a name used in countries and languages is
+// used where the query hints at.
+func (q Query) regional(countries []*models.ImdbapiCountry, languages []*models.ImdbapiLanguage) bool {
+	for _, country := range countries {
+		if q.Country != "" && country != nil && strings.EqualFold(country.Code, q.Country) {
+			return true
+		}
+	}
+	for _, language := range languages {
+		if q.Language != "" && language != nil && strings.EqualFold(language.Code, q.Language) {
+			return true
+		}
+	}
+	return false
+}
+
+type Options struct {
+	// Lookups is how many of the best candidates have their AKAs looked
+	// up, DefaultLookups when 0.
+	Lookups int
+	// Limit caps how many candidates Resolve returns, all of them when 0.
+	Limit int
+}
+
+const DefaultLookups = 5
+
+// Candidate is a title scored against a query. Name is the name of the
+// title that fit the query best, with AKA set when it was one of them.
+type Candidate struct {
+	Title      *models.ImdbapiTitle `json:"title"`
+	Confidence float64              `json:"confidence"`
+	Name       string               `json:"name"`
+	AKA        *models.ImdbapiAKA   `json:"aka,omitempty"`
+}
+
+type Resolver struct {
+	source Source
+	opts   Options
+}
+
+func NewResolver(source Source, opts Options) *Resolver {
+	opts.Lookups = cmp.Or(opts.Lookups, DefaultLookups)
+	return &Resolver{source: source, opts: opts}
+}
+
+// Resolve returns the candidates for query, best first. The server is
+// searched for the title as given and once more folded and without its
+// article, as its search does not see "Amelie" in "Amélie". Only the
+// search for the title as given has to succeed, the others just add
+// candidates. A title whose AKAs cannot be looked up is scored on its own
+// names.
+func (r *Resolver) Resolve(ctx context.Context, query Query) ([]Candidate, error) {
+	var candidates []Candidate
+	seen := map[string]bool{}
+	for i, search := range searches(query.Title) {
+		titles, err := r.source.Search(ctx, search)
+		if err != nil && i == 0 {
+			return nil, err
+		}
+		if err != nil {
+			log.Printf("Resolving %q, searching for %q failed: %v\n", query.Title, search, err)
+			continue
+		}
+		for _, title := range titles {
+			if title == nil || seen[title.ID] {
+				continue
+			}
+			seen[title.ID] = true
+			candidates = append(candidates, Score(query, title, nil))
+		}
+	}
+	sortCandidates(candidates)
+
+	for i := range candidates[:min(r.opts.Lookups, len(candidates))] {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		akas, err := r.source.AKAs(ctx, candidates[i].Title.ID)
+		if err != nil || len(akas) == 0 {
+			continue
+		}
+		candidates[i] = Score(query, candidates[i].Title, akas)
+	}
+	sortCandidates(candidates)
+
+	if r.opts.Limit > 0 && len(candidates) > r.opts.Limit {
+		candidates = candidates[:r.opts.Limit]
+	}
+	return candidates, nil
+}
+
+// searches lists the distinct queries to send for title.
+func searches(title string) []string {
+	queries := []string{strings.TrimSpace(title)}
+	for _, query := range []string{Fold(title), Key(title)} {
+		if query != "" && !slices.ContainsFunc(queries, func(q string) bool { return strings.EqualFold(q, query) }) {
+			queries = append(queries, query)
+		}
+	}
+	return queries
+}
+
+func sortCandidates(candidates []Candidate) {
+	slices.SortStableFunc(candidates, func(a, b Candidate) int { return cmp.Compare(b.Confidence, a.Confidence) })
+}
+
+// Score rates how well title, known by akas too, fits query: mostly on its
+// closest name, then on how near its start year is to the one asked for.
+// An unknown year counts half. When the query hints at a country or a
+// language, a name used there scores a little more, the original title
+// being used in the countries and languages the title comes in.
+func Score(query Query, title *models.ImdbapiTitle, akas []*models.ImdbapiAKA) Candidate {
+	nameWeight, regionWeight := 0.8, 0.0
+	if query.Country != "" || query.Language != "" {
+		nameWeight, regionWeight = 0.7, 0.1
+	}
+
+	candidate := Candidate{Title: title}
+	name := -1.0
+	consider := func(text string, aka *models.ImdbapiAKA, regional bool) {
+		if text == "" {
+			return
+		}
+		fit := nameWeight * nameSimilarity(query.Title, text)
+		if regional {
+			fit += regionWeight
+		}
+		if fit > name {
+			name, candidate.Name, candidate.AKA = fit, text, aka
+		}
+	}
+	consider(title.PrimaryTitle, nil, false)
+	consider(title.OriginalTitle, nil, query.regional(title.OriginCountries, title.SpokenLanguages))
+	for _, aka := range akas {
+		if aka != nil {
+			consider(aka.Text, aka, query.regional([]*models.ImdbapiCountry{aka.Country}, []*models.ImdbapiLanguage{aka.Language}))
+		}
+	}
+
+	year := 0.5
+	if query.Year != 0 && title.StartYear != 0 {
+		// Release years differ across countries, so being a year or two
+		// off is not ruled out.
+		year = max(0, 1-0.25*float64(abs(query.Year-int(title.StartYear))))
+	}
+
+	candidate.Confidence = round(max(name, 0) + 0.2*year)
+	return candidate
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func round(f float64) float64 {
+	return float64(int(f*1000+0.5)) / 1000
+}
@@ -0,0 +1,168 @@
+package resolve
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// fakeSource finds titles one of whose names contains the query, the way
+// the search endpoint does, and serves the AKAs it is given. Searching for
+// "broken", or for failing, fails.
+type fakeSource struct {
+	titles   []*models.ImdbapiTitle
+	akas     map[string][]*models.ImdbapiAKA
+	failing  string
+	searches []string
+	lookups  []string
+}
+
+func (s *fakeSource) Search(ctx context.Context, query string) ([]*models.ImdbapiTitle, error) {
+	s.searches = append(s.searches, query)
+	if query == "broken" || query == s.failing {
+		return nil, errors.New("search failed")
+	}
+	var found []*models.ImdbapiTitle
+	for _, title := range s.titles {
+		names := []string{title.PrimaryTitle, title.OriginalTitle}
+		for _, aka := range s.akas[title.ID] {
+			names = append(names, aka.Text)
+		}
+		for _, name := range names {
+			if strings.Contains(strings.ToLower(name), strings.ToLower(query)) {
+				found = append(found, title)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+func (s *fakeSource) AKAs(ctx context.Context, titleID string) ([]*models.ImdbapiAKA, error) {
+	s.lookups = append(s.lookups, titleID)
+	akas, ok := s.akas[titleID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return akas, nil
+}
+
+func aka(text, country string) *models.ImdbapiAKA {
+	return &models.ImdbapiAKA{Text: text, Country: &models.ImdbapiCountry{Code: country}}
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		titles: []*models.ImdbapiTitle{
+			{ID: "tt0097000", PrimaryTitle: "The Boat", StartYear: 1989},
+			{ID: "tt0082096", PrimaryTitle: "Das Boot", OriginalTitle: "Das Boot", StartYear: 1981, OriginCountries: []*models.ImdbapiCountry{{Code: "DE"}}},
+			{ID: "tt0245429", PrimaryTitle: "Spirited Away", OriginalTitle: "Sen to Chihiro no kamikakushi", StartYear: 2001},
+			{ID: "tt0211915", PrimaryTitle: "Amélie", OriginalTitle: "Le fabuleux destin d'Amélie Poulain", StartYear: 2001},
+			{ID: "tt0110413", PrimaryTitle: "Leon: The Professional", StartYear: 1994},
+			{ID: "tt0113277", PrimaryTitle: "Heat", StartYear: 1995},
+			{ID: "tt0100000", PrimaryTitle: "Heat", StartYear: 1986},
+		},
+		akas: map[string][]*models.ImdbapiAKA{
+			"tt0082096": {aka("The Boat", "US")},
+			"tt0245429": {aka("千と千尋の神隠し", "JP"), aka("Chihiros Reise ins Zauberland", "DE")},
+			"tt0211915": {aka("Die fabelhafte Welt der Amélie", "DE")},
+		},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	testCases := map[string]struct {
+		query    Query
+		expected string
+		aka      string
+	}{
+		"with the primary title":          {query: Query{Title: "Spirited Away"}, expected: "tt0245429"},
+		"with diacritics the title lacks": {query: Query{Title: "Léon"}, expected: "tt0110413"},
+		"with a trailing article":         {query: Query{Title: "Boot, Das"}, expected: "tt0082096"},
+		"with the year picking a remake":  {query: Query{Title: "Heat", Year: 1995}, expected: "tt0113277"},
+		"with the year off by one":        {query: Query{Title: "Heat", Year: 1987}, expected: "tt0100000"},
+		"with the year choosing an aka":   {query: Query{Title: "The Boat", Year: 1981}, expected: "tt0082096", aka: "The Boat"},
+		"without a hint":                  {query: Query{Title: "The Boat"}, expected: "tt0097000"},
+		"with a country choosing an aka":  {query: Query{Title: "The Boat", Country: "us"}, expected: "tt0082096", aka: "The Boat"},
+		"with the country of origin":      {query: Query{Title: "Boot", Country: "DE"}, expected: "tt0082096"},
+	}
+
+	for testName, testCase := range testCases {
+		resolver := NewResolver(newFakeSource(), Options{})
+		candidates, err := resolver.Resolve(context.Background(), testCase.query)
+		if err != nil || len(candidates) == 0 {
+			t.Errorf("TestResolve(%s) = got (%v, %v), want candidates.", testName, candidates, err)
+			continue
+		}
+		best := candidates[0]
+		if best.Title.ID != testCase.expected {
+			t.Errorf("TestResolve(%s) = got (%s at %.3f), want (%s).", testName, best.Title.ID, best.Confidence, testCase.expected)
+		}
+		if testCase.aka != "" && (best.AKA == nil || best.AKA.Text != testCase.aka) {
+			t.Errorf("TestResolve(%s) = got AKA (%v), want (%s).", testName, best.AKA, testCase.aka)
+		}
+	}
+}
+
+func TestResolveSearches(t *testing.T) {
+	source := newFakeSource()
+	if _, err := NewResolver(source, Options{}).Resolve(context.Background(), Query{Title: "The Amélie"}); err != nil {
+		t.Fatalf("TestResolveSearches() = got (%v), want no error.", err)
+	}
+	expected := []string{"The Amélie", "the amelie", "amelie"}
+	if strings.Join(source.searches, "|") != strings.Join(expected, "|") {
+		t.Errorf("TestResolveSearches() = got (%q), want (%q).", source.searches, expected)
+	}
+
+	if _, err := NewResolver(source, Options{}).Resolve(context.Background(), Query{Title: "broken"}); err == nil {
+		t.Errorf("TestResolveSearches() = got no error for a failed search, want one.")
+	}
+	source.failing = "amelie poulain"
+	candidates, err := NewResolver(source, Options{}).Resolve(context.Background(), Query{Title: "Amélie Poulain"})
+	if err != nil || len(candidates) != 1 || candidates[0].Title.ID != "tt0211915" {
+		t.Errorf("TestResolveSearches() = got (%v, %v) when only the folded search fails, want the candidates of the first.", candidates, err)
+	}
+}
+
+func TestResolveLookups(t *testing.T) {
+	source := newFakeSource()
+	candidates, err := NewResolver(source, Options{Lookups: 1, Limit: 1}).Resolve(context.Background(), Query{Title: "Heat", Year: 1995})
+	if err != nil {
+		t.Fatalf("TestResolveLookups() = got (%v), want no error.", err)
+	}
+	if len(candidates) != 1 || candidates[0].Title.ID != "tt0113277" || candidates[0].AKA != nil {
+		t.Errorf("TestResolveLookups() = got (%v), want tt0113277 alone, on its own name.", candidates)
+	}
+	if len(source.lookups) != 1 || source.lookups[0] != "tt0113277" {
+		t.Errorf("TestResolveLookups() = got lookups (%v), want the best candidate only.", source.lookups)
+	}
+}
+
+func TestScore(t *testing.T) {
+	spirited := newFakeSource().titles[2]
+	akas := newFakeSource().akas[spirited.ID]
+	testCases := map[string]struct {
+		query    Query
+		akas     []*models.ImdbapiAKA
+		expected float64
+		name     string
+	}{
+		"with the japanese aka":    {query: Query{Title: "千と千尋の神隠し", Year: 2001}, akas: akas, expected: 1, name: "千と千尋の神隠し"},
+		"with the german aka":      {query: Query{Title: "Chihiros Reise ins Zauberland"}, akas: akas, expected: 0.9, name: "Chihiros Reise ins Zauberland"},
+		"without its akas":         {query: Query{Title: "千と千尋の神隠し", Year: 2001}, expected: 0.2, name: "Spirited Away"},
+		"with the original title":  {query: Query{Title: "Sen to Chihiro no Kamikakushi", Year: 2001}, expected: 1, name: "Sen to Chihiro no kamikakushi"},
+		"with a far year":          {query: Query{Title: "Spirited Away", Year: 2011}, expected: 0.8, name: "Spirited Away"},
+		"with a language hint":     {query: Query{Title: "Spirited Away", Language: "eng"}, akas: []*models.ImdbapiAKA{{Text: "Spirited Away", Language: &models.ImdbapiLanguage{Code: "eng"}}}, expected: 0.9, name: "Spirited Away"},
+		"with a country elsewhere": {query: Query{Title: "Spirited Away", Country: "FR"}, akas: akas, expected: 0.8, name: "Spirited Away"},
+	}
+
+	for testName, testCase := range testCases {
+		candidate := Score(testCase.query, spirited, testCase.akas)
+		if candidate.Confidence != testCase.expected || candidate.Name != testCase.name {
+			t.Errorf("TestScore(%s) = got (%.3f, %s), want (%.3f, %s).", testName, candidate.Confidence, candidate.Name, testCase.expected, testCase.name)
+		}
+	}
+}
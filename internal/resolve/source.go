@@ -0,0 +1,39 @@
+package resolve
+
+import (
+	"context"
+
+	"github.com/foursixnine/imdblookup/internal/client"
+	"github.com/foursixnine/imdblookup/internal/ids"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// searchLimit is how many titles a search asks for, more than the usual
+// five as the best fit is often not the most popular one.
+const searchLimit = 20
+
+type clientSource struct {
+	client *client.ImdbClient
+}
+
+// NewClientSource looks titles up through the search endpoint and their
+// AKAs through /titles/{id}/akas.
+func NewClientSource(imdbClient *client.ImdbClient) Source {
+	return &clientSource{client: imdbClient}
+}
+
+func (s *clientSource) Search(ctx context.Context, query string) ([]*models.ImdbapiTitle, error) {
+	titles, err := s.client.SearchTitles(ctx, query, searchLimit)
+	if err != nil {
+		return nil, err
+	}
+	return titles, nil
+}
+
+func (s *clientSource) AKAs(ctx context.Context, titleID string) ([]*models.ImdbapiAKA, error) {
+	akas, err := s.client.TitleAKAs(ctx, ids.TitleID(titleID))
+	if err != nil {
+		return nil, err
+	}
+	return akas, nil
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindTitle    Kind = "title"
+	KindName     Kind = "name"
+	KindCredits  Kind = "credits"
+	KindEpisodes Kind = "episodes"
+)
+
+// Record points at something sync can fetch again: a title, a name, the
+// credits of a title or the episodes of a series.
+type Record struct {
+	Kind      Kind      `json:"kind"`
+	ID        string    `json:"id"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+var staleQueries = []struct {
+	kind  Kind
+	query string
+}{
+	{KindTitle, `SELECT id, fetched_at FROM titles WHERE fetched_at < ? ORDER BY fetched_at`},
+	{KindName, `SELECT id, fetched_at FROM names WHERE fetched_at IS NOT NULL AND fetched_at < ? ORDER BY fetched_at`},
+	// Credits and episodes are only refreshed for the listings saved whole,
+	// a few credits seen through filmographies not making one.
+	{KindCredits, `SELECT id, fetched_at FROM listings WHERE kind = 'credits' AND fetched_at < ? ORDER BY fetched_at`},
+	{KindEpisodes, `SELECT id, fetched_at FROM listings WHERE kind = 'episodes' AND fetched_at < ? ORDER BY fetched_at`},
+}
+
+// Stale lists the records last fetched more than olderThan ago, oldest
+// first within each kind.
+func (s *Store) Stale(ctx context.Context, olderThan time.Duration) ([]Record, error) {
+	cutoff := s.now().Add(-olderThan).Unix()
+
+	var records []Record
+	for _, q := range staleQueries {
+		rows, err := s.db.QueryContext(ctx, q.query, cutoff)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var record Record
+			var fetchedAt int64
+			if err := rows.Scan(&record.ID, &fetchedAt); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			record.Kind, record.FetchedAt = q.kind, time.Unix(fetchedAt, 0)
+			records = append(records, record)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// Prune drops the credits or episodes of record that were not saved again
+// since before, once they have all been fetched anew.
+func (s *Store) Prune(ctx context.Context, record Record, before time.Time) error {
+	var query string
+	switch record.Kind {
+	case KindCredits:
+		query = `DELETE FROM credits WHERE title_id = ? AND fetched_at < ?`
+	case KindEpisodes:
+		query = `DELETE FROM episodes WHERE series_id = ? AND fetched_at < ?`
+	default:
+		return fmt.Errorf("cannot prune %s records", record.Kind)
+	}
+	_, err := s.db.ExecContext(ctx, query, record.ID, before.Unix())
+	return err
+}
@@ -0,0 +1,486 @@
+// Package store mirrors fetched titles, names, credits and episodes into a
+// local SQLite database.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/foursixnine/imdblookup/models"
+
+	_ "modernc.org/sqlite"
+)
+
+// Every record keeps its full api JSON in data, the other columns and the
+// relation tables are there to query it. Names only seen through a credit
+// or a title are stubs with a NULL fetched_at until fetched themselves.
+// Credits and episodes also arrive a few at a time, through filmographies
+// or limited listings, so listings records when all the credits of a title
+// or episodes of a series were saved together, and position keeps the
+// order of the credits and seasons listings. Seasons are saved alongside
+// the episodes of their series, so seasons without episodes are kept.
+const schema = `
+CREATE TABLE IF NOT EXISTS titles (
+	id              TEXT PRIMARY KEY,
+	type            TEXT NOT NULL,
+	primary_title   TEXT NOT NULL,
+	original_title  TEXT NOT NULL,
+	start_year      INTEGER,
+	end_year        INTEGER,
+	runtime_seconds INTEGER,
+	rating          REAL,
+	vote_count      INTEGER,
+	data            TEXT NOT NULL,
+	fetched_at      INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS title_genres (
+	title_id TEXT NOT NULL REFERENCES titles(id) ON DELETE CASCADE,
+	genre    TEXT NOT NULL,
+	PRIMARY KEY (title_id, genre)
+);
+CREATE TABLE IF NOT EXISTS names (
+	id           TEXT PRIMARY KEY,
+	display_name TEXT NOT NULL,
+	birth_year   INTEGER,
+	death_year   INTEGER,
+	data         TEXT,
+	fetched_at   INTEGER
+);
+CREATE TABLE IF NOT EXISTS name_professions (
+	name_id    TEXT NOT NULL REFERENCES names(id) ON DELETE CASCADE,
+	profession TEXT NOT NULL,
+	PRIMARY KEY (name_id, profession)
+);
+CREATE TABLE IF NOT EXISTS title_people (
+	title_id TEXT NOT NULL REFERENCES titles(id) ON DELETE CASCADE,
+	name_id  TEXT NOT NULL REFERENCES names(id),
+	role     TEXT NOT NULL,
+	PRIMARY KEY (title_id, name_id, role)
+);
+CREATE TABLE IF NOT EXISTS credits (
+	title_id      TEXT NOT NULL,
+	name_id       TEXT NOT NULL REFERENCES names(id),
+	category      TEXT NOT NULL,
+	characters    TEXT NOT NULL,
+	episode_count INTEGER,
+	position      INTEGER,
+	data          TEXT NOT NULL,
+	fetched_at    INTEGER NOT NULL,
+	PRIMARY KEY (title_id, name_id, category)
+);
+CREATE INDEX IF NOT EXISTS credits_name ON credits(name_id);
+CREATE TABLE IF NOT EXISTS episodes (
+	id             TEXT PRIMARY KEY,
+	series_id      TEXT NOT NULL,
+	season         TEXT NOT NULL,
+	episode_number INTEGER,
+	title          TEXT NOT NULL,
+	rating         REAL,
+	data           TEXT NOT NULL,
+	fetched_at     INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS episodes_series ON episodes(series_id, season, episode_number);
+CREATE TABLE IF NOT EXISTS seasons (
+	series_id     TEXT NOT NULL,
+	season        TEXT NOT NULL,
+	episode_count INTEGER,
+	position      INTEGER NOT NULL,
+	PRIMARY KEY (series_id, season)
+);
+CREATE TABLE IF NOT EXISTS listings (
+	kind       TEXT NOT NULL,
+	id         TEXT NOT NULL,
+	fetched_at INTEGER NOT NULL,
+	PRIMARY KEY (kind, id)
+);
+`
+
+// Store is safe for concurrent use.
+type Store struct {
+	db *sql.DB
+	// MaxAge is how long a record is served before it counts as stale,
+	// zero meaning forever.
+	MaxAge time.Duration
+	now    func() time.Time
+}
+
+// DefaultPath is imdblookup.db next to the response cache.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "imdblookup", "imdblookup.db"), nil
+}
+
+func Open(path string, maxAge time.Duration) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating schema in %s: %w", path, err)
+	}
+	return &Store{db: db, MaxAge: maxAge, now: time.Now}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// fresh is the oldest fetched_at still served.
+func (s *Store) fresh() int64 {
+	if s.MaxAge <= 0 {
+		return 0
+	}
+	return s.now().Add(-s.MaxAge).Unix()
+}
+
+// Title is the stored title, nil when missing or stale.
+func (s *Store) Title(ctx context.Context, id string) (*models.ImdbapiTitle, error) {
+	return load[models.ImdbapiTitle](ctx, s.db, `SELECT data FROM titles WHERE id = ? AND fetched_at >= ?`, id, s.fresh())
+}
+
+// Name is the stored name, nil when missing, stale or only known as a stub.
+func (s *Store) Name(ctx context.Context, id string) (*models.ImdbapiName, error) {
+	return load[models.ImdbapiName](ctx, s.db, `SELECT data FROM names WHERE id = ? AND fetched_at >= ?`, id, s.fresh())
+}
+
+func load[T any](ctx context.Context, db *sql.DB, query string, args ...any) (*T, error) {
+	var data []byte
+	err := db.QueryRowContext(ctx, query, args...).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record T
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *Store) SaveTitle(ctx context.Context, title *models.ImdbapiTitle) error {
+	data, err := json.Marshal(title)
+	if err != nil {
+		return err
+	}
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var rating, votes any
+		if title.Rating != nil {
+			rating, votes = title.Rating.AggregateRating, title.Rating.VoteCount
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO titles (id, type, primary_title, original_title, start_year, end_year, runtime_seconds, rating, vote_count, data, fetched_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET type = excluded.type, primary_title = excluded.primary_title, original_title = excluded.original_title,
+				start_year = excluded.start_year, end_year = excluded.end_year, runtime_seconds = excluded.runtime_seconds,
+				rating = excluded.rating, vote_count = excluded.vote_count, data = excluded.data, fetched_at = excluded.fetched_at`,
+			title.ID, title.Type, title.PrimaryTitle, title.OriginalTitle, title.StartYear, title.EndYear, title.RuntimeSeconds, rating, votes, data, s.now().Unix()); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM title_genres WHERE title_id = ?`, title.ID); err != nil {
+			return err
+		}
+		for _, genre := range title.Genres {
+			if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO title_genres (title_id, genre) VALUES (?, ?)`, title.ID, genre); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM title_people WHERE title_id = ?`, title.ID); err != nil {
+			return err
+		}
+		for role, people := range map[string][]*models.ImdbapiName{"director": title.Directors, "writer": title.Writers, "star": title.Stars} {
+			for _, name := range people {
+				if name == nil || name.ID == "" {
+					continue
+				}
+				if err := stubName(ctx, tx, name); err != nil {
+					return err
+				}
+				if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO title_people (title_id, name_id, role) VALUES (?, ?, ?)`, title.ID, name.ID, role); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Store) SaveName(ctx context.Context, name *models.ImdbapiName) error {
+	data, err := json.Marshal(name)
+	if err != nil {
+		return err
+	}
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var born, died any
+		if name.BirthDate != nil {
+			born = name.BirthDate.Year
+		}
+		if name.DeathDate != nil {
+			died = name.DeathDate.Year
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO names (id, display_name, birth_year, death_year, data, fetched_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET display_name = excluded.display_name, birth_year = excluded.birth_year,
+				death_year = excluded.death_year, data = excluded.data, fetched_at = excluded.fetched_at`,
+			name.ID, name.DisplayName, born, died, data, s.now().Unix()); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM name_professions WHERE name_id = ?`, name.ID); err != nil {
+			return err
+		}
+		for _, profession := range name.PrimaryProfessions {
+			if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO name_professions (name_id, profession) VALUES (?, ?)`, name.ID, profession); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SaveCredit records that nameID is credited in titleID, the ids being
+// given separately as the credits of a title leave out the title and a
+// filmography leaves out the name.
+func (s *Store) SaveCredit(ctx context.Context, titleID, nameID string, credit *models.ImdbapiCredit) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		return s.saveCredit(ctx, tx, titleID, nameID, credit, nil)
+	})
+}
+
+// SaveCredits saves the complete credits listing of titleID, which Credits
+// then answers with.
+func (s *Store) SaveCredits(ctx context.Context, titleID string, credits []*models.ImdbapiCredit) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		for i, credit := range credits {
+			if credit == nil || credit.Name == nil {
+				continue
+			}
+			if err := s.saveCredit(ctx, tx, titleID, credit.Name.ID, credit, i); err != nil {
+				return err
+			}
+		}
+		return s.saveListing(ctx, tx, KindCredits, titleID)
+	})
+}
+
+// saveCredit keeps the position a credit had in the listing of its title
+// when saved again without one, as from a filmography.
+func (s *Store) saveCredit(ctx context.Context, tx *sql.Tx, titleID, nameID string, credit *models.ImdbapiCredit, position any) error {
+	characters, err := json.Marshal(credit.Characters)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(credit)
+	if err != nil {
+		return err
+	}
+
+	name := credit.Name
+	if name == nil || name.ID != nameID {
+		name = &models.ImdbapiName{ID: nameID}
+	}
+	if err := stubName(ctx, tx, name); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO credits (title_id, name_id, category, characters, episode_count, position, data, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (title_id, name_id, category) DO UPDATE SET characters = excluded.characters,
+			episode_count = excluded.episode_count, position = COALESCE(excluded.position, credits.position),
+			data = excluded.data, fetched_at = excluded.fetched_at`,
+		titleID, nameID, credit.Category, characters, credit.EpisodeCount, position, data, s.now().Unix())
+	return err
+}
+
+// SaveEpisodes saves the complete episode listing of seriesID, which
+// Episodes then answers with.
+func (s *Store) SaveEpisodes(ctx context.Context, seriesID string, episodes []*models.ImdbapiEpisode) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, episode := range episodes {
+			if episode == nil {
+				continue
+			}
+			data, err := json.Marshal(episode)
+			if err != nil {
+				return err
+			}
+			var rating any
+			if episode.Rating != nil {
+				rating = episode.Rating.AggregateRating
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO episodes (id, series_id, season, episode_number, title, rating, data, fetched_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (id) DO UPDATE SET series_id = excluded.series_id, season = excluded.season, episode_number = excluded.episode_number,
+					title = excluded.title, rating = excluded.rating, data = excluded.data, fetched_at = excluded.fetched_at`,
+				episode.ID, seriesID, episode.Season, episode.EpisodeNumber, episode.Title, rating, data, s.now().Unix()); err != nil {
+				return err
+			}
+		}
+		return s.saveListing(ctx, tx, KindEpisodes, seriesID)
+	})
+}
+
+// SaveSeasons replaces the seasons stored for seriesID, which Seasons
+// answers with as long as the episode listing of the series is fresh.
+func (s *Store) SaveSeasons(ctx context.Context, seriesID string, seasons []*models.ImdbapiSeason) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM seasons WHERE series_id = ?`, seriesID); err != nil {
+			return err
+		}
+		for position, season := range seasons {
+			if season == nil || season.Season == "" {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO seasons (series_id, season, episode_count, position) VALUES (?, ?, ?, ?)
+				ON CONFLICT (series_id, season) DO NOTHING`,
+				seriesID, season.Season, season.EpisodeCount, position); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Store) saveListing(ctx context.Context, tx *sql.Tx, kind Kind, id string) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO listings (kind, id, fetched_at) VALUES (?, ?, ?)
+		ON CONFLICT (kind, id) DO UPDATE SET fetched_at = excluded.fetched_at`, kind, id, s.now().Unix())
+	return err
+}
+
+// listed reports whether the listing of id was saved whole and is not
+// stale.
+func (s *Store) listed(ctx context.Context, kind Kind, id string) (bool, error) {
+	var fetchedAt int64
+	err := s.db.QueryRowContext(ctx, `SELECT fetched_at FROM listings WHERE kind = ? AND id = ? AND fetched_at >= ?`, kind, id, s.fresh()).Scan(&fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Credits are the stored credits of a title in the order of its listing,
+// nil unless the listing was saved whole and is not stale. Credits only
+// known from a filmography come last, carrying the name as a stub.
+func (s *Store) Credits(ctx context.Context, titleID string) ([]*models.ImdbapiCredit, error) {
+	if ok, err := s.listed(ctx, KindCredits, titleID); !ok {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT credits.data, names.id, names.display_name FROM credits JOIN names ON names.id = credits.name_id
+		WHERE credits.title_id = ? ORDER BY credits.position IS NULL, credits.position, credits.rowid`, titleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credits := []*models.ImdbapiCredit{}
+	for rows.Next() {
+		var data []byte
+		var name models.ImdbapiName
+		if err := rows.Scan(&data, &name.ID, &name.DisplayName); err != nil {
+			return nil, err
+		}
+		var credit models.ImdbapiCredit
+		if err := json.Unmarshal(data, &credit); err != nil {
+			return nil, err
+		}
+		if credit.Name == nil {
+			credit.Name = &name
+		}
+		credits = append(credits, &credit)
+	}
+	return credits, rows.Err()
+}
+
+// Episodes are the stored episodes of a series in season and episode order,
+// nil unless the listing was saved whole and is not stale. Regular seasons
+// come in numeric order, then specials and then seasons that are not a
+// number, unnumbered episodes last within their season.
+func (s *Store) Episodes(ctx context.Context, seriesID string) ([]*models.ImdbapiEpisode, error) {
+	if ok, err := s.listed(ctx, KindEpisodes, seriesID); !ok {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM episodes WHERE series_id = ?
+		ORDER BY CASE WHEN season GLOB '[0-9]*' AND CAST(season AS INTEGER) > 0 THEN 0 WHEN season = '0' OR lower(season) LIKE 'special%' THEN 1 ELSE 2 END,
+			CAST(season AS INTEGER), season, episode_number = 0, episode_number, rowid`, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	episodes := []*models.ImdbapiEpisode{}
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var episode models.ImdbapiEpisode
+		if err := json.Unmarshal(data, &episode); err != nil {
+			return nil, err
+		}
+		episodes = append(episodes, &episode)
+	}
+	return episodes, rows.Err()
+}
+
+// Seasons are the stored seasons of a series in the order of its listing,
+// nil unless the episodes of the series are listed and not stale.
+func (s *Store) Seasons(ctx context.Context, seriesID string) ([]*models.ImdbapiSeason, error) {
+	if ok, err := s.listed(ctx, KindEpisodes, seriesID); !ok {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT season, episode_count FROM seasons WHERE series_id = ? ORDER BY position`, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seasons := []*models.ImdbapiSeason{}
+	for rows.Next() {
+		var season models.ImdbapiSeason
+		var episodeCount sql.NullInt32
+		if err := rows.Scan(&season.Season, &episodeCount); err != nil {
+			return nil, err
+		}
+		season.EpisodeCount = episodeCount.Int32
+		seasons = append(seasons, &season)
+	}
+	return seasons, rows.Err()
+}
+
+// stubName makes sure a names row exists for a relation to point at,
+// without touching a fetched name.
+func stubName(ctx context.Context, tx *sql.Tx, name *models.ImdbapiName) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO names (id, display_name) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET display_name = excluded.display_name WHERE names.fetched_at IS NULL AND excluded.display_name != ''`,
+		name.ID, name.DisplayName)
+	return err
+}
+
+func (s *Store) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,223 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+func openTestStore(t *testing.T, maxAge time.Duration) (*Store, *time.Time) {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "nested", "test.db"), maxAge)
+	if err != nil {
+		t.Fatalf("Open = unexpected error (%v)", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	db.now = func() time.Time { return now }
+	return db, &now
+}
+
+func TestStoreTitle(t *testing.T) {
+	db, now := openTestStore(t, time.Hour)
+	ctx := context.Background()
+
+	title := &models.ImdbapiTitle{
+		ID:           "tt0111161",
+		PrimaryTitle: "The Shawshank Redemption",
+		Type:         "movie",
+		Genres:       []string{"Drama"},
+		Rating:       &models.ImdbapiRating{AggregateRating: 9.3, VoteCount: 3000000},
+		Directors:    []*models.ImdbapiName{{ID: "nm0001104", DisplayName: "Frank Darabont"}},
+	}
+	if err := db.SaveTitle(ctx, title); err != nil {
+		t.Fatalf("TestStoreTitle = unexpected error saving (%v)", err)
+	}
+
+	stored, err := db.Title(ctx, "tt0111161")
+	if err != nil || stored == nil || stored.PrimaryTitle != title.PrimaryTitle || stored.Rating.VoteCount != 3000000 || !slices.Equal(stored.Genres, title.Genres) {
+		t.Errorf("TestStoreTitle = got (%v, %v), want (%v).", stored, err, title)
+	}
+
+	var genres, directors int
+	db.db.QueryRow(`SELECT COUNT(*) FROM title_genres WHERE title_id = ?`, title.ID).Scan(&genres)
+	db.db.QueryRow(`SELECT COUNT(*) FROM title_people WHERE title_id = ? AND role = 'director'`, title.ID).Scan(&directors)
+	if genres != 1 || directors != 1 {
+		t.Errorf("TestStoreTitle(relations) = got (%d genres, %d directors), want (1, 1).", genres, directors)
+	}
+
+	// Directors are only stubs until fetched themselves.
+	if name, err := db.Name(ctx, "nm0001104"); name != nil || err != nil {
+		t.Errorf("TestStoreTitle(stub) = got (%v, %v), want (nil, nil).", name, err)
+	}
+
+	*now = now.Add(2 * time.Hour)
+	if stale, err := db.Title(ctx, "tt0111161"); stale != nil || err != nil {
+		t.Errorf("TestStoreTitle(stale) = got (%v, %v), want (nil, nil).", stale, err)
+	}
+	if missing, err := db.Title(ctx, "tt0000000"); missing != nil || err != nil {
+		t.Errorf("TestStoreTitle(missing) = got (%v, %v), want (nil, nil).", missing, err)
+	}
+}
+
+func TestStoreName(t *testing.T) {
+	db, _ := openTestStore(t, 0)
+	ctx := context.Background()
+
+	credit := &models.ImdbapiCredit{Category: "director", Name: &models.ImdbapiName{ID: "nm0001104", DisplayName: "Frank Darabont"}}
+	if err := db.SaveCredit(ctx, "tt0111161", "nm0001104", credit); err != nil {
+		t.Fatalf("TestStoreName = unexpected error saving credit (%v)", err)
+	}
+	name := &models.ImdbapiName{ID: "nm0001104", DisplayName: "Frank Darabont", PrimaryProfessions: []string{"director", "writer"}, BirthDate: &models.ImdbapiPrecisionDate{Year: 1959}}
+	if err := db.SaveName(ctx, name); err != nil {
+		t.Fatalf("TestStoreName = unexpected error saving (%v)", err)
+	}
+	// A later credit must not turn the fetched name back into a stub.
+	if err := db.SaveCredit(ctx, "tt0120689", "nm0001104", &models.ImdbapiCredit{Category: "director"}); err != nil {
+		t.Fatalf("TestStoreName = unexpected error saving credit (%v)", err)
+	}
+
+	stored, err := db.Name(ctx, "nm0001104")
+	if err != nil || stored == nil || stored.BirthDate.Year != 1959 || !slices.Equal(stored.PrimaryProfessions, name.PrimaryProfessions) {
+		t.Errorf("TestStoreName = got (%v, %v), want (%v).", stored, err, name)
+	}
+
+	var credits int
+	db.db.QueryRow(`SELECT COUNT(*) FROM credits WHERE name_id = ?`, name.ID).Scan(&credits)
+	if credits != 2 {
+		t.Errorf("TestStoreName(credits) = got %d credits, want 2.", credits)
+	}
+}
+
+func TestStoreStaleAndPrune(t *testing.T) {
+	db, now := openTestStore(t, 0)
+	ctx := context.Background()
+	started := *now
+
+	db.SaveTitle(ctx, &models.ImdbapiTitle{ID: "tt0000002", Type: "tvSeries"})
+	db.SaveEpisodes(ctx, "tt0000002", []*models.ImdbapiEpisode{{ID: "tt1000011", Season: "1", EpisodeNumber: 1}, {ID: "tt1000012", Season: "1", EpisodeNumber: 2}})
+	db.SaveCredits(ctx, "tt0000002", []*models.ImdbapiCredit{{Category: "actor", Name: &models.ImdbapiName{ID: "nm0000001"}}})
+	// Only seen through a filmography, so not a listing to refresh.
+	db.SaveCredit(ctx, "tt0000003", "nm0000001", &models.ImdbapiCredit{Category: "actor"})
+
+	*now = now.Add(48 * time.Hour)
+	db.SaveName(ctx, &models.ImdbapiName{ID: "nm0000002"})
+
+	stale, err := db.Stale(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("TestStoreStaleAndPrune = unexpected error (%v)", err)
+	}
+	expected := []Record{
+		{Kind: KindTitle, ID: "tt0000002", FetchedAt: started},
+		{Kind: KindCredits, ID: "tt0000002", FetchedAt: started},
+		{Kind: KindEpisodes, ID: "tt0000002", FetchedAt: started},
+	}
+	if len(stale) != len(expected) {
+		t.Fatalf("TestStoreStaleAndPrune = got (%v), want (%v).", stale, expected)
+	}
+	for i := range expected {
+		if stale[i].Kind != expected[i].Kind || stale[i].ID != expected[i].ID || !stale[i].FetchedAt.Equal(expected[i].FetchedAt) {
+			t.Errorf("TestStoreStaleAndPrune(%d) = got (%v), want (%v).", i, stale[i], expected[i])
+		}
+	}
+
+	// Episode 2 is gone upstream, so only episode 1 is saved again.
+	refreshed := *now
+	db.SaveEpisodes(ctx, "tt0000002", []*models.ImdbapiEpisode{{ID: "tt1000011", Season: "1", EpisodeNumber: 1}})
+	if err := db.Prune(ctx, expected[2], refreshed); err != nil {
+		t.Fatalf("TestStoreStaleAndPrune = unexpected error pruning (%v)", err)
+	}
+	episodes, err := db.Episodes(ctx, "tt0000002")
+	if err != nil || len(episodes) != 1 || episodes[0].ID != "tt1000011" {
+		t.Errorf("TestStoreStaleAndPrune(pruned) = got (%v, %v), want only tt1000011.", episodes, err)
+	}
+
+	if err := db.Prune(ctx, Record{Kind: KindTitle, ID: "tt0000002"}, refreshed); err == nil {
+		t.Errorf("TestStoreStaleAndPrune = pruning a title succeeded, want an error.")
+	}
+}
+
+func TestStoreEpisodeOrder(t *testing.T) {
+	db, _ := openTestStore(t, 0)
+	ctx := context.Background()
+
+	var episodes []*models.ImdbapiEpisode
+	for i, key := range []string{"10/1", "Specials/1", "2/2", "/1", "1/1", "2/0", "2/1", "0/1"} {
+		season, number, _ := strings.Cut(key, "/")
+		episode, _ := strconv.Atoi(number)
+		episodes = append(episodes, &models.ImdbapiEpisode{ID: fmt.Sprintf("tt10000%02d", i), Season: season, EpisodeNumber: int32(episode)})
+	}
+	if err := db.SaveEpisodes(ctx, "tt0000002", episodes); err != nil {
+		t.Fatalf("TestStoreEpisodeOrder = unexpected error (%v)", err)
+	}
+	stored, err := db.Episodes(ctx, "tt0000002")
+	var got []string
+	for _, episode := range stored {
+		got = append(got, fmt.Sprintf("%s/%d", episode.Season, episode.EpisodeNumber))
+	}
+	if want := []string{"1/1", "2/1", "2/2", "2/0", "10/1", "0/1", "Specials/1", "/1"}; err != nil || !slices.Equal(got, want) {
+		t.Errorf("TestStoreEpisodeOrder = got (%v, %v), want (%v).", got, err, want)
+	}
+}
+
+func TestStoreListings(t *testing.T) {
+	db, now := openTestStore(t, time.Hour)
+	ctx := context.Background()
+
+	// A filmography alone says nothing about the other credits of a title.
+	filmography := &models.ImdbapiCredit{Category: "producer", Title: &models.ImdbapiTitle{ID: "tt0111161"}}
+	db.SaveCredit(ctx, "tt0111161", "nm0000209", filmography)
+	if credits, err := db.Credits(ctx, "tt0111161"); credits != nil || err != nil {
+		t.Errorf("TestStoreListings(filmography) = got (%v, %v), want (nil, nil).", credits, err)
+	}
+
+	listing := []*models.ImdbapiCredit{
+		{Category: "actor", Name: &models.ImdbapiName{ID: "nm0000209", DisplayName: "Tim Robbins"}, Characters: []string{"Andy Dufresne"}},
+		{Category: "actor", Name: &models.ImdbapiName{ID: "nm0000151", DisplayName: "Morgan Freeman"}},
+	}
+	if err := db.SaveCredits(ctx, "tt0111161", listing); err != nil {
+		t.Fatalf("TestStoreListings = unexpected error saving credits (%v)", err)
+	}
+	credits, err := db.Credits(ctx, "tt0111161")
+	var got []string
+	for _, credit := range credits {
+		got = append(got, credit.Name.DisplayName+"/"+credit.Category)
+	}
+	if want := []string{"Tim Robbins/actor", "Morgan Freeman/actor", "Tim Robbins/producer"}; err != nil || !slices.Equal(got, want) || credits[0].Characters[0] != "Andy Dufresne" {
+		t.Errorf("TestStoreListings(credits) = got (%v, %v), want (%v).", got, err, want)
+	}
+
+	if episodes, err := db.Episodes(ctx, "tt0000002"); episodes != nil || err != nil {
+		t.Errorf("TestStoreListings(no episodes) = got (%v, %v), want (nil, nil).", episodes, err)
+	}
+	db.SaveEpisodes(ctx, "tt0000002", nil)
+	if episodes, err := db.Episodes(ctx, "tt0000002"); episodes == nil || len(episodes) != 0 || err != nil {
+		t.Errorf("TestStoreListings(empty episodes) = got (%v, %v), want an empty listing.", episodes, err)
+	}
+	db.SaveSeasons(ctx, "tt0000002", []*models.ImdbapiSeason{{Season: "2", EpisodeCount: 1}, {Season: "1", EpisodeCount: 3}, {Season: "Specials"}})
+	db.SaveSeasons(ctx, "tt0000002", []*models.ImdbapiSeason{{Season: "2", EpisodeCount: 2}, {Season: "1", EpisodeCount: 3}})
+	seasons, err := db.Seasons(ctx, "tt0000002")
+	got = nil
+	for _, season := range seasons {
+		got = append(got, fmt.Sprintf("%s/%d", season.Season, season.EpisodeCount))
+	}
+	if want := []string{"2/2", "1/3"}; err != nil || !slices.Equal(got, want) {
+		t.Errorf("TestStoreListings(seasons) = got (%v, %v), want (%v).", got, err, want)
+	}
+
+	*now = now.Add(2 * time.Hour)
+	if credits, err := db.Credits(ctx, "tt0111161"); credits != nil || err != nil {
+		t.Errorf("TestStoreListings(stale) = got (%v, %v), want (nil, nil).", credits, err)
+	}
+	if seasons, err := db.Seasons(ctx, "tt0000002"); seasons != nil || err != nil {
+		t.Errorf("TestStoreListings(stale seasons) = got (%v, %v), want (nil, nil).", seasons, err)
+	}
+}
@@ -0,0 +1,170 @@
+package watchlist
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/foursixnine/imdblookup/internal/ids"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// Statuses an entry can be filtered on.
+const (
+	Pending = "pending"
+	Done    = "done"
+	All     = "all"
+)
+
+// Filter selects entries. Zero fields select everything, but for Status
+// which is Pending when empty. Genre and Types need the entries enriched.
+type Filter struct {
+	Status string
+	Tag    string
+	Genre  string
+	Types  []models.ImdbapiTitleType
+}
+
+// order is a way to sort entries. Those whose value is not known, mostly
+// for not being enriched, come last whichever the direction.
+type order struct {
+	known   func(title *models.ImdbapiTitle) bool
+	compare func(a, b *Entry) int
+}
+
+var orders = map[string]order{
+	// Added is the order titles were put on the list, oldest first.
+	"added": {
+		compare: func(a, b *Entry) int { return a.Added.Compare(b.Added) },
+	},
+	// Rating puts the best rated first.
+	"rating": {
+		known: func(title *models.ImdbapiTitle) bool { return title.Rating != nil && title.Rating.AggregateRating != 0 },
+		compare: func(a, b *Entry) int {
+			return cmp.Compare(b.Title.Rating.AggregateRating, a.Title.Rating.AggregateRating)
+		},
+	},
+	// Runtime puts the shortest first, for what can be got through fast.
+	"runtime": {
+		known:   func(title *models.ImdbapiTitle) bool { return title.RuntimeSeconds != 0 },
+		compare: func(a, b *Entry) int { return cmp.Compare(a.Title.RuntimeSeconds, b.Title.RuntimeSeconds) },
+	},
+	// Year puts the newest first.
+	"year": {
+		known:   func(title *models.ImdbapiTitle) bool { return title.StartYear != 0 },
+		compare: func(a, b *Entry) int { return cmp.Compare(b.Title.StartYear, a.Title.StartYear) },
+	},
+	"title": {
+		known: func(title *models.ImdbapiTitle) bool { return label(title) != "" },
+		compare: func(a, b *Entry) int {
+			return strings.Compare(strings.ToLower(label(a.Title)), strings.ToLower(label(b.Title)))
+		},
+	},
+}
+
+func label(title *models.ImdbapiTitle) string {
+	return cmp.Or(title.PrimaryTitle, title.OriginalTitle)
+}
+
+func (o order) isKnown(entry *Entry) bool {
+	return o.known == nil || (entry.Title != nil && o.known(entry.Title))
+}
+
+// Sorts lists the orders Select takes.
+func Sorts() []string {
+	return slices.Sorted(maps.Keys(orders))
+}
+
+// Select returns the entries matching filter ordered by sortBy, one of
+// Sorts, or added when empty, reverse turning the order around. Ties keep
+// the order entries were added in.
+func (w *Watchlist) Select(filter Filter, sortBy string, reverse bool) ([]*Entry, error) {
+	order, ok := orders[cmp.Or(sortBy, "added")]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort %q, want one of %s", sortBy, strings.Join(Sorts(), ", "))
+	}
+	status := cmp.Or(filter.Status, Pending)
+	if status != Pending && status != Done && status != All {
+		return nil, fmt.Errorf("unknown status %q, want pending, done or all", status)
+	}
+
+	var entries []*Entry
+	for _, entry := range w.Entries {
+		if filter.matches(entry, status) {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b *Entry) int {
+		return cmp.Or(a.Added.Compare(b.Added), strings.Compare(string(a.ID), string(b.ID)))
+	})
+	slices.SortStableFunc(entries, func(a, b *Entry) int {
+		knownA, knownB := order.isKnown(a), order.isKnown(b)
+		switch {
+		case knownA && knownB:
+			if reverse {
+				return order.compare(b, a)
+			}
+			return order.compare(a, b)
+		case knownA:
+			return -1
+		case knownB:
+			return 1
+		}
+		return 0
+	})
+	return entries, nil
+}
+
+func (filter Filter) matches(entry *Entry, status string) bool {
+	if status != All && entry.IsDone() != (status == Done) {
+		return false
+	}
+	if filter.Tag != "" && !slices.Contains(entry.Tags, filter.Tag) {
+		return false
+	}
+	if filter.Genre == "" && len(filter.Types) == 0 {
+		return true
+	}
+	if entry.Title == nil {
+		return false
+	}
+	if filter.Genre != "" && !slices.ContainsFunc(entry.Title.Genres, func(genre string) bool { return strings.EqualFold(genre, filter.Genre) }) {
+		return false
+	}
+	return len(filter.Types) == 0 || slices.Contains(filter.Types, models.ImdbapiTitleType(entry.Title.Type))
+}
+
+// Source looks up the titles entries are enriched with.
+type Source interface {
+	Title(ctx context.Context, id ids.TitleID) (*models.ImdbapiTitle, error)
+}
+
+// Enrich looks up the titles of entries never enriched, or enriched
+// longer than maxAge ago when it is not 0. An entry that cannot be looked
+// up keeps what it had, the failures are reported together.
+func (w *Watchlist) Enrich(ctx context.Context, source Source, entries []*Entry, maxAge time.Duration) (int, error) {
+	enriched := 0
+	var problems []error
+	for _, entry := range entries {
+		id := entry.ID
+		if entry.Title != nil && (maxAge == 0 || w.now().Sub(entry.Fetched) < maxAge) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return enriched, err
+		}
+		title, err := source.Title(ctx, id)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("looking up %s: %w", id, err))
+			continue
+		}
+		entry.Title, entry.Fetched = title, w.now().UTC().Truncate(time.Second)
+		enriched++
+	}
+	return enriched, errors.Join(problems...)
+}
@@ -0,0 +1,123 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/foursixnine/imdblookup/internal/ids"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+type fakeSource struct {
+	titles  map[ids.TitleID]*models.ImdbapiTitle
+	lookups []ids.TitleID
+}
+
+func (s *fakeSource) Title(ctx context.Context, id ids.TitleID) (*models.ImdbapiTitle, error) {
+	s.lookups = append(s.lookups, id)
+	title, ok := s.titles[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return title, nil
+}
+
+func rated(rating float32) *models.ImdbapiRating {
+	return &models.ImdbapiRating{AggregateRating: rating}
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{titles: map[ids.TitleID]*models.ImdbapiTitle{
+		"tt0113277": {ID: "tt0113277", PrimaryTitle: "Heat", Type: "MOVIE", StartYear: 1995, RuntimeSeconds: 10200, Rating: rated(8.3), Genres: []string{"Crime", "Drama"}},
+		"tt0082096": {ID: "tt0082096", PrimaryTitle: "Das Boot", Type: "MOVIE", StartYear: 1981, RuntimeSeconds: 8940, Rating: rated(8.4), Genres: []string{"Drama", "War"}},
+		"tt0903747": {ID: "tt0903747", PrimaryTitle: "Breaking Bad", Type: "TV_SERIES", StartYear: 2008, RuntimeSeconds: 2700, Rating: rated(9.5), Genres: []string{"Crime"}},
+		"tt9999998": {ID: "tt9999998", PrimaryTitle: "Announced", Type: "MOVIE"},
+	}}
+}
+
+// newWatchlist lists ids in order, enriched from source but for those it
+// does not know.
+func newWatchlist(t *testing.T, source Source, titleIDs ...ids.TitleID) *Watchlist {
+	t.Helper()
+	list := &Watchlist{Entries: map[ids.TitleID]*Entry{}, now: clock()}
+	var entries []*Entry
+	for _, id := range titleIDs {
+		entry, _ := list.Add(id)
+		entries = append(entries, entry)
+	}
+	list.Enrich(context.Background(), source, entries, 0)
+	return list
+}
+
+func entryIDs(entries []*Entry) []ids.TitleID {
+	var titleIDs []ids.TitleID
+	for _, entry := range entries {
+		titleIDs = append(titleIDs, entry.ID)
+	}
+	return titleIDs
+}
+
+func TestSelect(t *testing.T) {
+	list := newWatchlist(t, newFakeSource(), "tt0113277", "tt0000001", "tt0082096", "tt0903747", "tt9999998")
+	list.MarkDone("tt0082096", true)
+	list.Entries["tt0903747"].Tag("binge")
+
+	testCases := map[string]struct {
+		filter   Filter
+		sort     string
+		reverse  bool
+		expected []ids.TitleID
+		err      bool
+	}{
+		"with the defaults":       {expected: []ids.TitleID{"tt0113277", "tt0000001", "tt0903747", "tt9999998"}},
+		"with all by rating":      {filter: Filter{Status: All}, sort: "rating", expected: []ids.TitleID{"tt0903747", "tt0082096", "tt0113277", "tt0000001", "tt9999998"}},
+		"with rating reversed":    {sort: "rating", reverse: true, expected: []ids.TitleID{"tt0113277", "tt0903747", "tt0000001", "tt9999998"}},
+		"with runtime":            {sort: "runtime", expected: []ids.TitleID{"tt0903747", "tt0113277", "tt0000001", "tt9999998"}},
+		"with year":               {sort: "year", expected: []ids.TitleID{"tt0903747", "tt0113277", "tt0000001", "tt9999998"}},
+		"with title":              {filter: Filter{Status: All}, sort: "title", expected: []ids.TitleID{"tt9999998", "tt0903747", "tt0082096", "tt0113277", "tt0000001"}},
+		"with added reversed":     {reverse: true, expected: []ids.TitleID{"tt9999998", "tt0903747", "tt0000001", "tt0113277"}},
+		"with done ones":          {filter: Filter{Status: Done}, expected: []ids.TitleID{"tt0082096"}},
+		"with a tag":              {filter: Filter{Tag: "binge"}, expected: []ids.TitleID{"tt0903747"}},
+		"with a genre":            {filter: Filter{Status: All, Genre: "drama"}, expected: []ids.TitleID{"tt0113277", "tt0082096"}},
+		"with a type":             {filter: Filter{Types: []models.ImdbapiTitleType{models.ImdbapiTitleTypeTVSERIES}}, expected: []ids.TitleID{"tt0903747"}},
+		"with a genre and a type": {filter: Filter{Genre: "crime", Types: []models.ImdbapiTitleType{models.ImdbapiTitleTypeMOVIE}}, expected: []ids.TitleID{"tt0113277"}},
+		"with an unknown sort":    {sort: "votes", err: true},
+		"with an unknown status":  {filter: Filter{Status: "later"}, err: true},
+	}
+
+	for testName, testCase := range testCases {
+		entries, err := list.Select(testCase.filter, testCase.sort, testCase.reverse)
+		if (err != nil) != testCase.err {
+			t.Errorf("TestSelect(%s) = got error (%v), want error (%v).", testName, err, testCase.err)
+			continue
+		}
+		if got := entryIDs(entries); !slices.Equal(got, testCase.expected) {
+			t.Errorf("TestSelect(%s) = got (%v), want (%v).", testName, got, testCase.expected)
+		}
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	source := newFakeSource()
+	list := newWatchlist(t, source, "tt0113277", "tt0000001")
+	if !slices.Equal(source.lookups, []ids.TitleID{"tt0113277", "tt0000001"}) {
+		t.Errorf("TestEnrich() = got lookups (%v), want both entries.", source.lookups)
+	}
+	entries := []*Entry{list.Entries["tt0113277"], list.Entries["tt0000001"]}
+
+	source.lookups = nil
+	enriched, err := list.Enrich(context.Background(), source, entries, 0)
+	if enriched != 0 || err == nil || !slices.Equal(source.lookups, []ids.TitleID{"tt0000001"}) {
+		t.Errorf("TestEnrich() = got (%d, %v, %v), want only the failed entry looked up again, failing.", enriched, err, source.lookups)
+	}
+
+	source.lookups = nil
+	source.titles["tt0113277"] = &models.ImdbapiTitle{ID: "tt0113277", PrimaryTitle: "Heat (renamed)"}
+	enriched, _ = list.Enrich(context.Background(), source, entries, time.Minute)
+	if enriched != 1 || list.Entries["tt0113277"].Title.PrimaryTitle != "Heat (renamed)" {
+		t.Errorf("TestEnrich() = got (%d, %v), want the stale entry refreshed.", enriched, list.Entries["tt0113277"].Title)
+	}
+}
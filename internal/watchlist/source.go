@@ -0,0 +1,27 @@
+package watchlist
+
+import (
+	"context"
+
+	"github.com/foursixnine/imdblookup/internal/client"
+	"github.com/foursixnine/imdblookup/internal/ids"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+type clientSource struct {
+	client *client.ImdbClient
+}
+
+// NewClientSource looks titles up through the client, and so through its
+// cache and local store.
+func NewClientSource(imdbClient *client.ImdbClient) Source {
+	return &clientSource{client: imdbClient}
+}
+
+func (s *clientSource) Title(ctx context.Context, id ids.TitleID) (*models.ImdbapiTitle, error) {
+	title, err := s.client.GetTitle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return title, nil
+}
@@ -0,0 +1,158 @@
+// Package watchlist keeps the titles someone means to review in a local
+// JSON file keyed by title id. Entries start out as bare ids and are
+// enriched with their title lazily, when listed.
+package watchlist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/foursixnine/imdblookup/internal/ids"
+	"github.com/foursixnine/imdblookup/models"
+)
+
+var ErrNotListed = errors.New("title is not on the watchlist")
+
+// Entry is a title on the watchlist. Title and Fetched are only set once
+// the entry has been enriched.
+type Entry struct {
+	ID      ids.TitleID          `json:"id"`
+	Added   time.Time            `json:"added"`
+	Tags    []string             `json:"tags,omitempty"`
+	DoneAt  *time.Time           `json:"done,omitempty"`
+	Title   *models.ImdbapiTitle `json:"title,omitempty"`
+	Fetched time.Time            `json:"fetched,omitzero"`
+}
+
+func (e *Entry) IsDone() bool {
+	return e.DoneAt != nil
+}
+
+// Tag adds tags the entry does not have yet, keeping them sorted.
+func (e *Entry) Tag(tags ...string) {
+	for _, tag := range tags {
+		if tag != "" && !slices.Contains(e.Tags, tag) {
+			e.Tags = append(e.Tags, tag)
+		}
+	}
+	slices.Sort(e.Tags)
+}
+
+func (e *Entry) Untag(tags ...string) {
+	e.Tags = slices.DeleteFunc(e.Tags, func(tag string) bool { return slices.Contains(tags, tag) })
+}
+
+// Watchlist is the content of one watchlist file. It is not safe for
+// concurrent use.
+type Watchlist struct {
+	Entries map[ids.TitleID]*Entry `json:"entries"`
+
+	path string
+	now  func() time.Time
+}
+
+// DefaultPath is watchlist.json in the user's configuration directory, as
+// unlike the cache it is not something to throw away.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "imdblookup", "watchlist.json"), nil
+}
+
+// Load reads the watchlist at path, a missing file being an empty one.
+func Load(path string) (*Watchlist, error) {
+	watchlist := &Watchlist{Entries: map[ids.TitleID]*Entry{}, path: path, now: time.Now}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return watchlist, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, watchlist); err != nil {
+		return nil, fmt.Errorf("reading watchlist %s: %w", path, err)
+	}
+	if watchlist.Entries == nil {
+		watchlist.Entries = map[ids.TitleID]*Entry{}
+	}
+	return watchlist, nil
+}
+
+// Save writes the watchlist back to where it was loaded from, through a
+// temporary file so an interrupted save leaves the old one whole. Entries
+// come out sorted by id, keeping the diffs of a shared file small.
+func (w *Watchlist) Save() error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), ".watchlist-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), w.path)
+}
+
+// Add puts id on the watchlist with tags, reporting false when it was
+// already there, in which case the tags are added to it.
+func (w *Watchlist) Add(id ids.TitleID, tags ...string) (*Entry, bool) {
+	entry, ok := w.Entries[id]
+	if !ok {
+		entry = &Entry{ID: id, Added: w.now().UTC().Truncate(time.Second)}
+		w.Entries[id] = entry
+	}
+	entry.Tag(tags...)
+	return entry, !ok
+}
+
+func (w *Watchlist) Remove(id ids.TitleID) error {
+	if _, ok := w.Entries[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotListed, id)
+	}
+	delete(w.Entries, id)
+	return nil
+}
+
+func (w *Watchlist) Entry(id ids.TitleID) (*Entry, error) {
+	entry, ok := w.Entries[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotListed, id)
+	}
+	return entry, nil
+}
+
+// MarkDone marks the entry of id as reviewed, or as pending again when
+// done is false. Marking a done entry done keeps its first date.
+func (w *Watchlist) MarkDone(id ids.TitleID, done bool) error {
+	entry, err := w.Entry(id)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !done:
+		entry.DoneAt = nil
+	case entry.DoneAt == nil:
+		now := w.now().UTC().Truncate(time.Second)
+		entry.DoneAt = &now
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package watchlist
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/foursixnine/imdblookup/internal/ids"
+)
+
+// clock is a fake now, one minute later on every call.
+func clock() func() time.Time {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "watchlist.json")
+	list, err := Load(path)
+	if err != nil || len(list.Entries) != 0 {
+		t.Fatalf("TestLoadSave() = got (%v, %v) for a missing file, want an empty watchlist.", list, err)
+	}
+	list.now = clock()
+	list.Add("tt0113277", "crime", "heist")
+	list.Add("tt0082096")
+	if err := list.MarkDone("tt0082096", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.Save(); err != nil {
+		t.Fatalf("TestLoadSave() = got (%v) saving, want no error.", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("TestLoadSave() = got (%v) loading, want no error.", err)
+	}
+	heat, boot := loaded.Entries["tt0113277"], loaded.Entries["tt0082096"]
+	if heat == nil || boot == nil || !slices.Equal(heat.Tags, []string{"crime", "heist"}) || heat.IsDone() || !boot.IsDone() || !boot.DoneAt.After(boot.Added) {
+		t.Errorf("TestLoadSave() = got (%+v, %+v), want both entries back as saved.", heat, boot)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Errorf("TestLoadSave() = got no error for a broken file, want one.")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"entries":{"tt12":{"id":"tt12"}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Errorf("TestLoadSave() = got no error for a malformed id, want one.")
+	}
+}
+
+func TestEntries(t *testing.T) {
+	list := &Watchlist{Entries: map[ids.TitleID]*Entry{}, now: clock()}
+	entry, added := list.Add("tt0113277", "b", "a")
+	if !added || !slices.Equal(entry.Tags, []string{"a", "b"}) {
+		t.Errorf("TestEntries() = got (%v, %v) adding, want a new entry tagged a and b.", entry.Tags, added)
+	}
+	again, added := list.Add("tt0113277", "c", "a")
+	if added || again != entry || !slices.Equal(entry.Tags, []string{"a", "b", "c"}) {
+		t.Errorf("TestEntries() = got (%v, %v) adding again, want the tags merged.", entry.Tags, added)
+	}
+	entry.Untag("b", "z")
+	if !slices.Equal(entry.Tags, []string{"a", "c"}) {
+		t.Errorf("TestEntries() = got (%v) untagging, want a and c.", entry.Tags)
+	}
+
+	if err := list.MarkDone("tt0113277", true); err != nil || !entry.IsDone() {
+		t.Errorf("TestEntries() = got (%v) marking done, want the entry done.", err)
+	}
+	first := *entry.DoneAt
+	if list.MarkDone("tt0113277", true); !entry.DoneAt.Equal(first) {
+		t.Errorf("TestEntries() = got (%v) marking done again, want the first date (%v).", entry.DoneAt, first)
+	}
+	if list.MarkDone("tt0113277", false); entry.IsDone() {
+		t.Errorf("TestEntries() = got done after undoing, want pending.")
+	}
+
+	for name, err := range map[string]error{
+		"done":   list.MarkDone("tt0000000", true),
+		"remove": list.Remove("tt0000000"),
+	} {
+		if !errors.Is(err, ErrNotListed) {
+			t.Errorf("TestEntries(%s) = got (%v) for a title not listed, want (%v).", name, err, ErrNotListed)
+		}
+	}
+	if err := list.Remove("tt0113277"); err != nil || len(list.Entries) != 0 {
+		t.Errorf("TestEntries() = got (%v, %d entries) removing, want none left.", err, len(list.Entries))
+	}
+}
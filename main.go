@@ -1,21 +1,35 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
-	"sync"
 	"syscall"
+	"time"
 
+	"github.com/foursixnine/imdblookup/internal/cache"
 	"github.com/foursixnine/imdblookup/internal/client"
 	ce "github.com/foursixnine/imdblookup/internal/errors"
+	"github.com/foursixnine/imdblookup/internal/ids"
+	"github.com/foursixnine/imdblookup/internal/store"
 )
 
 type CLIargs struct {
-	api string
+	api         string
+	noCache     bool
+	cacheTTL    time.Duration
+	storePath   string
+	noStore     bool
+	storeMaxAge time.Duration
+	offline     bool
 }
 
 type CLIopts struct {
@@ -29,8 +43,16 @@ func main() {
 	var args CLIargs
 	var opts CLIopts
 
-	flag.StringVar(&opts.Query, "query", "Stranger Things", "Search query for IMDB titles")
+	flag.StringVar(&opts.Query, "query", "Stranger Things", "Search query for IMDB titles, used when no command is given")
+	flag.IntVar(&opts.Limit, "limit", 5, "Maximum number of search results, used when no command is given")
 	flag.StringVar(&args.api, "api", "https://api.imdbapi.dev", "Api url to use as base")
+	flag.BoolVar(&args.noCache, "no-cache", false, "Always query the api, bypassing the response cache")
+	flag.DurationVar(&args.cacheTTL, "cache-ttl", 0, "Keep every cached response for this long instead of the per-endpoint defaults")
+	flag.StringVar(&args.storePath, "store", "", "SQLite database mirroring fetched records, defaults to imdblookup.db in the cache directory")
+	flag.BoolVar(&args.noStore, "no-store", false, "Neither read from nor write to the local SQLite mirror")
+	flag.DurationVar(&args.storeMaxAge, "store-max-age", 7*24*time.Hour, "Serve records from the local mirror for this long after fetching them")
+	flag.BoolVar(&args.offline, "offline", false, "Never touch the network, answering from the response cache and the local store only, however old")
+	flag.Usage = usage
 	flag.Parse()
 
 	if args.api == "" {
@@ -44,34 +66,147 @@ func main() {
 		log.Fatalf("Error parsing api url: %v", err)
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(1)
-	done := make(chan struct{})
-	result := &ce.IMDBClientApplicationError{}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	imdbClient := client.New(url)
+	options := clientOptions(url, args)
+	db := openStore(args)
+	if db != nil {
+		options.Store = db
+	}
+	imdbClient := client.NewWithOptions(options)
 	log.Printf("Application started with %s as Server\n", args.api)
 
-	go getTitles(imdbClient, opts.Query, &wg, done, result)
-	go progressMarker(done)
+	// Without a command we keep behaving like the original single-purpose
+	// search tool.
+	name, commandArgs := "search", []string{"--query", opts.Query, "--limit", strconv.Itoa(opts.Limit)}
+	if flag.NArg() > 0 {
+		name, commandArgs = flag.Arg(0), flag.Args()[1:]
+	}
 
-	wg.Wait()
+	code := exitCode(runCommand(ctx, imdbClient, name, commandArgs))
+	if db != nil {
+		db.Close()
+	}
+	if code != ce.SUCCESS {
+		stop()
+		os.Exit(code)
+	}
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: %s [flags] <command> [command flags] [arguments]\n\n", os.Args[0])
+	printCommands(out)
+	fmt.Fprintf(out, "\nflags:\n")
+	flag.PrintDefaults()
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return ce.SUCCESS
+	}
 
-	if result.Code != 0 {
-		if errors.Is(result, syscall.ECONNREFUSED) {
-			log.Println("Connection to api server has been refused")
-			result.Code = ce.CONNECTIONREFUSEDERROR
+	var appErr *ce.IMDBClientApplicationError
+	var httpErr *ce.HTTPError
+	var invalidID *ids.InvalidIDError
+	switch {
+	case errors.Is(err, context.Canceled):
+		log.Println("Interrupted, in-flight requests have been cancelled")
+		return ce.CANCELLEDERROR
+	case errors.Is(err, ce.ErrOffline):
+		var clientErr *ce.IMDBClientError
+		if errors.As(err, &clientErr) {
+			log.Println(clientErr.Message)
 		} else {
-			var appErr *ce.IMDBClientApplicationError
-			// if errors.As(result, ce.NewIMDBClientApplicationError("Search title cannot be empty", nil)) {
-			if errors.As(result, &appErr) && appErr.AppMessage == "Search title cannot be empty" {
-				log.Println("Search query cannot be empty")
-			} else {
-				log.Printf("Unhandled error, %v\n", result)
-				ce.RootCause(result)
-			}
+			log.Println("Not available offline")
 		}
-		os.Exit(result.Code)
+		return ce.OFFLINEERROR
+	case errors.Is(err, syscall.ECONNREFUSED):
+		log.Println("Connection to api server has been refused")
+		return ce.CONNECTIONREFUSEDERROR
+	case errors.As(err, &invalidID):
+		log.Println(invalidID)
+		return ce.USAGEERROR
+	case errors.As(err, &appErr) && appErr.Code != ce.SUCCESS:
+		if appErr.AppMessage == "Search title cannot be empty" {
+			log.Println("Search query cannot be empty")
+		} else {
+			log.Println(appErr.AppMessage)
+		}
+		return appErr.Code
+	case errors.Is(err, ce.CodeNotFound), errors.As(err, &httpErr) && httpErr.Code == http.StatusNotFound:
+		var apiErr *ce.APIError
+		if errors.As(err, &apiErr) {
+			log.Printf("Not found: %s\n", apiErr.Message)
+		} else {
+			log.Printf("Not found: %v\n", err)
+		}
+		return ce.NOTFOUNDERROR
+	default:
+		log.Printf("Unhandled error, %v\n", err)
+		ce.RootCause(err)
+		return ce.GENERICERROR
+	}
+}
+
+func clientOptions(url *url.URL, args CLIargs) *client.ImdbClientOptions {
+	options := &client.ImdbClientOptions{
+		ApiURL:    url,
+		Verbose:   true,
+		UserAgent: "imdblookup/0.1",
+		Retry:     client.DefaultRetryPolicy(),
+		CacheTTL:  cache.DefaultTTLPolicy(),
+		Offline:   args.offline,
 	}
 
+	if args.noCache {
+		return options
+	}
+
+	if args.cacheTTL > 0 {
+		options.CacheTTL = cache.TTLPolicy{Default: args.cacheTTL}
+	}
+
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		log.Printf("Response cache disabled: %v\n", err)
+		return options
+	}
+	disk, err := cache.NewDisk(dir)
+	if err != nil {
+		log.Printf("Response cache disabled: %v\n", err)
+		return options
+	}
+	// Offline, an expired answer is still better than none.
+	disk.ServeExpired = args.offline
+	options.Cache = disk
+	return options
+}
+
+// openStore opens the local mirror, which failing to open only costs the
+// read through.
+func openStore(args CLIargs) *store.Store {
+	if args.noStore {
+		return nil
+	}
+
+	path := args.storePath
+	if path == "" {
+		var err error
+		if path, err = store.DefaultPath(); err != nil {
+			log.Printf("Local store disabled: %v\n", err)
+			return nil
+		}
+	}
+	maxAge := args.storeMaxAge
+	if args.offline {
+		maxAge = 0
+	}
+	db, err := store.Open(path, maxAge)
+	if err != nil {
+		log.Printf("Local store disabled: %v\n", err)
+		return nil
+	}
+	return db
 }
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"testing"
 
@@ -12,6 +17,7 @@ import (
 func TestMain(t *testing.T) {
 	testCases := map[string]struct {
 		params   string
+		args     []string
 		expected string
 		exitcode int
 	}{
@@ -25,6 +31,127 @@ func TestMain(t *testing.T) {
 			params:   "Stranger Things",
 			// exitcode: ce.SUCCESS, Success should not pupulate err
 		},
+		"with search command": {
+			expected: `\(foobar\).*"Stranger Things"`,
+			args:     []string{"search", "--limit", "1", "Stranger", "Things"},
+		},
+		"with title command": {
+			expected: `\(tt0000001\).*"First"`,
+			args:     []string{"title", "tt0000001"},
+		},
+		"with title as json": {
+			expected: `(?s)\{\n  "directors": null,.*"id": "tt0000001",`,
+			args:     []string{"title", "--output", "json", "tt0000001"},
+		},
+		"with search as csv": {
+			expected: "id,originalTitle\nfoobar,Stranger Things\n",
+			args:     []string{"search", "--output", "csv", "--columns", "id,originalTitle", "Stranger Things"},
+		},
+		"with format template": {
+			expected: "tt0000001 First MOVIE\n",
+			args:     []string{"title", "--format", "{{.ID}} {{.PrimaryTitle}} {{upper .Type}}", "tt0000001"},
+		},
+		"with broken format template": {
+			expected: `invalid --format template`,
+			args:     []string{"title", "--format", "{{.ID", "tt0000001"},
+			exitcode: ce.USAGEERROR,
+		},
+		"with unknown output format": {
+			expected: `unknown output format "xml"`,
+			args:     []string{"title", "--output", "xml", "tt0000001"},
+			exitcode: ce.USAGEERROR,
+		},
+		"with episodes command": {
+			expected: `(?s)Season 1 \(3 episodes\)\n  E01  2016-07-15  8\.4  Pilot +\(tt1000011\).*Season 2 .*Specials \(1 episodes\)`,
+			args:     []string{"episodes", "tt0000002"},
+		},
+		"with heatmap command": {
+			expected: `(?s)S1 +8\.4\+ 8\.1\+ +8\.2\nS2 +7\.9= +- +7\.9\nS3 +- +- +-\n\nBest:  S01E01  8\.4  Pilot\nWorst: S02E01  7\.9  Return\nTrend: #-\. -0\.250 per episode \(falling\)`,
+			args:     []string{"heatmap", "tt0000002"},
+		},
+		"with heatmap bad color": {
+			expected: `unknown --color "rainbow"`,
+			args:     []string{"heatmap", "--color", "rainbow", "tt0000002"},
+			exitcode: ce.USAGEERROR,
+		},
+		"with path command": {
+			expected: `1 degrees, 2 lookups\n\(nm0000001\)\t-> "nm0000001"\n  in "First" \(tt0000001\)\n\(nm0000003\)`,
+			args:     []string{"path", "nm0000001", "nm0000003"},
+		},
+		"with path beyond budget": {
+			expected: `request budget exhausted after 1 lookups`,
+			args:     []string{"path", "--budget", "1", "nm0000001", "nm0000003"},
+			exitcode: ce.GENERICERROR,
+		},
+		"with sync without store": {
+			expected: `sync: the local store is disabled`,
+			args:     []string{"--no-store", "sync"},
+			exitcode: ce.USAGEERROR,
+		},
+		"with offline miss": {
+			expected: `offline, no cached answer for http://.*/titles/tt0000001`,
+			args:     []string{"--offline", "title", "tt0000001"},
+			exitcode: ce.OFFLINEERROR,
+		},
+		"with title not found": {
+			expected: `Not found: .*title not found`,
+			args:     []string{"title", "tt9999999"},
+			exitcode: ce.NOTFOUNDERROR,
+		},
+		"with scan command": {
+			expected: `(?s)matched +0\.88 +tests/testdata/library/First \(2000\)\.mkv +First \(tt0000001\)\nunmatched +0\.\d+ +tests/testdata/library/Heat \(1995\)\.mkv +-\nmatched +0\.80 +tests/testdata/library/Stranger\.Things\.S01E01\.1080p\.mkv +Stranger Things \(foobar\)\n3 files: 2 matched, 0 ambiguous, 1 unmatched`,
+			args:     []string{"scan", "tests/testdata/library"},
+		},
+		"with nfo dry run": {
+			expected: `(?s)Not writing a tvshow\.nfo in tests/testdata/shows,.*\ncreate\ttests/testdata/shows/First \(2000\)\.nfo\ncreate\ttests/testdata/shows/Second/tvshow\.nfo\ncreate\ttests/testdata/shows/Second/Season 01/Second\.S01E01\.1080p\.nfo\ncreate\ttests/testdata/shows/Second\.S01E02\.nfo\n$`,
+			args:     []string{"nfo", "--dry-run", "tests/testdata/shows"},
+		},
+		"with resolve command": {
+			expected: `0\.90 +tt0000001 +First\s*$`,
+			args:     []string{"resolve", "First"},
+		},
+		"with missing resolve title": {
+			expected: `usage: imdblookup resolve \[--year y\] \[--country c\] \[--language l\] \[--limit n\] <title>`,
+			args:     []string{"resolve"},
+			exitcode: ce.USAGEERROR,
+		},
+		"with rename plan": {
+			expected: `(?s)move\t\S*tests/testdata/shows/First \(2000\)\.mkv\n\t-> \S*tests/testdata/shows/First \[tt0000001\]/First\.mkv\nmove\t\S*tests/testdata/shows/Second/Season 01/Second\.S01E01\.1080p\.mkv\n\t-> \S*tests/testdata/shows/Second \[tt0000002\]/Season 01/Second S01E01 - Pilot\.mkv\nmove\t\S*tests/testdata/shows/Second\.S01E02\.mkv\n\t-> \S*tests/testdata/shows/Second \[tt0000002\]/Season 01/Second S01E02 - Middle\.mkv\n3 moves, 0 skipped`,
+			args:     []string{"rename", "plan", "tests/testdata/shows"},
+		},
+		"with unknown rename action": {
+			expected: `rename: unknown action "bogus", want plan, apply or undo`,
+			args:     []string{"rename", "bogus"},
+			exitcode: ce.USAGEERROR,
+		},
+		"with watchlist add": {
+			expected: `added\ttt0000001\nadded\ttt0000002`,
+			args:     []string{"watchlist", "add", "--tag", "team", "tt0000001", "https://www.imdb.com/title/tt0000002/"},
+		},
+		"with empty watchlist": {
+			expected: `0 titles`,
+			args:     []string{"watchlist", "list", "--sort", "rating"},
+		},
+		"with watchlist done of an unlisted title": {
+			expected: `1 titles could not be changed: title is not on the watchlist: tt0000001`,
+			args:     []string{"watchlist", "done", "tt0000001"},
+			exitcode: ce.GENERICERROR,
+		},
+		"with missing title id": {
+			expected: `usage: imdblookup title <title id>`,
+			args:     []string{"title"},
+			exitcode: ce.USAGEERROR,
+		},
+		"with malformed title id": {
+			expected: `invalid imdb id "tt12"`,
+			args:     []string{"title", "tt12"},
+			exitcode: ce.USAGEERROR,
+		},
+		"with unknown command": {
+			expected: `commands:`,
+			args:     []string{"frobnicate"},
+			exitcode: ce.USAGEERROR,
+		},
 		"with broken api": {
 			expected: `api url does not have scheme: 'localhost:22/'`,
 			params:   "Stranger Things",
@@ -33,7 +160,6 @@ func TestMain(t *testing.T) {
 	}
 	server := tests.SetupServer(t)
 	defer server.Close()
-	apiurl := server.URL
 
 	cmd := exec.Command("go", "build", "-o", "test_binary", ".")
 	err := cmd.Run()
@@ -43,12 +169,16 @@ func TestMain(t *testing.T) {
 	defer exec.Command("rm", "test_binary").Run()
 
 	for testName, testCase := range testCases {
-
+		apiurl := server.URL
 		if testName == "with broken api" {
 			apiurl = "localhost:22/"
 		}
 
 		cmd = exec.Command("./test_binary", "--query", testCase.params, "--api", apiurl)
+		if testCase.args != nil {
+			cmd = exec.Command("./test_binary", append([]string{"--api", apiurl}, testCase.args...)...)
+		}
+		cmd.Env = append(os.Environ(), "XDG_CACHE_HOME="+t.TempDir(), "XDG_CONFIG_HOME="+t.TempDir())
 		output, err := cmd.CombinedOutput()
 		exitCode := cmd.ProcessState.ExitCode()
 
@@ -66,3 +196,140 @@ func TestMain(t *testing.T) {
 		}
 	}
 }
+
+// TestNfo writes the NFOs of a copy of the shows library and reads them
+// back.
+func TestNfo(t *testing.T) {
+	server := tests.SetupServer(t)
+	defer server.Close()
+
+	binary := filepath.Join(t.TempDir(), "imdblookup")
+	if err := exec.Command("go", "build", "-o", binary, ".").Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	library := t.TempDir()
+	for _, video := range []string{"First (2000).mkv", "Second.S01E02.mkv", "Second/Season 01/Second.S01E01.1080p.mkv"} {
+		path := filepath.Join(library, video)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(binary, "--api", server.URL, "nfo", library)
+	cmd.Env = append(os.Environ(), "XDG_CACHE_HOME="+t.TempDir(), "XDG_CONFIG_HOME="+t.TempDir())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("TestNfo() = got (%v), want no error. Output:\n%s", err, output)
+	}
+
+	testCases := map[string]struct {
+		file     string
+		expected string
+	}{
+		"with a movie":         {file: "First (2000).nfo", expected: `(?s)<movie>.*<title>First</title>.*<uniqueid type="imdb" default="true">tt0000001</uniqueid>.*<actor>`},
+		"with a series":        {file: "Second/tvshow.nfo", expected: `(?s)<tvshow>.*<title>Second</title>.*<uniqueid type="imdb" default="true">tt0000002</uniqueid>`},
+		"with an episode":      {file: "Second/Season 01/Second.S01E01.1080p.nfo", expected: `(?s)<episodedetails>.*<title>Pilot</title>.*<showtitle>Second</showtitle>.*<season>1</season>.*<episode>1</episode>.*<aired>2016-07-15</aired>`},
+		"with a loose episode": {file: "Second.S01E02.nfo", expected: `(?s)<episodedetails>.*<title>Middle</title>.*<showtitle>Second</showtitle>.*<episode>2</episode>`},
+	}
+	if _, err := os.Stat(filepath.Join(library, "tvshow.nfo")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("TestNfo() = got (%v) for a tvshow.nfo in the library root, want none.", err)
+	}
+	for testName, testCase := range testCases {
+		data, err := os.ReadFile(filepath.Join(library, testCase.file))
+		if err != nil || !regexp.MustCompile(testCase.expected).Match(data) {
+			t.Errorf("TestNfo(%s) = got (%v), want (%s). File:\n%s", testName, err, testCase.expected, data)
+		}
+	}
+}
+
+// TestWatchlist changes one watchlist file through several runs, with
+// every action honouring --output.
+func TestWatchlist(t *testing.T) {
+	server := tests.SetupServer(t)
+	defer server.Close()
+
+	binary := filepath.Join(t.TempDir(), "imdblookup")
+	if err := exec.Command("go", "build", "-o", binary, ".").Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "watchlist.json")
+	env := append(os.Environ(), "XDG_CACHE_HOME="+t.TempDir(), "XDG_CONFIG_HOME="+t.TempDir())
+
+	steps := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"add", "tt0000001", "tt0000002"}, expected: `added\ttt0000001\nadded\ttt0000002\n`},
+		{args: []string{"done", "--output", "json", "tt0000002"}, expected: `(?s)^\[\s*{\s*"id": "tt0000002",\s*"added": "[^"]+",\s*"done": "[^"]+"\s*}\s*\]\n$`},
+		{args: []string{"done", "--undo", "--output", "csv", "--columns", "id", "tt0000002"}, expected: `^id\ntt0000002\n$`},
+		{args: []string{"remove", "--output", "json", "tt0000001"}, expected: `(?s)^\[\s*{\s*"id": "tt0000001",\s*"added": "[^"]+"\s*}\s*\]\n$`},
+		{args: []string{"list", "--status", "all"}, expected: `\[ \]\s+tt0000002\s+Second\s.*\n1 titles\n`},
+	}
+	for _, step := range steps {
+		cmd := exec.Command(binary, append([]string{"--api", server.URL, "watchlist", "--file", file}, step.args...)...)
+		cmd.Env = env
+		output, err := cmd.Output()
+		if err != nil || !regexp.MustCompile(step.expected).Match(output) {
+			t.Fatalf("TestWatchlist(%v) = got (%v), want (%s). Output:\n%s", step.args, err, step.expected, output)
+		}
+	}
+}
+
+// TestOffline fetches records online, makes them stale and expects them
+// back offline anyway.
+func TestOffline(t *testing.T) {
+	server := tests.SetupServer(t)
+	defer server.Close()
+
+	binary := filepath.Join(t.TempDir(), "imdblookup")
+	if err := exec.Command("go", "build", "-o", binary, ".").Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	db := filepath.Join(t.TempDir(), "imdblookup.db")
+	env := append(os.Environ(), "XDG_CACHE_HOME="+t.TempDir(), "XDG_CONFIG_HOME="+t.TempDir())
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binary, append([]string{"--api", server.URL}, args...)...)
+		cmd.Env = env
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	for _, args := range [][]string{
+		{"--no-cache", "--store", db, "title", "tt0000001"},
+		{"--no-cache", "--store", db, "credits", "tt0000001"},
+		{"--no-cache", "--store", db, "episodes", "tt0000002"},
+		{"--no-store", "--cache-ttl", "1ns", "title", "tt0000001"},
+	} {
+		if output, err := run(args...); err != nil {
+			t.Fatalf("TestOffline(%v) = got (%v) online, want no error. Output:\n%s", args, err, output)
+		}
+	}
+	stale, err := sql.Open("sqlite", db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"titles", "credits", "episodes", "listings"} {
+		if _, err := stale.Exec(`UPDATE ` + table + ` SET fetched_at = 0`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale.Close()
+
+	testCases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"with a stale title":    {args: []string{"--no-cache", "--store", db, "title", "tt0000001"}, expected: `\(tt0000001\).*"First"`},
+		"with stale credits":    {args: []string{"--no-cache", "--store", db, "credits", "tt0000001"}, expected: `\(nm0000001\)`},
+		"with stale episodes":   {args: []string{"--no-cache", "--store", db, "episodes", "tt0000002"}, expected: `(?s)Season 1 .*Season 3 \(0 episodes\)`},
+		"with an expired cache": {args: []string{"--no-store", "title", "tt0000001"}, expected: `\(tt0000001\)`},
+	}
+	for testName, testCase := range testCases {
+		output, err := run(append([]string{"--offline"}, testCase.args...)...)
+		if err != nil || !regexp.MustCompile(testCase.expected).MatchString(output) {
+			t.Errorf("TestOffline(%s) = got (%v), want (%s). Output:\n%s", testName, err, testCase.expected, output)
+		}
+	}
+}
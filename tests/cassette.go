@@ -0,0 +1,313 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces every scrubbed secret in a cassette.
+const Redacted = "REDACTED"
+
+// RecordedRequest keeps what a replayed request is matched on. Query is
+// canonical, keys and the values of every key sorted.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette is the file a Recorder writes and a Replayer reads.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("reading cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+func (cassette *Cassette) Save(path string) error {
+	// Queries and bodies stay readable without the HTML escaping of & < >.
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(cassette); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data.Bytes(), 0o644)
+}
+
+// Scrubber blanks secrets out of interactions before they are written, and
+// out of requests before they are matched so both sides compare equal.
+type Scrubber struct {
+	// Headers are dropped from requests and responses, matched without
+	// regard to case.
+	Headers []string
+	// QueryParams have their values replaced by Redacted.
+	QueryParams []string
+	// Body, when set, rewrites every response body.
+	Body func(body []byte) []byte
+}
+
+// DefaultScrubber covers the usual credentials, leaving pageToken and the
+// other api parameters alone. Date goes too, so recording again only
+// changes a cassette where the answers did.
+func DefaultScrubber() Scrubber {
+	return Scrubber{
+		Headers:     []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Date"},
+		QueryParams: []string{"key", "apiKey", "api_key", "access_token", "token", "secret"},
+	}
+}
+
+func (scrubber Scrubber) header(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range scrubber.Headers {
+		header.Del(name)
+	}
+	return header
+}
+
+func (scrubber Scrubber) query(query url.Values) string {
+	query = cloneValues(query)
+	for key := range query {
+		if slices.ContainsFunc(scrubber.QueryParams, func(secret string) bool { return strings.EqualFold(secret, key) }) {
+			query[key] = []string{Redacted}
+		}
+	}
+	for _, values := range query {
+		slices.Sort(values)
+	}
+	return query.Encode()
+}
+
+func cloneValues(values url.Values) url.Values {
+	clone := url.Values{}
+	for key, v := range values {
+		clone[key] = slices.Clone(v)
+	}
+	return clone
+}
+
+func (scrubber Scrubber) request(req *http.Request) RecordedRequest {
+	return RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  scrubber.query(req.URL.Query()),
+		Header: scrubber.header(req.Header),
+	}
+}
+
+// Recorder sends requests through Base and keeps every exchange, to be
+// written out with Save.
+type Recorder struct {
+	Base     http.RoundTripper
+	Scrubber Scrubber
+	path     string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+func NewRecorder(path string, base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{Base: base, Scrubber: DefaultScrubber(), path: path}
+}
+
+func (recorder *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := recorder.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if recorder.Scrubber.Body != nil {
+		body = recorder.Scrubber.Body(body)
+	}
+	interaction := &Interaction{
+		Request: recorder.Scrubber.request(req),
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: recorder.Scrubber.header(resp.Header),
+			Body:   string(body),
+		},
+	}
+
+	recorder.mu.Lock()
+	recorder.cassette.Interactions = append(recorder.cassette.Interactions, interaction)
+	recorder.mu.Unlock()
+	return resp, nil
+}
+
+// Save writes everything recorded so far to the cassette file.
+func (recorder *Recorder) Save() error {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.cassette.Save(recorder.path)
+}
+
+type Match int
+
+const (
+	// MatchStrict wants the method, path and query to be the same, and
+	// replays every interaction once, in recorded order.
+	MatchStrict Match = iota
+	// MatchLenient wants the same method and path, picks the interaction
+	// sharing the most query parameters and replays it as often as asked,
+	// preferring those not replayed yet.
+	MatchLenient
+)
+
+// NoInteractionError is returned for a request the cassette has no answer
+// for.
+type NoInteractionError struct {
+	Method string
+	URL    string
+}
+
+func (e *NoInteractionError) Error() string {
+	return fmt.Sprintf("cassette has no interaction for %s %s", e.Method, e.URL)
+}
+
+// Replayer answers requests from a cassette without any network.
+type Replayer struct {
+	Match    Match
+	Scrubber Scrubber
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+func NewReplayer(path string, match Match) (*Replayer, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Replayer{Match: match, Scrubber: DefaultScrubber(), cassette: cassette, used: make([]bool, len(cassette.Interactions))}, nil
+}
+
+func (replayer *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded := replayer.Scrubber.request(req)
+
+	replayer.mu.Lock()
+	interaction := replayer.find(recorded)
+	replayer.mu.Unlock()
+	if interaction == nil {
+		return nil, &NoInteractionError{Method: req.Method, URL: req.URL.String()}
+	}
+
+	header := interaction.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+		StatusCode:    interaction.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (replayer *Replayer) find(request RecordedRequest) *Interaction {
+	best, bestScore := -1, -1
+	for i, interaction := range replayer.cassette.Interactions {
+		if interaction.Request.Method != request.Method || interaction.Request.Path != request.Path {
+			continue
+		}
+		if replayer.Match == MatchStrict {
+			if !replayer.used[i] && interaction.Request.Query == request.Query {
+				replayer.used[i] = true
+				return interaction
+			}
+			continue
+		}
+		// Among equally good matches the ones not replayed yet go first, so
+		// repeated requests walk through what was recorded for them.
+		score := 2 * sharedParams(interaction.Request.Query, request.Query)
+		if !replayer.used[i] {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	replayer.used[best] = true
+	return replayer.cassette.Interactions[best]
+}
+
+// sharedParams counts the key=value pairs two canonical queries have in
+// common, an identical query always scoring highest.
+func sharedParams(a, b string) int {
+	if a == b {
+		return 1 << 20
+	}
+	pairs := strings.Split(a, "&")
+	score := 0
+	for pair := range strings.SplitSeq(b, "&") {
+		if pair != "" && slices.Contains(pairs, pair) {
+			score++
+		}
+	}
+	return score
+}
+
+// Unused lists the interactions never replayed, for tests checking every
+// recorded request is still made.
+func (replayer *Replayer) Unused() []*Interaction {
+	replayer.mu.Lock()
+	defer replayer.mu.Unlock()
+	var unused []*Interaction
+	for i, interaction := range replayer.cassette.Interactions {
+		if !replayer.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
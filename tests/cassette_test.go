@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, transport http.RoundTripper, url string) (int, string, error) {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatalf("building request for %s: %v", url, err)
+	}
+	req.Header.Set("Authorization", "Bearer hunter2")
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), nil
+}
+
+func recordCassette(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprintf(w, "%s page %s", r.URL.Path, cmp.Or(r.URL.Query().Get("pageToken"), "1"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder := NewRecorder(path, nil)
+	for _, url := range []string{"/titles?types=MOVIE&key=hunter2&genres=Drama", "/titles?types=MOVIE&pageToken=2", "/titles?types=MOVIE&pageToken=2", "/missing"} {
+		if _, _, err := get(t, recorder, server.URL+url); err != nil {
+			t.Fatalf("recording %s: %v", url, err)
+		}
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("saving cassette: %v", err)
+	}
+	return path
+}
+
+func TestRecorderScrubsSecrets(t *testing.T) {
+	data, err := os.ReadFile(recordCassette(t))
+	if err != nil {
+		t.Fatalf("TestRecorderScrubsSecrets = unexpected error (%v)", err)
+	}
+	for _, secret := range []string{"Bearer hunter2", "key=hunter2", "session=secret", `"Date"`} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("TestRecorderScrubsSecrets = cassette still contains (%s).", secret)
+		}
+	}
+	if !strings.Contains(string(data), `"query": "genres=Drama&key=REDACTED&types=MOVIE"`) {
+		t.Errorf("TestRecorderScrubsSecrets = got (%s), want a sorted query with the key redacted.", data)
+	}
+}
+
+func TestReplayer(t *testing.T) {
+	path := recordCassette(t)
+
+	testCases := map[string]struct {
+		match    Match
+		urls     []string
+		expected []string
+		unused   int
+	}{
+		"strict": {
+			match:    MatchStrict,
+			urls:     []string{"/titles?genres=Drama&types=MOVIE&key=other", "/titles?pageToken=2&types=MOVIE", "/titles?pageToken=2&types=MOVIE", "/titles?pageToken=2&types=MOVIE", "/titles?types=TV_SERIES"},
+			expected: []string{"200 /titles page 1", "200 /titles page 2", "200 /titles page 2", "no interaction", "no interaction"},
+			unused:   1,
+		},
+		"lenient": {
+			match:    MatchLenient,
+			urls:     []string{"/titles?pageToken=2&types=MOVIE", "/titles?pageToken=2&types=MOVIE", "/titles?pageToken=2&types=MOVIE", "/titles?genres=Drama", "/missing?page=3", "/names"},
+			expected: []string{"200 /titles page 2", "200 /titles page 2", "200 /titles page 2", "200 /titles page 1", "404 /missing page 1", "no interaction"},
+			unused:   0,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		replayer, err := NewReplayer(path, testCase.match)
+		if err != nil {
+			t.Fatalf("TestReplayer(%s) = unexpected error (%v)", testName, err)
+		}
+		for i, url := range testCase.urls {
+			status, body, err := get(t, replayer, "http://replayed.invalid"+url)
+			got := fmt.Sprintf("%d %s", status, body)
+			var noInteraction *NoInteractionError
+			if errors.As(err, &noInteraction) {
+				got = "no interaction"
+			}
+			if got != testCase.expected[i] {
+				t.Errorf("TestReplayer(%s, %s) = got (%s), want (%s).", testName, url, got, testCase.expected[i])
+			}
+		}
+		if unused := len(replayer.Unused()); unused != testCase.unused {
+			t.Errorf("TestReplayer(%s) = got %d unused interactions, want %d.", testName, unused, testCase.unused)
+		}
+	}
+}
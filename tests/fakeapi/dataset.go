@@ -0,0 +1,254 @@
+package fakeapi
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// Dataset is everything the fake serves. Titles are kept in popularity
+// order and Names in STARmeter order, the per-id maps holding the
+// resources listed under /titles/{id}/... and /names/{id}/....
+type Dataset struct {
+	Titles []*models.ImdbapiTitle
+	Names  []*models.ImdbapiName
+
+	// Credits are keyed by title id and carry their Name, filmographies
+	// being derived from them.
+	Credits          map[string][]*models.ImdbapiCredit
+	Episodes         map[string][]*models.ImdbapiEpisode
+	ReleaseDates     map[string][]*models.ImdbapiReleaseDate
+	AKAs             map[string][]*models.ImdbapiAKA
+	Images           map[string][]*models.ImdbapiImage
+	Videos           map[string][]*models.ImdbapiVideo
+	AwardNominations map[string][]*models.ImdbapiAwardNomination
+	ParentsGuide     map[string][]*models.ImdbapiParentsGuide
+	Certificates     map[string][]*models.ImdbapiCertificate
+	CompanyCredits   map[string][]*models.ImdbapiCompanyCredit
+	BoxOffice        map[string]*models.ImdbapiBoxOffice
+	Relationships    map[string][]*models.ImdbapiNameRelationship
+	Trivia           map[string][]*models.ImdbapiNameTrivia
+	Interests        []*models.ImdbapiInterestCategory
+}
+
+// Sizes of a seeded dataset, enough for /titles to span several pages.
+const (
+	SeedTitles = 120
+	SeedNames  = 80
+)
+
+var (
+	adjectives = []string{"Silent", "Crimson", "Last", "Hidden", "Broken", "Golden", "Midnight", "Lost", "Wild", "Iron", "Electric", "Quiet", "Distant", "Burning", "Paper", "Northern"}
+	nouns      = []string{"Harbor", "Empire", "Witness", "Garden", "Signal", "Frontier", "Orchard", "Voyage", "Kingdom", "Machine", "River", "Letter", "Summer", "Mirror", "Station", "Tide"}
+	firstNames = []string{"Ada", "Boris", "Clara", "Dev", "Elena", "Farid", "Grace", "Hugo", "Ines", "Jonas", "Kira", "Liam", "Maya", "Noah", "Olga", "Pablo"}
+	lastNames  = []string{"Adler", "Brooks", "Castillo", "Dumont", "Eriksen", "Fontaine", "Garcia", "Hale", "Ito", "Jensen", "Kowalski", "Laurent", "Moreau", "Nakamura", "Okafor", "Petrov"}
+	genres     = []string{"Drama", "Comedy", "Crime", "Thriller", "Sci-Fi", "Romance", "Horror", "Documentary", "Animation", "Adventure"}
+	countries  = []*models.ImdbapiCountry{{Code: "US", Name: "United States"}, {Code: "GB", Name: "United Kingdom"}, {Code: "FR", Name: "France"}, {Code: "DE", Name: "Germany"}, {Code: "JP", Name: "Japan"}, {Code: "ES", Name: "Spain"}, {Code: "KR", Name: "South Korea"}}
+	languages  = []*models.ImdbapiLanguage{{Code: "en", Name: "English"}, {Code: "fr", Name: "French"}, {Code: "de", Name: "German"}, {Code: "ja", Name: "Japanese"}, {Code: "es", Name: "Spanish"}, {Code: "ko", Name: "Korean"}}
+	// titleTypes repeats MOVIE so most titles are films.
+	titleTypes     = []string{"MOVIE", "MOVIE", "MOVIE", "TV_SERIES", "TV_SERIES", "TV_MINI_SERIES", "TV_MOVIE", "SHORT", "VIDEO"}
+	castCategories = []string{"actor", "actress"}
+	crewCategories = []string{"director", "writer", "producer", "composer", "cinematographer", "editor"}
+)
+
+// TitleID and NameID are the ids of the n-th seeded title and name,
+// counting from zero, numbered away from the ids of the legacy mock.
+func TitleID(n int) string { return fmt.Sprintf("tt%07d", 2000001+n) }
+func NameID(n int) string  { return fmt.Sprintf("nm%07d", 2000001+n) }
+
+func pick[T any](r *rand.Rand, items []T) T {
+	return items[r.IntN(len(items))]
+}
+
+func pickN[T any](r *rand.Rand, items []T, n int) []T {
+	picked := slices.Clone(items)
+	r.Shuffle(len(picked), func(i, j int) { picked[i], picked[j] = picked[j], picked[i] })
+	return picked[:min(n, len(picked))]
+}
+
+func date(r *rand.Rand, year int32) *models.ImdbapiPrecisionDate {
+	return &models.ImdbapiPrecisionDate{Year: year, Month: int32(1 + r.IntN(12)), Day: int32(1 + r.IntN(28))}
+}
+
+func image(r *rand.Rand, id, kind string) *models.ImdbapiImage {
+	return &models.ImdbapiImage{URL: fmt.Sprintf("https://images.fakeimdbapi.invalid/%s/%s-%d.jpg", id, kind, r.IntN(1000)), Type: kind, Width: 1000, Height: 1500}
+}
+
+// Seed builds the same dataset for the same seed.
+func Seed(seed uint64) *Dataset {
+	r := rand.New(rand.NewPCG(seed, seed^0x5eed))
+	data := &Dataset{
+		Credits:          map[string][]*models.ImdbapiCredit{},
+		Episodes:         map[string][]*models.ImdbapiEpisode{},
+		ReleaseDates:     map[string][]*models.ImdbapiReleaseDate{},
+		AKAs:             map[string][]*models.ImdbapiAKA{},
+		Images:           map[string][]*models.ImdbapiImage{},
+		Videos:           map[string][]*models.ImdbapiVideo{},
+		AwardNominations: map[string][]*models.ImdbapiAwardNomination{},
+		ParentsGuide:     map[string][]*models.ImdbapiParentsGuide{},
+		Certificates:     map[string][]*models.ImdbapiCertificate{},
+		CompanyCredits:   map[string][]*models.ImdbapiCompanyCredit{},
+		BoxOffice:        map[string]*models.ImdbapiBoxOffice{},
+		Relationships:    map[string][]*models.ImdbapiNameRelationship{},
+		Trivia:           map[string][]*models.ImdbapiNameTrivia{},
+	}
+
+	for i, category := range []string{"Genre", "Mood"} {
+		interests := &models.ImdbapiInterestCategory{Category: category}
+		names := genres
+		if category == "Mood" {
+			names = []string{"Feel-Good", "Dark", "Slow Burn", "Epic"}
+		}
+		for j, name := range names {
+			interests.Interests = append(interests.Interests, &models.ImdbapiInterest{
+				ID:          fmt.Sprintf("in%07d", 1+i*100+j),
+				Name:        name,
+				Description: name + " titles.",
+			})
+		}
+		data.Interests = append(data.Interests, interests)
+	}
+
+	for n := range SeedNames {
+		id := NameID(n)
+		born := int32(1940 + r.IntN(60))
+		name := &models.ImdbapiName{
+			ID:                 id,
+			DisplayName:        pick(r, firstNames) + " " + pick(r, lastNames),
+			BirthDate:          date(r, born),
+			BirthLocation:      pick(r, countries).Name,
+			HeightCm:           int32(150 + r.IntN(45)),
+			PrimaryImage:       image(r, id, "primary"),
+			PrimaryProfessions: pickN(r, append(slices.Clone(castCategories), crewCategories...), 1+r.IntN(2)),
+			MeterRanking:       &models.ImdbapiNameMeterRanking{CurrentRank: int32(n + 1)},
+		}
+		name.Biography = name.DisplayName + " is a fictional person of the fake imdbapi dataset."
+		data.Names = append(data.Names, name)
+		data.Images[id] = []*models.ImdbapiImage{name.PrimaryImage, image(r, id, "event"), image(r, id, "still_frame")}
+		for k := range 1 + r.IntN(3) {
+			data.Trivia[id] = append(data.Trivia[id], &models.ImdbapiNameTrivia{ID: fmt.Sprintf("tr%s%02d", id[2:], k), Text: fmt.Sprintf("%s once did something memorable, #%d.", name.DisplayName, k+1), InterestCount: int32(r.IntN(500)), VoteCount: int32(r.IntN(600))})
+		}
+	}
+	for n, name := range data.Names {
+		if n%4 == 0 && n+1 < len(data.Names) {
+			spouse := data.Names[n+1]
+			data.Relationships[name.ID] = append(data.Relationships[name.ID], &models.ImdbapiNameRelationship{Name: &models.ImdbapiName{ID: spouse.ID, DisplayName: spouse.DisplayName}, RelationType: "spouse"})
+			data.Relationships[spouse.ID] = append(data.Relationships[spouse.ID], &models.ImdbapiNameRelationship{Name: &models.ImdbapiName{ID: name.ID, DisplayName: name.DisplayName}, RelationType: "spouse"})
+		}
+	}
+
+	for n := range SeedTitles {
+		id := TitleID(n)
+		year := int32(1970 + r.IntN(55))
+		title := &models.ImdbapiTitle{
+			ID:              id,
+			Type:            pick(r, titleTypes),
+			PrimaryTitle:    "The " + pick(r, adjectives) + " " + pick(r, nouns),
+			StartYear:       year,
+			RuntimeSeconds:  int32(60 * (80 + r.IntN(80))),
+			Genres:          pickN(r, genres, 1+r.IntN(3)),
+			OriginCountries: pickN(r, countries, 1+r.IntN(2)),
+			SpokenLanguages: pickN(r, languages, 1+r.IntN(2)),
+			Rating:          &models.ImdbapiRating{AggregateRating: float32(20+r.IntN(78)) / 10, VoteCount: int32(r.IntN(2000000))},
+			Metacritic:      &models.ImdbapiMetacritic{Score: int32(20 + r.IntN(80)), ReviewCount: int32(r.IntN(60))},
+			PrimaryImage:    image(r, id, "poster"),
+		}
+		title.OriginalTitle = title.PrimaryTitle
+		title.Plot = "A fictional " + title.Genres[0] + " story about " + title.PrimaryTitle + "."
+		for _, genre := range title.Genres {
+			title.Interests = append(title.Interests, data.Interests[0].Interests[slices.Index(genres, genre)])
+		}
+		if title.Type == "TV_SERIES" || title.Type == "TV_MINI_SERIES" {
+			title.RuntimeSeconds = int32(60 * (25 + r.IntN(35)))
+			title.EndYear = year + int32(1+r.IntN(6))
+			data.Episodes[id] = seedEpisodes(r, id, title)
+		}
+		data.Titles = append(data.Titles, title)
+
+		seedCredits(r, data, title)
+		data.ReleaseDates[id] = []*models.ImdbapiReleaseDate{{Country: title.OriginCountries[0], ReleaseDate: date(r, year), Attributes: []string{"premiere"}}}
+		for _, country := range pickN(r, countries, 2) {
+			data.ReleaseDates[id] = append(data.ReleaseDates[id], &models.ImdbapiReleaseDate{Country: country, ReleaseDate: date(r, year+int32(r.IntN(2)))})
+			data.AKAs[id] = append(data.AKAs[id], &models.ImdbapiAKA{Country: country, Text: title.PrimaryTitle + " (" + country.Code + ")"})
+			data.Certificates[id] = append(data.Certificates[id], &models.ImdbapiCertificate{Country: country, Rating: pick(r, []string{"G", "PG", "12", "15", "R", "18"})})
+		}
+		data.Images[id] = []*models.ImdbapiImage{title.PrimaryImage, image(r, id, "still_frame"), image(r, id, "behind_the_scenes")}
+		data.Videos[id] = []*models.ImdbapiVideo{{ID: "vi" + id[2:], Type: "trailer", Name: "Official Trailer", PrimaryImage: image(r, id, "thumbnail"), RuntimeSeconds: int32(60 + r.IntN(120))}}
+		data.ParentsGuide[id] = []*models.ImdbapiParentsGuide{{Category: "VIOLENCE", SeverityBreakdowns: []*models.ImdbapiParentsGuideSeverity{{SeverityLevel: pick(r, []string{"none", "mild", "moderate", "severe"}), VoteCount: int32(r.IntN(300))}}}}
+		data.CompanyCredits[id] = []*models.ImdbapiCompanyCredit{{Company: &models.ImdbapiCompany{ID: fmt.Sprintf("co%07d", 1+r.IntN(50)), Name: pick(r, nouns) + " Pictures"}, Category: "production"}}
+		if title.Type == "MOVIE" {
+			budget := 1 + r.IntN(200)
+			data.BoxOffice[id] = &models.ImdbapiBoxOffice{
+				ProductionBudget: &models.ImdbapiMoney{Amount: strconv.Itoa(budget * 1000000), Currency: "USD"},
+				DomesticGross:    &models.ImdbapiMoney{Amount: strconv.Itoa(budget * (500000 + r.IntN(2000000))), Currency: "USD"},
+				WorldwideGross:   &models.ImdbapiMoney{Amount: strconv.Itoa(budget * (1000000 + r.IntN(4000000))), Currency: "USD"},
+			}
+		}
+		if r.IntN(3) == 0 {
+			credits := data.Credits[id]
+			data.AwardNominations[id] = []*models.ImdbapiAwardNomination{{
+				Event:    &models.ImdbapiEvent{ID: fmt.Sprintf("ev%07d", 1+r.IntN(5)), Name: pick(r, []string{"Academy Awards", "Golden Globes", "BAFTA Awards", "Cannes Film Festival"})},
+				Year:     year + 1,
+				Category: "Best Picture",
+				IsWinner: r.IntN(3) == 0,
+				Nominees: []*models.ImdbapiName{credits[0].Name},
+			}}
+		}
+	}
+	return data
+}
+
+func seedCredits(r *rand.Rand, data *Dataset, title *models.ImdbapiTitle) {
+	people := pickN(r, data.Names, 3+r.IntN(10))
+	for i, name := range people {
+		credit := &models.ImdbapiCredit{Name: &models.ImdbapiName{ID: name.ID, DisplayName: name.DisplayName, PrimaryImage: name.PrimaryImage}}
+		if i < 2 || r.IntN(3) > 0 {
+			credit.Category = pick(r, castCategories)
+			credit.Characters = []string{pick(r, firstNames)}
+		} else {
+			credit.Category = pick(r, crewCategories)
+		}
+		data.Credits[title.ID] = append(data.Credits[title.ID], credit)
+
+		stub := &models.ImdbapiName{ID: name.ID, DisplayName: name.DisplayName}
+		switch credit.Category {
+		case "director":
+			title.Directors = append(title.Directors, stub)
+		case "writer":
+			title.Writers = append(title.Writers, stub)
+		case "actor", "actress":
+			if len(title.Stars) < 3 {
+				title.Stars = append(title.Stars, stub)
+			}
+		}
+	}
+}
+
+func seedEpisodes(r *rand.Rand, seriesID string, title *models.ImdbapiTitle) []*models.ImdbapiEpisode {
+	var episodes []*models.ImdbapiEpisode
+	seasons := 1 + r.IntN(4)
+	if title.Type == "TV_MINI_SERIES" {
+		seasons = 1
+	}
+	for season := 1; season <= seasons; season++ {
+		for number := 1; number <= 6+r.IntN(5); number++ {
+			episodes = append(episodes, &models.ImdbapiEpisode{
+				ID:             fmt.Sprintf("tt%02d%02d%s", season, number, seriesID[5:]),
+				Season:         strconv.Itoa(season),
+				EpisodeNumber:  int32(number),
+				Title:          fmt.Sprintf("%s %d", pick(r, nouns), number),
+				Plot:           "Things happen in " + title.PrimaryTitle + ".",
+				RuntimeSeconds: title.RuntimeSeconds,
+				ReleaseDate:    date(r, title.StartYear+int32(season-1)),
+				Rating:         &models.ImdbapiRating{AggregateRating: float32(55+r.IntN(45)) / 10, VoteCount: int32(r.IntN(20000))},
+			})
+		}
+	}
+	if r.IntN(3) == 0 {
+		episodes = append(episodes, &models.ImdbapiEpisode{ID: "tt9900" + seriesID[5:], Season: "Specials", Title: "Behind the Scenes"})
+	}
+	return episodes
+}
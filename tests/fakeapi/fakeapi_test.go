@@ -0,0 +1,177 @@
+package fakeapi_test
+
+import (
+	"encoding/json"
+	e "errors"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/foursixnine/imdblookup/internal/client"
+	"github.com/foursixnine/imdblookup/internal/errors"
+	"github.com/foursixnine/imdblookup/internal/ids"
+	"github.com/foursixnine/imdblookup/models"
+	"github.com/foursixnine/imdblookup/tests/fakeapi"
+)
+
+func newClient(t *testing.T, data *fakeapi.Dataset) *client.ImdbClient {
+	t.Helper()
+	server := httptest.NewServer(fakeapi.New(data))
+	t.Cleanup(server.Close)
+	url, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal("Failed to parse url")
+	}
+	return client.New(url)
+}
+
+func TestSeed(t *testing.T) {
+	if !reflect.DeepEqual(fakeapi.Seed(7), fakeapi.Seed(7)) {
+		t.Error("TestSeed = the same seed gave two datasets, want one.")
+	}
+	if reflect.DeepEqual(fakeapi.Seed(7).Titles, fakeapi.Seed(8).Titles) {
+		t.Error("TestSeed = different seeds gave the same titles.")
+	}
+}
+
+func TestListTitles(t *testing.T) {
+	data := fakeapi.Seed(1)
+	imdbClient := newClient(t, data)
+
+	testCases := map[string]struct {
+		filter *client.ListTitlesFilter
+		keep   func(*models.ImdbapiTitle) bool
+		order  func(a, b *models.ImdbapiTitle) bool
+	}{
+		"with every page": {
+			filter: &client.ListTitlesFilter{},
+			keep:   func(*models.ImdbapiTitle) bool { return true },
+		},
+		"with type and year": {
+			filter: &client.ListTitlesFilter{Types: []models.ImdbapiTitleType{models.ImdbapiTitleTypeTVSERIES}, StartYear: 1990},
+			keep: func(title *models.ImdbapiTitle) bool {
+				return title.Type == "TV_SERIES" && title.StartYear >= 1990
+			},
+		},
+		"with rating descending": {
+			filter: &client.ListTitlesFilter{MinAggregateRating: 6, SortBy: models.ImdbapiTitleSortBySORTBYUSERRATING, SortOrder: models.ImdbapiSortOrderDESC},
+			keep:   func(title *models.ImdbapiTitle) bool { return title.Rating.AggregateRating >= 6 },
+			order: func(a, b *models.ImdbapiTitle) bool {
+				return a.Rating.AggregateRating >= b.Rating.AggregateRating
+			},
+		},
+		"with year ascending": {
+			filter: &client.ListTitlesFilter{SortBy: models.ImdbapiTitleSortBySORTBYYEAR},
+			keep:   func(*models.ImdbapiTitle) bool { return true },
+			order:  func(a, b *models.ImdbapiTitle) bool { return a.StartYear <= b.StartYear },
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			titles, err := imdbClient.ListTitlesContext(t.Context(), testCase.filter)
+			if err != nil {
+				t.Fatalf("TestListTitles(%s) = unexpected error (%v)", testName, err)
+			}
+
+			want := 0
+			for _, title := range data.Titles {
+				if testCase.keep(title) {
+					want++
+				}
+			}
+			if len(titles) != want || want == 0 {
+				t.Errorf("TestListTitles(%s) = got (%d) titles, want (%d).", testName, len(titles), want)
+			}
+			for i := 1; testCase.order != nil && i < len(titles); i++ {
+				if !testCase.order(titles[i-1], titles[i]) {
+					t.Errorf("TestListTitles(%s) = titles %d and %d are out of order.", testName, i-1, i)
+				}
+			}
+		})
+	}
+}
+
+func TestRPCErrors(t *testing.T) {
+	imdbClient := newClient(t, fakeapi.Seed(1))
+
+	testCases := map[string]struct {
+		path   string
+		params []client.QueryParameters
+		code   errors.Code
+	}{
+		"with unknown title":     {path: "titles/tt9999999", code: errors.CodeNotFound},
+		"with malformed title":   {path: "titles/foo", code: errors.CodeInvalidArgument},
+		"with unknown route":     {path: "nowhere", code: errors.CodeNotFound},
+		"with unknown sort":      {path: "titles", params: []client.QueryParameters{{Key: "sortBy", Value: "SORT_BY_MOOD"}}, code: errors.CodeInvalidArgument},
+		"with page size too big": {path: "titles/tt2000001/credits", params: []client.QueryParameters{{Key: "pageSize", Value: "51"}}, code: errors.CodeInvalidArgument},
+		"with garbage token":     {path: "titles", params: []client.QueryParameters{{Key: "pageToken", Value: "garbage"}}, code: errors.CodeInvalidArgument},
+		"with too many ids": {path: "titles:batchGet", params: []client.QueryParameters{
+			{Key: "titleIds", Value: "tt2000001"}, {Key: "titleIds", Value: "tt2000002"}, {Key: "titleIds", Value: "tt2000003"},
+			{Key: "titleIds", Value: "tt2000004"}, {Key: "titleIds", Value: "tt2000005"}, {Key: "titleIds", Value: "tt2000006"},
+		}, code: errors.CodeInvalidArgument},
+		"with empty search": {path: "search/titles", params: []client.QueryParameters{{Key: "query", Value: " "}}, code: errors.CodeInvalidArgument},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			_, err := imdbClient.Get(testCase.path, &testCase.params)
+			var apiErr *errors.APIError
+			if !e.As(err, &apiErr) || apiErr.Code != testCase.code || len(apiErr.Details) == 0 {
+				t.Errorf("TestRPCErrors(%s) = got (%v), want an APIError with code (%v) and details.", testName, err, testCase.code)
+			}
+		})
+	}
+}
+
+func TestPageTokenBelongsToQuery(t *testing.T) {
+	imdbClient := newClient(t, fakeapi.Seed(1))
+
+	body, err := imdbClient.Get("titles", &[]client.QueryParameters{})
+	var first models.ImdbapiListTitlesResponse
+	if err != nil || json.Unmarshal(body, &first) != nil || first.NextPageToken == "" {
+		t.Fatalf("TestPageTokenBelongsToQuery = got (%v), want a first page with a token.", err)
+	}
+
+	testCases := map[string]struct {
+		params []client.QueryParameters
+		error  bool
+	}{
+		"with the same query": {params: []client.QueryParameters{{Key: "pageToken", Value: first.NextPageToken}}},
+		"with another filter": {params: []client.QueryParameters{{Key: "pageToken", Value: first.NextPageToken}, {Key: "types", Value: "MOVIE"}}, error: true},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			_, err := imdbClient.Get("titles", &testCase.params)
+			if (err != nil) != testCase.error || (err != nil && !e.Is(err, errors.CodeInvalidArgument)) {
+				t.Errorf("TestPageTokenBelongsToQuery(%s) = got (%v), want error (%v).", testName, err, testCase.error)
+			}
+		})
+	}
+}
+
+func TestBatchGet(t *testing.T) {
+	data := fakeapi.Seed(1)
+	imdbClient := newClient(t, data)
+
+	requested := []ids.TitleID{ids.TitleID(data.Titles[3].ID), "tt9999999", ids.TitleID(data.Titles[0].ID)}
+	results, err := imdbClient.BatchGetTitles(t.Context(), requested)
+	if err != nil {
+		t.Fatalf("TestBatchGet = unexpected error (%v)", err)
+	}
+
+	var found []string
+	for _, result := range results {
+		if result.Err == nil {
+			found = append(found, result.Value.ID)
+		}
+	}
+	if want := []string{data.Titles[3].ID, data.Titles[0].ID}; !slices.Equal(found, want) {
+		t.Errorf("TestBatchGet = got (%v), want (%v).", found, want)
+	}
+}
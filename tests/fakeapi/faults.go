@@ -0,0 +1,304 @@
+package fakeapi
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+// FaultHeader carries a Scenario for a single request, in the form
+// ParseScenario reads. It wins over the scenario set with SetScenario.
+const FaultHeader = "X-Fake-Fault"
+
+// Scenario describes how the server misbehaves. The zero value serves
+// every request untouched.
+type Scenario struct {
+	// Latency delays every response, Jitter adds up to that much on
+	// either side of it.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// Status answers with an rpcStatus error instead of the real response,
+	// 429 and 503 being the interesting ones. A RetryAfter above zero is
+	// sent along as the Retry-After header, rounded up to whole seconds.
+	Status     int
+	RetryAfter time.Duration
+
+	// Truncate sends only that many bytes of the body before dropping the
+	// connection, while announcing the full Content-Length.
+	Truncate int
+	// Drip sends the body a DripSize chunk at a time, pausing Drip between
+	// chunks.
+	Drip     time.Duration
+	DripSize int
+	// Reset drops the connection without answering.
+	Reset bool
+	// Malformed sends a complete response whose body is cut in half, so
+	// it is no longer valid JSON.
+	Malformed bool
+
+	// Rate is the chance, between 0 and 1, that a request is faulted; 0
+	// faults every request.
+	Rate float64
+	// Times faults only that many requests, after which the scenario
+	// serves normally; 0 never stops. Requests carrying the scenario in
+	// FaultHeader are counted per header value.
+	Times int
+	// Path limits the scenario to requests whose path starts with it.
+	Path string
+}
+
+// ParseScenario reads a comma separated list of key=value settings, such
+// as "status=503,retry-after=1s,times=2". The boolean faults, reset and
+// malformed, take no value. An id setting is accepted and ignored, to
+// keep the Times count of two tests sending otherwise equal headers apart.
+func ParseScenario(value string) (Scenario, error) {
+	var scenario Scenario
+	for setting := range strings.SplitSeq(value, ",") {
+		key, arg, _ := strings.Cut(strings.TrimSpace(setting), "=")
+		var err error
+		switch key {
+		case "", "id":
+		case "latency":
+			scenario.Latency, err = time.ParseDuration(arg)
+		case "jitter":
+			scenario.Jitter, err = time.ParseDuration(arg)
+		case "status":
+			scenario.Status, err = strconv.Atoi(arg)
+		case "retry-after":
+			scenario.RetryAfter, err = time.ParseDuration(arg)
+		case "truncate":
+			scenario.Truncate, err = strconv.Atoi(arg)
+		case "drip":
+			scenario.Drip, err = time.ParseDuration(arg)
+		case "drip-size":
+			scenario.DripSize, err = strconv.Atoi(arg)
+		case "reset":
+			scenario.Reset = true
+		case "malformed":
+			scenario.Malformed = true
+		case "rate":
+			scenario.Rate, err = strconv.ParseFloat(arg, 64)
+		case "times":
+			scenario.Times, err = strconv.Atoi(arg)
+		case "path":
+			scenario.Path = arg
+		default:
+			return scenario, fmt.Errorf("unknown fault %q", key)
+		}
+		if err != nil {
+			return scenario, fmt.Errorf("fault %s: %w", key, err)
+		}
+	}
+	return scenario, nil
+}
+
+// FaultTransport adds Scenario as FaultHeader to every request it sends
+// through Base, http.DefaultTransport when nil.
+type FaultTransport struct {
+	Scenario string
+	Base     http.RoundTripper
+}
+
+func (t *FaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.Header.Set(FaultHeader, t.Scenario)
+	if t.Base != nil {
+		return t.Base.RoundTrip(r)
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// FaultInjector wraps a handler, applying the current Scenario to the
+// requests it serves.
+type FaultInjector struct {
+	next http.Handler
+
+	mu       sync.Mutex
+	scenario Scenario
+	faulted  map[string]int
+	rand     *rand.Rand
+}
+
+func InjectFaults(next http.Handler) *FaultInjector {
+	return &FaultInjector{next: next, faulted: map[string]int{}, rand: rand.New(rand.NewPCG(1, 2))}
+}
+
+// SetScenario replaces the scenario for every request not carrying
+// FaultHeader, and restarts its Times count.
+func (f *FaultInjector) SetScenario(scenario Scenario) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scenario = scenario
+	delete(f.faulted, "")
+}
+
+// ClearScenario goes back to serving every request untouched.
+func (f *FaultInjector) ClearScenario() {
+	f.SetScenario(Scenario{})
+}
+
+func (f *FaultInjector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get(FaultHeader)
+	f.mu.Lock()
+	scenario := f.scenario
+	f.mu.Unlock()
+	if key != "" {
+		var err error
+		if scenario, err = ParseScenario(key); err != nil {
+			respond(w, nil, invalidArgument(FaultHeader, err.Error()))
+			return
+		}
+		// The header is consumed here so injectors wrapping one another
+		// fault the request once.
+		r = r.Clone(r.Context())
+		r.Header.Del(FaultHeader)
+	}
+
+	if !f.applies(key, scenario, r) {
+		f.next.ServeHTTP(w, r)
+		return
+	}
+
+	if !f.delay(r.Context(), scenario) {
+		return
+	}
+	switch {
+	case scenario.Reset:
+		reset(w)
+	case scenario.Status != 0:
+		var details []*models.ProtobufAny
+		if scenario.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int((scenario.RetryAfter+time.Second-1)/time.Second)))
+			details = append(details, &models.ProtobufAny{
+				AtType:      "type.googleapis.com/google.rpc.RetryInfo",
+				ProtobufAny: map[string]any{"retryDelay": fmt.Sprintf("%.3fs", scenario.RetryAfter.Seconds())},
+			})
+		}
+		writeError(w, scenario.Status, rpcCode(scenario.Status), "injected fault", details...)
+	case scenario.Truncate > 0 || scenario.Drip > 0 || scenario.Malformed:
+		recorder := httptest.NewRecorder()
+		f.next.ServeHTTP(recorder, r)
+		writeFaulty(w, r, recorder, scenario)
+	default:
+		f.next.ServeHTTP(w, r)
+	}
+}
+
+// applies decides whether this request is faulted, counting it against
+// Times when it is.
+func (f *FaultInjector) applies(key string, scenario Scenario, r *http.Request) bool {
+	if scenario == (Scenario{}) || !strings.HasPrefix(r.URL.Path, scenario.Path) {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if scenario.Times > 0 && f.faulted[key] >= scenario.Times {
+		return false
+	}
+	if scenario.Rate > 0 && f.rand.Float64() >= scenario.Rate {
+		return false
+	}
+	f.faulted[key]++
+	return true
+}
+
+// delay sleeps for the scenario's latency, false means the client gave up
+// meanwhile.
+func (f *FaultInjector) delay(ctx context.Context, scenario Scenario) bool {
+	wait := scenario.Latency
+	if scenario.Jitter > 0 {
+		f.mu.Lock()
+		wait += time.Duration(f.rand.Int64N(int64(2*scenario.Jitter)+1)) - scenario.Jitter
+		f.mu.Unlock()
+	}
+	if wait <= 0 {
+		return true
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func rpcCode(status int) int32 {
+	switch status {
+	case http.StatusTooManyRequests:
+		return 8
+	case http.StatusServiceUnavailable:
+		return 14
+	case http.StatusGatewayTimeout:
+		return 4
+	case http.StatusBadRequest:
+		return 3
+	case http.StatusNotFound:
+		return 5
+	}
+	return 13
+}
+
+// reset closes the connection with SO_LINGER at zero, so the client reads
+// a RST rather than a clean EOF.
+func reset(w http.ResponseWriter) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
+
+func writeFaulty(w http.ResponseWriter, r *http.Request, recorder *httptest.ResponseRecorder, scenario Scenario) {
+	body := recorder.Body.Bytes()
+	if scenario.Malformed {
+		body = body[:len(body)/2]
+	}
+	for key, values := range recorder.Header() {
+		w.Header()[key] = values
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(recorder.Code)
+
+	if scenario.Truncate > 0 && scenario.Truncate < len(body) {
+		w.Write(body[:scenario.Truncate])
+		http.NewResponseController(w).Flush()
+		// Aborting leaves the announced Content-Length unmet, the client
+		// sees an unexpected EOF.
+		panic(http.ErrAbortHandler)
+	}
+
+	size := max(scenario.DripSize, 1)
+	if scenario.Drip <= 0 {
+		size = max(len(body), 1)
+	}
+	controller := http.NewResponseController(w)
+	for chunk := range slices.Chunk(body, size) {
+		if _, err := w.Write(chunk); err != nil {
+			return
+		}
+		controller.Flush()
+		if scenario.Drip > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(scenario.Drip):
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package fakeapi_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/foursixnine/imdblookup/tests/fakeapi"
+)
+
+func TestParseScenario(t *testing.T) {
+	testCases := map[string]struct {
+		value    string
+		expected fakeapi.Scenario
+		error    bool
+	}{
+		"with nothing": {value: ""},
+		"with status and retry": {
+			value:    "status=503, retry-after=2s, times=2, id=a",
+			expected: fakeapi.Scenario{Status: 503, RetryAfter: 2 * time.Second, Times: 2},
+		},
+		"with flags": {
+			value:    "reset,malformed,path=/titles",
+			expected: fakeapi.Scenario{Reset: true, Malformed: true, Path: "/titles"},
+		},
+		"with drip": {
+			value:    "latency=10ms,jitter=5ms,drip=1ms,drip-size=8,rate=0.5",
+			expected: fakeapi.Scenario{Latency: 10 * time.Millisecond, Jitter: 5 * time.Millisecond, Drip: time.Millisecond, DripSize: 8, Rate: 0.5},
+		},
+		"with unknown fault": {value: "explode", error: true},
+		"with bad duration":  {value: "latency=soon", error: true},
+	}
+
+	for testName, testCase := range testCases {
+		scenario, err := fakeapi.ParseScenario(testCase.value)
+		if (err != nil) != testCase.error {
+			t.Errorf("TestParseScenario(%s) = got error (%v), want error (%v).", testName, err, testCase.error)
+		}
+		if err == nil && !reflect.DeepEqual(scenario, testCase.expected) {
+			t.Errorf("TestParseScenario(%s) = got (%+v), want (%+v).", testName, scenario, testCase.expected)
+		}
+	}
+}
+
+func TestFaultInjector(t *testing.T) {
+	testCases := map[string]struct {
+		scenario fakeapi.Scenario
+		header   string
+		statuses []int
+		// retryAfter is the Retry-After header of the first response.
+		retryAfter string
+		error      bool
+	}{
+		"with nothing": {
+			statuses: []int{200, 200},
+		},
+		"with two failures": {
+			scenario: fakeapi.Scenario{Status: http.StatusServiceUnavailable, Times: 2},
+			statuses: []int{503, 503, 200},
+		},
+		"with retry after": {
+			scenario:   fakeapi.Scenario{Status: http.StatusTooManyRequests, RetryAfter: 1500 * time.Millisecond, Times: 1},
+			statuses:   []int{429, 200},
+			retryAfter: "2",
+		},
+		"with other paths only": {
+			scenario: fakeapi.Scenario{Status: http.StatusTooManyRequests, Path: "/names"},
+			statuses: []int{200},
+		},
+		"with header over scenario": {
+			scenario: fakeapi.Scenario{Status: http.StatusServiceUnavailable},
+			header:   "status=429,times=1",
+			statuses: []int{429, 200},
+		},
+		"with bad header": {
+			header:   "explode",
+			statuses: []int{400},
+		},
+		"with reset": {
+			scenario: fakeapi.Scenario{Reset: true},
+			error:    true,
+		},
+		"with truncated body": {
+			scenario: fakeapi.Scenario{Truncate: 10},
+			error:    true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			fake := fakeapi.New(fakeapi.Seed(1))
+			fake.SetScenario(testCase.scenario)
+			server := httptest.NewServer(fake)
+			t.Cleanup(server.Close)
+			client := server.Client()
+			if testCase.header != "" {
+				client.Transport = &fakeapi.FaultTransport{Scenario: testCase.header, Base: client.Transport}
+			}
+
+			if testCase.error {
+				resp, err := client.Get(server.URL + "/titles/tt2000001")
+				if err == nil {
+					_, err = io.ReadAll(resp.Body)
+					resp.Body.Close()
+				}
+				if err == nil {
+					t.Errorf("TestFaultInjector(%s) = got no error, want one.", testName)
+				}
+				return
+			}
+
+			for i, status := range testCase.statuses {
+				resp, err := client.Get(server.URL + "/titles/tt2000001")
+				if err != nil {
+					t.Fatalf("TestFaultInjector(%s) = unexpected error (%v)", testName, err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != status {
+					t.Errorf("TestFaultInjector(%s) = request %d got (%d), want (%d).", testName, i, resp.StatusCode, status)
+				}
+				if retryAfter := resp.Header.Get("Retry-After"); i == 0 && retryAfter != testCase.retryAfter {
+					t.Errorf("TestFaultInjector(%s) = got Retry-After (%s), want (%s).", testName, retryAfter, testCase.retryAfter)
+				}
+			}
+		})
+	}
+}
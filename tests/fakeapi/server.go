@@ -0,0 +1,537 @@
+// Package fakeapi is an in-memory stand-in for every route of
+// other/imdbapi.swagger.yaml, for integration tests and local development.
+package fakeapi
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+const (
+	// DefaultPageSize and MaxPageSize bound the pageSize parameter of the
+	// sub-resource listings.
+	DefaultPageSize = 20
+	MaxPageSize     = 50
+	// TitlesPageSize is the fixed page size of /titles and the STARmeter.
+	TitlesPageSize = 50
+	// MaxBatchIDs is the most ids a batchGet accepts.
+	MaxBatchIDs = 5
+)
+
+var (
+	titleIDPattern    = regexp.MustCompile(`^tt\d{7,8}$`)
+	nameIDPattern     = regexp.MustCompile(`^nm\d{7,8}$`)
+	interestIDPattern = regexp.MustCompile(`^in\d{7,8}$`)
+)
+
+// Server serves a Dataset, which must not be changed while it runs,
+// through a FaultInjector.
+type Server struct {
+	*FaultInjector
+	data *Dataset
+	mux  *http.ServeMux
+}
+
+func New(data *Dataset) *Server {
+	s := &Server{data: data, mux: http.NewServeMux()}
+	s.FaultInjector = InjectFaults(http.HandlerFunc(s.route))
+
+	s.mux.HandleFunc("GET /titles", s.listTitles)
+	s.mux.HandleFunc("GET /titles:batchGet", s.batchGetTitles)
+	s.mux.HandleFunc("GET /titles/{titleId}", s.getTitle)
+	s.mux.HandleFunc("GET /titles/{titleId}/credits", s.titleCredits)
+	s.mux.HandleFunc("GET /titles/{titleId}/releaseDates", titleList(s, s.data.ReleaseDates, func(items []*models.ImdbapiReleaseDate, next string) any {
+		return models.ImdbapiListTitleReleaseDatesResponse{ReleaseDates: items, NextPageToken: next}
+	}))
+	s.mux.HandleFunc("GET /titles/{titleId}/akas", s.titleAKAs)
+	s.mux.HandleFunc("GET /titles/{titleId}/seasons", s.titleSeasons)
+	s.mux.HandleFunc("GET /titles/{titleId}/episodes", s.titleEpisodes)
+	s.mux.HandleFunc("GET /titles/{titleId}/images", s.titleImages)
+	s.mux.HandleFunc("GET /titles/{titleId}/videos", s.titleVideos)
+	s.mux.HandleFunc("GET /titles/{titleId}/awardNominations", titleList(s, s.data.AwardNominations, func(items []*models.ImdbapiAwardNomination, next string) any {
+		return models.ImdbapiListTitleAwardNominationsResponse{AwardNominations: items, NextPageToken: next}
+	}))
+	s.mux.HandleFunc("GET /titles/{titleId}/parentsGuide", s.titleParentsGuide)
+	s.mux.HandleFunc("GET /titles/{titleId}/certificates", s.titleCertificates)
+	s.mux.HandleFunc("GET /titles/{titleId}/companyCredits", s.titleCompanyCredits)
+	s.mux.HandleFunc("GET /titles/{titleId}/boxOffice", s.titleBoxOffice)
+	s.mux.HandleFunc("GET /search/titles", s.searchTitles)
+	s.mux.HandleFunc("GET /names:batchGet", s.batchGetNames)
+	s.mux.HandleFunc("GET /names/{nameId}", s.getName)
+	s.mux.HandleFunc("GET /names/{nameId}/images", s.nameImages)
+	s.mux.HandleFunc("GET /names/{nameId}/filmography", s.nameFilmography)
+	s.mux.HandleFunc("GET /names/{nameId}/relationships", s.nameRelationships)
+	s.mux.HandleFunc("GET /names/{nameId}/trivia", s.nameTrivia)
+	s.mux.HandleFunc("GET /chart/starmeter", s.starMeter)
+	s.mux.HandleFunc("GET /interests", s.interests)
+	s.mux.HandleFunc("GET /interests/{interestId}", s.getInterest)
+	return s
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := s.mux.Handler(r); pattern == "" {
+		writeError(w, http.StatusNotFound, 5, "no route for "+r.Method+" "+r.URL.Path, errorInfo("ROUTE_NOT_FOUND", nil))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// apiError is an rpcStatus body with the http status it is sent with.
+type apiError struct {
+	status  int
+	code    int32
+	message string
+	details []*models.ProtobufAny
+}
+
+func writeError(w http.ResponseWriter, status int, code int32, message string, details ...*models.ProtobufAny) {
+	writeJSON(w, status, models.RPCStatus{Code: code, Message: message, Details: details})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		status, data = http.StatusInternalServerError, []byte(`{"code":13,"message":"encoding the response failed"}`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func respond(w http.ResponseWriter, value any, err *apiError) {
+	if err != nil {
+		writeError(w, err.status, err.code, err.message, err.details...)
+		return
+	}
+	writeJSON(w, http.StatusOK, value)
+}
+
+func errorInfo(reason string, metadata map[string]string) *models.ProtobufAny {
+	detail := map[string]any{"reason": reason, "domain": "imdbapi.dev"}
+	if metadata != nil {
+		detail["metadata"] = metadata
+	}
+	return &models.ProtobufAny{AtType: "type.googleapis.com/google.rpc.ErrorInfo", ProtobufAny: detail}
+}
+
+func notFound(what, id string) *apiError {
+	reason := strings.ToUpper(what) + "_NOT_FOUND"
+	return &apiError{status: http.StatusNotFound, code: 5, message: what + " not found", details: []*models.ProtobufAny{errorInfo(reason, map[string]string{what + "Id": id})}}
+}
+
+func invalidArgument(field, description string) *apiError {
+	return &apiError{status: http.StatusBadRequest, code: 3, message: "invalid " + field + ": " + description, details: []*models.ProtobufAny{{
+		AtType:      "type.googleapis.com/google.rpc.BadRequest",
+		ProtobufAny: map[string]any{"fieldViolations": []map[string]string{{"field": field, "description": description}}},
+	}}}
+}
+
+// pageToken is what a nextPageToken encodes: where the next page starts
+// and a digest of the request it belongs to, so a token cannot be replayed
+// against other filters.
+type pageToken struct {
+	Offset int    `json:"o"`
+	Query  string `json:"q"`
+}
+
+// queryDigest hashes the route and every parameter but the paging ones.
+func queryDigest(r *http.Request) string {
+	query := url.Values{}
+	for key, values := range r.URL.Query() {
+		if key != "pageToken" && key != "pageSize" {
+			query[key] = slices.Sorted(slices.Values(values))
+		}
+	}
+	sum := sha256.Sum256([]byte(r.URL.Path + "?" + query.Encode()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// paginate cuts items into the page the request asks for. Sized routes take
+// a pageSize parameter, DefaultPageSize when missing, the others always
+// page by TitlesPageSize.
+func paginate[T any](r *http.Request, items []T, sized bool) ([]T, string, *apiError) {
+	pageSize := TitlesPageSize
+	if sized {
+		pageSize = DefaultPageSize
+		if value := r.URL.Query().Get("pageSize"); value != "" {
+			size, err := strconv.Atoi(value)
+			if err != nil || size < 1 || size > MaxPageSize {
+				return nil, "", invalidArgument("pageSize", fmt.Sprintf("must be between 1 and %d", MaxPageSize))
+			}
+			pageSize = size
+		}
+	}
+
+	digest := queryDigest(r)
+	offset := 0
+	if value := r.URL.Query().Get("pageToken"); value != "" {
+		var token pageToken
+		data, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil || json.Unmarshal(data, &token) != nil || token.Offset < 0 || token.Offset > len(items) {
+			return nil, "", invalidArgument("pageToken", "malformed page token")
+		}
+		if token.Query != digest {
+			return nil, "", invalidArgument("pageToken", "page token does not belong to this request")
+		}
+		offset = token.Offset
+	}
+
+	end := min(offset+pageSize, len(items))
+	next := ""
+	if end < len(items) {
+		data, _ := json.Marshal(pageToken{Offset: end, Query: digest})
+		next = base64.RawURLEncoding.EncodeToString(data)
+	}
+	return items[offset:end], next, nil
+}
+
+func (s *Server) title(r *http.Request) (*models.ImdbapiTitle, *apiError) {
+	id := r.PathValue("titleId")
+	if !titleIDPattern.MatchString(id) {
+		return nil, invalidArgument("titleId", "must look like tt1234567")
+	}
+	for _, title := range s.data.Titles {
+		if title.ID == id {
+			return title, nil
+		}
+	}
+	return nil, notFound("title", id)
+}
+
+func (s *Server) name(r *http.Request) (*models.ImdbapiName, *apiError) {
+	id := r.PathValue("nameId")
+	if !nameIDPattern.MatchString(id) {
+		return nil, invalidArgument("nameId", "must look like nm1234567")
+	}
+	for _, name := range s.data.Names {
+		if name.ID == id {
+			return name, nil
+		}
+	}
+	return nil, notFound("name", id)
+}
+
+// titleList serves a paginated resource of a title straight from its map.
+func titleList[T any](s *Server, resource map[string][]*T, response func(items []*T, next string) any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		title, err := s.title(r)
+		if err != nil {
+			respond(w, nil, err)
+			return
+		}
+		items := resource[title.ID]
+		page, next, err := paginate(r, items, true)
+		if err != nil {
+			respond(w, nil, err)
+			return
+		}
+		respond(w, response(page, next), nil)
+	}
+}
+
+func (s *Server) getTitle(w http.ResponseWriter, r *http.Request) {
+	title, err := s.title(r)
+	respond(w, title, err)
+}
+
+func (s *Server) getName(w http.ResponseWriter, r *http.Request) {
+	name, err := s.name(r)
+	respond(w, name, err)
+}
+
+// filterCategories keeps the items whose category is one of the
+// categories parameters, all of them when there are none.
+func filterCategories[T any](r *http.Request, items []*T, category func(*T) string) []*T {
+	categories := r.URL.Query()["categories"]
+	if len(categories) == 0 {
+		return items
+	}
+	return slices.DeleteFunc(slices.Clone(items), func(item *T) bool { return !slices.Contains(categories, category(item)) })
+}
+
+func (s *Server) titleCredits(w http.ResponseWriter, r *http.Request) {
+	title, err := s.title(r)
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+	credits := filterCategories(r, s.data.Credits[title.ID], func(c *models.ImdbapiCredit) string { return c.Category })
+	page, next, err := paginate(r, credits, true)
+	respond(w, models.ImdbapiListTitleCreditsResponse{Credits: page, NextPageToken: next, TotalCount: int32(len(credits))}, err)
+}
+
+func (s *Server) titleAKAs(w http.ResponseWriter, r *http.Request) {
+	title, err := s.title(r)
+	respond(w, models.ImdbapiListTitleAKAsResponse{Akas: s.data.AKAs[idOf(title)]}, err)
+}
+
+func (s *Server) titleSeasons(w http.ResponseWriter, r *http.Request) {
+	title, err := s.title(r)
+	response := models.ImdbapiListTitleSeasonsResponse{}
+	for _, episode := range s.data.Episodes[idOf(title)] {
+		i := slices.IndexFunc(response.Seasons, func(season *models.ImdbapiSeason) bool { return season.Season == episode.Season })
+		if i < 0 {
+			response.Seasons = append(response.Seasons, &models.ImdbapiSeason{Season: episode.Season})
+			i = len(response.Seasons) - 1
+		}
+		response.Seasons[i].EpisodeCount++
+	}
+	respond(w, response, err)
+}
+
+func (s *Server) titleEpisodes(w http.ResponseWriter, r *http.Request) {
+	title, err := s.title(r)
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+	episodes := s.data.Episodes[title.ID]
+	if season := r.URL.Query().Get("season"); season != "" {
+		episodes = slices.DeleteFunc(slices.Clone(episodes), func(e *models.ImdbapiEpisode) bool { return e.Season != season })
+	}
+	page, next, err := paginate(r, episodes, true)
+	respond(w, models.ImdbapiListTitleEpisodesResponse{Episodes: page, NextPageToken: next, TotalCount: int32(len(episodes))}, err)
+}
+
+// filterTypes keeps the items whose type is one of the types parameters.
+func filterTypes[T any](r *http.Request, items []*T, kind func(*T) string) []*T {
+	types := r.URL.Query()["types"]
+	if len(types) == 0 {
+		return items
+	}
+	return slices.DeleteFunc(slices.Clone(items), func(item *T) bool { return !slices.Contains(types, kind(item)) })
+}
+
+func (s *Server) titleImages(w http.ResponseWriter, r *http.Request) {
+	title, err := s.title(r)
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+	images := filterTypes(r, s.data.Images[title.ID], func(i *models.ImdbapiImage) string { return i.Type })
+	page, next, err := paginate(r, images, true)
+	respond(w, models.ImdbapiListTitleImagesResponse{Images: page, NextPageToken: next, TotalCount: int32(len(images))}, err)
+}
+
+func (s *Server) titleVideos(w http.ResponseWriter, r *http.Request) {
+	title, err := s.title(r)
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+	videos := filterTypes(r, s.data.Videos[title.ID], func(v *models.ImdbapiVideo) string { return v.Type })
+	page, next, err := paginate(r, videos, true)
+	respond(w, models.ImdbapiListTitleVideosResponse{Videos: page, NextPageToken: next, TotalCount: int32(len(videos))}, err)
+}
+
+func (s *Server) titleParentsGuide(w http.ResponseWriter, r *http.Request) {
+	title, err := s.title(r)
+	respond(w, models.ImdbapiListTitleParentsGuideResponse{ParentsGuide: s.data.ParentsGuide[idOf(title)]}, err)
+}
+
+func (s *Server) titleCertificates(w http.ResponseWriter, r *http.Request) {
+	title, err := s.title(r)
+	certificates := s.data.Certificates[idOf(title)]
+	respond(w, models.ImdbapiListTitleCertificatesResponse{Certificates: certificates, TotalCount: int32(len(certificates))}, err)
+}
+
+func (s *Server) titleCompanyCredits(w http.ResponseWriter, r *http.Request) {
+	title, err := s.title(r)
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+	credits := filterCategories(r, s.data.CompanyCredits[title.ID], func(c *models.ImdbapiCompanyCredit) string { return c.Category })
+	page, next, err := paginate(r, credits, true)
+	respond(w, models.ImdbapiListTitleCompanyCreditsResponse{CompanyCredits: page, NextPageToken: next, TotalCount: int32(len(credits))}, err)
+}
+
+func (s *Server) titleBoxOffice(w http.ResponseWriter, r *http.Request) {
+	title, err := s.title(r)
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+	respond(w, cmp.Or(s.data.BoxOffice[title.ID], &models.ImdbapiBoxOffice{}), nil)
+}
+
+func (s *Server) searchTitles(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("query")))
+	if query == "" {
+		respond(w, nil, invalidArgument("query", "must not be empty"))
+		return
+	}
+	limit := MaxPageSize
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > MaxPageSize {
+			respond(w, nil, invalidArgument("limit", fmt.Sprintf("must be between 1 and %d", MaxPageSize)))
+			return
+		}
+		limit = n
+	}
+
+	response := models.ImdbapiSearchTitlesResponse{}
+	for _, title := range s.data.Titles {
+		if len(response.Titles) < limit && (strings.Contains(strings.ToLower(title.PrimaryTitle), query) || strings.Contains(strings.ToLower(title.OriginalTitle), query)) {
+			response.Titles = append(response.Titles, title)
+		}
+	}
+	respond(w, response, nil)
+}
+
+// batchIDs checks the ids parameter of a batchGet, ids it does not hold
+// being left out of the answer rather than failing it.
+func batchIDs(r *http.Request, param string, pattern *regexp.Regexp) ([]string, *apiError) {
+	ids := r.URL.Query()[param]
+	if len(ids) == 0 {
+		return nil, invalidArgument(param, "at least one id is required")
+	}
+	if len(ids) > MaxBatchIDs {
+		return nil, invalidArgument(param, fmt.Sprintf("at most %d ids are allowed", MaxBatchIDs))
+	}
+	for _, id := range ids {
+		if !pattern.MatchString(id) {
+			return nil, invalidArgument(param, fmt.Sprintf("malformed id %q", id))
+		}
+	}
+	return ids, nil
+}
+
+func (s *Server) batchGetTitles(w http.ResponseWriter, r *http.Request) {
+	ids, err := batchIDs(r, "titleIds", titleIDPattern)
+	response := models.ImdbapiBatchGetTitlesResponse{}
+	for _, title := range s.data.Titles {
+		if slices.Contains(ids, title.ID) {
+			response.Titles = append(response.Titles, title)
+		}
+	}
+	respond(w, response, err)
+}
+
+func (s *Server) batchGetNames(w http.ResponseWriter, r *http.Request) {
+	ids, err := batchIDs(r, "nameIds", nameIDPattern)
+	response := models.ImdbapiBatchGetNamesResponse{}
+	for _, name := range s.data.Names {
+		if slices.Contains(ids, name.ID) {
+			response.Names = append(response.Names, name)
+		}
+	}
+	respond(w, response, err)
+}
+
+func (s *Server) nameImages(w http.ResponseWriter, r *http.Request) {
+	name, err := s.name(r)
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+	images := filterTypes(r, s.data.Images[name.ID], func(i *models.ImdbapiImage) string { return i.Type })
+	page, next, err := paginate(r, images, true)
+	respond(w, models.ImdbapiListNameImagesResponse{Images: page, NextPageToken: next, TotalCount: int32(len(images))}, err)
+}
+
+// filmography is every credit of nameID, in popularity order of the titles.
+func (s *Server) filmography(nameID string) []*models.ImdbapiCredit {
+	var credits []*models.ImdbapiCredit
+	for _, title := range s.data.Titles {
+		for _, credit := range s.data.Credits[title.ID] {
+			if credit.Name.ID == nameID {
+				credits = append(credits, &models.ImdbapiCredit{
+					Title:        &models.ImdbapiTitle{ID: title.ID, Type: title.Type, PrimaryTitle: title.PrimaryTitle, StartYear: title.StartYear, PrimaryImage: title.PrimaryImage},
+					Category:     credit.Category,
+					Characters:   credit.Characters,
+					EpisodeCount: credit.EpisodeCount,
+				})
+			}
+		}
+	}
+	return credits
+}
+
+func (s *Server) nameFilmography(w http.ResponseWriter, r *http.Request) {
+	name, err := s.name(r)
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+	credits := filterCategories(r, s.filmography(name.ID), func(c *models.ImdbapiCredit) string { return c.Category })
+	page, next, err := paginate(r, credits, true)
+	respond(w, models.ImdbapiListNameFilmographyResponse{Credits: page, NextPageToken: next, TotalCount: int32(len(credits))}, err)
+}
+
+func (s *Server) nameRelationships(w http.ResponseWriter, r *http.Request) {
+	name, err := s.name(r)
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+	respond(w, models.ImdbapiListNameRelationshipsResponse{Relationships: s.data.Relationships[name.ID]}, nil)
+}
+
+func (s *Server) nameTrivia(w http.ResponseWriter, r *http.Request) {
+	name, err := s.name(r)
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+	trivia := s.data.Trivia[name.ID]
+	page, next, err := paginate(r, trivia, true)
+	respond(w, models.ImdbapiListNameTriviaResponse{TriviaEntries: page, NextPageToken: next, TotalCount: int32(len(trivia))}, err)
+}
+
+func (s *Server) starMeter(w http.ResponseWriter, r *http.Request) {
+	names := slices.Clone(s.data.Names)
+	slices.SortStableFunc(names, func(a, b *models.ImdbapiName) int {
+		return cmp.Compare(rank(a), rank(b))
+	})
+	page, next, err := paginate(r, names, false)
+	respond(w, models.ImdbapiListStarMetersResponse{Names: page, NextPageToken: next}, err)
+}
+
+func rank(name *models.ImdbapiName) int32 {
+	if name.MeterRanking == nil {
+		return 1 << 30
+	}
+	return name.MeterRanking.CurrentRank
+}
+
+func (s *Server) interests(w http.ResponseWriter, r *http.Request) {
+	respond(w, models.ImdbapiListListInterestCategoriesResponse{Categories: s.data.Interests}, nil)
+}
+
+func (s *Server) getInterest(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("interestId")
+	if !interestIDPattern.MatchString(id) {
+		respond(w, nil, invalidArgument("interestId", "must look like in1234567"))
+		return
+	}
+	for _, category := range s.data.Interests {
+		for _, interest := range category.Interests {
+			if interest.ID == id {
+				respond(w, interest, nil)
+				return
+			}
+		}
+	}
+	respond(w, nil, notFound("interest", id))
+}
+
+func idOf(title *models.ImdbapiTitle) string {
+	if title == nil {
+		return ""
+	}
+	return title.ID
+}
@@ -0,0 +1,185 @@
+package fakeapi
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/foursixnine/imdblookup/models"
+)
+
+var (
+	knownTypes = []string{"MOVIE", "TV_SERIES", "TV_MINI_SERIES", "TV_SPECIAL", "TV_MOVIE", "SHORT", "VIDEO", "VIDEO_GAME"}
+	sortOrders = []string{"ASC", "DESC"}
+)
+
+// titleSorts orders titles for each sortBy value; popularity keeps the
+// dataset order.
+var titleSorts = map[string]func(s *Server, a, b *models.ImdbapiTitle) int{
+	"SORT_BY_POPULARITY": func(*Server, *models.ImdbapiTitle, *models.ImdbapiTitle) int { return 0 },
+	"SORT_BY_RELEASE_DATE": func(s *Server, a, b *models.ImdbapiTitle) int {
+		return cmp.Compare(s.releaseDate(a), s.releaseDate(b))
+	},
+	"SORT_BY_USER_RATING": func(_ *Server, a, b *models.ImdbapiTitle) int {
+		return cmp.Compare(aggregateRating(a), aggregateRating(b))
+	},
+	"SORT_BY_USER_RATING_COUNT": func(_ *Server, a, b *models.ImdbapiTitle) int {
+		return cmp.Compare(voteCount(a), voteCount(b))
+	},
+	"SORT_BY_YEAR": func(_ *Server, a, b *models.ImdbapiTitle) int {
+		return cmp.Compare(a.StartYear, b.StartYear)
+	},
+}
+
+// titleFilter is the parsed query of a /titles request.
+type titleFilter struct {
+	types, genres, countries, languages, names, interests []string
+	startYear, endYear                                    int32
+	minVotes, maxVotes                                    int32
+	minRating, maxRating                                  float32
+}
+
+func (s *Server) listTitles(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, err := parseTitleFilter(r)
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+	sortBy := cmp.Or(query.Get("sortBy"), "SORT_BY_POPULARITY")
+	order, ok := titleSorts[sortBy]
+	if !ok {
+		respond(w, nil, invalidArgument("sortBy", "unknown sort "+strconv.Quote(sortBy)))
+		return
+	}
+	sortOrder := cmp.Or(query.Get("sortOrder"), "ASC")
+	if !slices.Contains(sortOrders, sortOrder) {
+		respond(w, nil, invalidArgument("sortOrder", "must be ASC or DESC"))
+		return
+	}
+
+	var titles []*models.ImdbapiTitle
+	for _, title := range s.data.Titles {
+		if s.matches(filter, title) {
+			titles = append(titles, title)
+		}
+	}
+	slices.SortStableFunc(titles, func(a, b *models.ImdbapiTitle) int {
+		if sortOrder == "DESC" {
+			a, b = b, a
+		}
+		return order(s, a, b)
+	})
+
+	page, next, err := paginate(r, titles, false)
+	respond(w, models.ImdbapiListTitlesResponse{Titles: page, NextPageToken: next, TotalCount: int32(len(titles))}, err)
+}
+
+func parseTitleFilter(r *http.Request) (titleFilter, *apiError) {
+	query := r.URL.Query()
+	filter := titleFilter{
+		types:     query["types"],
+		genres:    query["genres"],
+		countries: query["countryCodes"],
+		languages: query["languageCodes"],
+		names:     query["nameIds"],
+		interests: query["interestIds"],
+		maxVotes:  1_000_000_000,
+		maxRating: 10,
+	}
+	for _, kind := range filter.types {
+		if !slices.Contains(knownTypes, kind) {
+			return filter, invalidArgument("types", "unknown type "+strconv.Quote(kind))
+		}
+	}
+
+	ints := []struct {
+		param    string
+		value    *int32
+		min, max int64
+	}{
+		{"startYear", &filter.startYear, 0, 9999},
+		{"endYear", &filter.endYear, 0, 9999},
+		{"minVoteCount", &filter.minVotes, 0, 1_000_000_000},
+		{"maxVoteCount", &filter.maxVotes, 0, 1_000_000_000},
+	}
+	for _, p := range ints {
+		if value := query.Get(p.param); value != "" {
+			n, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || n < p.min || n > p.max {
+				return filter, invalidArgument(p.param, "must be between "+strconv.FormatInt(p.min, 10)+" and "+strconv.FormatInt(p.max, 10))
+			}
+			*p.value = int32(n)
+		}
+	}
+	for param, value := range map[string]*float32{"minAggregateRating": &filter.minRating, "maxAggregateRating": &filter.maxRating} {
+		if text := query.Get(param); text != "" {
+			f, err := strconv.ParseFloat(text, 32)
+			if err != nil || f < 0 || f > 10 {
+				return filter, invalidArgument(param, "must be between 0.0 and 10.0")
+			}
+			*value = float32(f)
+		}
+	}
+	return filter, nil
+}
+
+func (s *Server) matches(filter titleFilter, title *models.ImdbapiTitle) bool {
+	if len(filter.types) > 0 && !slices.Contains(filter.types, title.Type) {
+		return false
+	}
+	if len(filter.genres) > 0 && !slices.ContainsFunc(title.Genres, func(genre string) bool { return slices.Contains(filter.genres, genre) }) {
+		return false
+	}
+	if len(filter.countries) > 0 && !slices.ContainsFunc(title.OriginCountries, func(c *models.ImdbapiCountry) bool { return slices.Contains(filter.countries, c.Code) }) {
+		return false
+	}
+	if len(filter.languages) > 0 && !slices.ContainsFunc(title.SpokenLanguages, func(l *models.ImdbapiLanguage) bool { return slices.Contains(filter.languages, l.Code) }) {
+		return false
+	}
+	if len(filter.interests) > 0 && !slices.ContainsFunc(title.Interests, func(i *models.ImdbapiInterest) bool { return slices.Contains(filter.interests, i.ID) }) {
+		return false
+	}
+	if len(filter.names) > 0 && !slices.ContainsFunc(s.data.Credits[title.ID], func(c *models.ImdbapiCredit) bool { return slices.Contains(filter.names, c.Name.ID) }) {
+		return false
+	}
+	if filter.startYear != 0 && title.StartYear < filter.startYear {
+		return false
+	}
+	if filter.endYear != 0 && title.StartYear > filter.endYear {
+		return false
+	}
+	votes, rating := voteCount(title), aggregateRating(title)
+	return votes >= filter.minVotes && votes <= filter.maxVotes && rating >= filter.minRating && rating <= filter.maxRating
+}
+
+// releaseDate is the earliest release of title as yyyymmdd.
+func (s *Server) releaseDate(title *models.ImdbapiTitle) int32 {
+	var earliest int32
+	for _, release := range s.data.ReleaseDates[title.ID] {
+		if date := release.ReleaseDate; date != nil {
+			if value := date.Year*10000 + date.Month*100 + date.Day; value < earliest || earliest == 0 {
+				earliest = value
+			}
+		}
+	}
+	if earliest == 0 {
+		return title.StartYear * 10000
+	}
+	return earliest
+}
+
+func aggregateRating(title *models.ImdbapiTitle) float32 {
+	if title.Rating == nil {
+		return 0
+	}
+	return title.Rating.AggregateRating
+}
+
+func voteCount(title *models.ImdbapiTitle) int32 {
+	if title.Rating == nil {
+		return 0
+	}
+	return title.Rating.VoteCount
+}
@@ -6,15 +6,23 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/foursixnine/imdblookup/models"
+	"github.com/foursixnine/imdblookup/tests/fakeapi"
 )
 
+// SetupServer serves the canned answers the tests rely on and hands every
+// other route to a fakeapi server seeded with 1. Any route can be faulted
+// by sending a scenario in the fakeapi.FaultHeader header.
 func SetupServer(t *testing.T) (server *httptest.Server) {
 	t.Helper()
-	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	fake := fakeapi.New(fakeapi.Seed(1))
+	server = httptest.NewServer(fakeapi.InjectFaults(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		for key, value := range r.Header {
 			log.Printf("Request header: %s => %v\n", key, value)
 		}
@@ -45,11 +53,105 @@ func SetupServer(t *testing.T) (server *httptest.Server) {
 			}
 
 			w.Write(data)
+		case "/titles":
+			data, err := getTitlesPage(t, r.URL.Query())
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, err)
+				return
+			}
+
+			w.Header().Add("Content-Type", "application/json")
+			w.Write(data)
+		case "/titles:batchGet", "/names:batchGet":
+			ids := append(r.URL.Query()["titleIds"], r.URL.Query()["nameIds"]...)
+			if len(ids) > 5 {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"code":3,"message":"at most 5 ids"}`)
+				return
+			}
+
+			var data []byte
+			if r.URL.Path == "/titles:batchGet" {
+				response := models.ImdbapiBatchGetTitlesResponse{}
+				for _, title := range listTitles {
+					if slices.Contains(ids, title.ID) {
+						response.Titles = append(response.Titles, title)
+					}
+				}
+				data, _ = json.Marshal(response)
+			} else {
+				response := models.ImdbapiBatchGetNamesResponse{}
+				for _, credit := range listCredits {
+					if slices.Contains(ids, credit.Name.ID) {
+						response.Names = append(response.Names, credit.Name)
+					}
+				}
+				data, _ = json.Marshal(response)
+			}
+			w.Header().Add("Content-Type", "application/json")
+			w.Write(data)
+		case "/titles/tt0000002/seasons":
+			data, _ := json.Marshal(models.ImdbapiListTitleSeasonsResponse{Seasons: []*models.ImdbapiSeason{
+				{Season: "2", EpisodeCount: 1},
+				{Season: "1", EpisodeCount: 3},
+				{Season: "3"},
+			}})
+			w.Header().Add("Content-Type", "application/json")
+			w.Write(data)
+		case "/titles/tt0000002/episodes":
+			var episodes []*models.ImdbapiEpisode
+			for _, episode := range listEpisodes {
+				if season := r.URL.Query().Get("season"); season == "" || season == episode.Season {
+					episodes = append(episodes, episode)
+				}
+			}
+			page, next, err := getPage(episodes, r.URL.Query())
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, err)
+				return
+			}
+
+			data, _ := json.Marshal(models.ImdbapiListTitleEpisodesResponse{Episodes: page, NextPageToken: next})
+			w.Header().Add("Content-Type", "application/json")
+			w.Write(data)
+		case "/titles/tt0000001/credits", "/titles/tt0000002/credits":
+			credits, next, err := getPage(listCredits, r.URL.Query())
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, err)
+				return
+			}
+
+			data, _ := json.Marshal(models.ImdbapiListTitleCreditsResponse{Credits: credits, NextPageToken: next})
+			w.Header().Add("Content-Type", "application/json")
+			w.Write(data)
+		case "/names/nm0000001/filmography":
+			data, _ := json.Marshal(models.ImdbapiListNameFilmographyResponse{Credits: []*models.ImdbapiCredit{{Category: "actor", Title: listTitles[0]}}})
+			w.Header().Add("Content-Type", "application/json")
+			w.Write(data)
+		case "/titles/tt0000001":
+			data, _ := json.Marshal(listTitles[0])
+			w.Header().Add("Content-Type", "application/json")
+			w.Write(data)
+		case "/titles/tt0000002":
+			data, _ := json.Marshal(listTitles[1])
+			w.Header().Add("Content-Type", "application/json")
+			w.Write(data)
+		case "/titles/tt9999999":
+			w.Header().Add("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code":5,"message":"title not found","details":[{"@type":"type.googleapis.com/google.rpc.ErrorInfo","reason":"TITLE_NOT_FOUND","domain":"imdbapi.dev","metadata":{"titleId":"tt9999999"}}]}`)
+		case "/titles/invalid":
+			w.Header().Add("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":3,"message":"invalid title id","details":[{"@type":"type.googleapis.com/google.rpc.BadRequest","fieldViolations":[{"field":"titleId","description":"must start with tt"}]},{"@type":"type.googleapis.com/example.Unknown","foo":"bar"}]}`)
 		default:
-			http.NotFoundHandler().ServeHTTP(w, r)
+			fake.ServeHTTP(w, r)
 		}
 
-	}))
+	})))
 
 	return
 }
@@ -64,6 +166,10 @@ func getDataForQuery(t *testing.T, query string) (data []byte, err error) {
 		}
 		titles.Titles = titleValues //append(titles.Titles, &title)
 		data, err = json.Marshal(titles)
+	case "First":
+		data, err = json.Marshal(models.ImdbapiSearchTitlesResponse{Titles: listTitles[:1]})
+	case "Second":
+		data, err = json.Marshal(models.ImdbapiSearchTitlesResponse{Titles: listTitles[1:2]})
 	case "Broken Json":
 		data = []byte("{")
 		err = nil
@@ -73,3 +179,70 @@ func getDataForQuery(t *testing.T, query string) (data []byte, err error) {
 	}
 	return
 }
+
+var listTitles = []*models.ImdbapiTitle{
+	{ID: "tt0000001", PrimaryTitle: "First", Type: "MOVIE"},
+	{ID: "tt0000002", PrimaryTitle: "Second", Type: "TV_SERIES"},
+	{ID: "tt0000003", PrimaryTitle: "Third", Type: "MOVIE"},
+	{ID: "tt0000004", PrimaryTitle: "Fourth", Type: "TV_SERIES"},
+	{ID: "tt0000005", PrimaryTitle: "Fifth", Type: "MOVIE"},
+}
+
+func getTitlesPage(t *testing.T, params url.Values) (data []byte, err error) {
+	t.Helper()
+
+	var matching []*models.ImdbapiTitle
+	for _, title := range listTitles {
+		if types := params["types"]; len(types) > 0 && !slices.Contains(types, title.Type) {
+			continue
+		}
+		matching = append(matching, title)
+	}
+
+	titles, next, err := getPage(matching, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(models.ImdbapiListTitlesResponse{
+		Titles:        titles,
+		NextPageToken: next,
+		TotalCount:    int32(len(matching)),
+	})
+}
+
+var listEpisodes = []*models.ImdbapiEpisode{
+	{ID: "tt1000021", Season: "2", EpisodeNumber: 1, Title: "Return", Rating: &models.ImdbapiRating{AggregateRating: 7.9}},
+	{ID: "tt1000012", Season: "1", EpisodeNumber: 2, Title: "Middle", Rating: &models.ImdbapiRating{AggregateRating: 8.1}},
+	{ID: "tt1000010", Season: "1", Title: "Unaired"},
+	{ID: "tt1000011", Season: "1", EpisodeNumber: 1, Title: "Pilot", Rating: &models.ImdbapiRating{AggregateRating: 8.4}, ReleaseDate: &models.ImdbapiPrecisionDate{Year: 2016, Month: 7, Day: 15}},
+	{ID: "tt1000001", Season: "Specials", Title: "Behind the Scenes"},
+}
+
+var listCredits = []*models.ImdbapiCredit{
+	{Category: "actor", Name: &models.ImdbapiName{ID: "nm0000001"}},
+	{Category: "actress", Name: &models.ImdbapiName{ID: "nm0000002"}},
+	{Category: "director", Name: &models.ImdbapiName{ID: "nm0000003"}},
+}
+
+// getPage cuts items into pages of two, the page token being the offset of
+// the next page.
+func getPage[T any](items []*T, params url.Values) (page []*T, next string, err error) {
+	const pageSize = 2
+
+	offset := 0
+	if token := params.Get("pageToken"); token != "" {
+		if offset, err = strconv.Atoi(token); err != nil {
+			return nil, "", fmt.Errorf("invalid page token %q", token)
+		}
+	}
+
+	end := min(offset+pageSize, len(items))
+	if offset < end {
+		page = items[offset:end]
+	}
+	if end < len(items) {
+		next = strconv.Itoa(end)
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// RecordEnv set to 1 makes UseCassette record from the network.
+const RecordEnv = "IMDBLOOKUP_RECORD"
+
+// RecordAPIEnv is the api a test records from when not the real one, such
+// as a local cmd/fakeimdbapi. Cassettes leave the host out, so they replay
+// the same whichever was recorded.
+const RecordAPIEnv = "IMDBLOOKUP_RECORD_API"
+
+// CassettePath is testdata/cassettes/<name>.json in this directory, which
+// is where UseCassette reads and writes.
+func CassettePath(name string) string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "testdata", "cassettes", name+".json")
+}
+
+// UseCassette is the transport for a test replaying the cassette name. With
+// IMDBLOOKUP_RECORD=1 it records from the network instead and saves the
+// cassette when the test ends, unless it failed. A cassette missing when
+// replaying fails the test, as it is meant to be committed.
+func UseCassette(t testing.TB, name string, match Match) http.RoundTripper {
+	t.Helper()
+	path := CassettePath(name)
+
+	if os.Getenv(RecordEnv) == "1" {
+		recorder := NewRecorder(path, nil)
+		t.Cleanup(func() {
+			if t.Failed() {
+				t.Logf("not saving cassette %s of a failed test", name)
+				return
+			}
+			if err := recorder.Save(); err != nil {
+				t.Errorf("saving cassette %s: %v", name, err)
+			}
+		})
+		return recorder
+	}
+
+	replayer, err := NewReplayer(path, match)
+	if err != nil {
+		t.Fatalf("loading cassette %s, record it with %s=1: %v", name, RecordEnv, err)
+	}
+	return replayer
+}